@@ -33,6 +33,8 @@ type Bucket struct {
 	page     *page              // inline page reference
 	rootNode *node              // materialized node for the root page.
 	nodes    map[pgid]*node     // node cache
+	parent   *Bucket            // parent bucket, in writable transactions
+	name     []byte             // name in the parent bucket
 
 	// Sets the threshold for filling nodes when they split. By default,
 	// the bucket will fill to 50% but it can be useful to increase this
@@ -112,12 +114,22 @@ func (b *Bucket) Bucket(name []byte) *Bucket {
 	// Otherwise create a bucket and cache it.
 	var child = b.openBucket(v)
 	if b.buckets != nil {
+		child.parent, child.name = b, cloneBytes(name)
 		b.buckets[string(name)] = child
 	}
 
 	return child
 }
 
+// path returns the names of the bucket and of its parents, from the root.
+func (b *Bucket) path() [][]byte {
+	var path [][]byte
+	for ; b.parent != nil; b = b.parent {
+		path = append([][]byte{b.name}, path...)
+	}
+	return path
+}
+
 // Helper method that re-interprets a sub-bucket value
 // from a parent into a Bucket
 func (b *Bucket) openBucket(value []byte) *Bucket {
@@ -250,6 +262,8 @@ func (b *Bucket) DeleteBucket(key []byte) error {
 	// Delete the node if we have a matching key.
 	c.node().del(key)
 
+	b.tx.trackDeleteBucket(append(b.path(), cloneBytes(key)))
+
 	return nil
 }
 
@@ -301,6 +315,8 @@ func (b *Bucket) Put(key []byte, value []byte) error {
 	key = cloneBytes(key)
 	c.node().put(key, key, value, 0, 0)
 
+	b.tx.trackKey(b, key, false)
+
 	return nil
 }
 
@@ -331,6 +347,8 @@ func (b *Bucket) Delete(key []byte) error {
 	// Delete the node if we have a matching key.
 	c.node().del(key)
 
+	b.tx.trackKey(b, key, true)
+
 	return nil
 }
 
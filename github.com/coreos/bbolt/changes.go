@@ -0,0 +1,115 @@
+package bbolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// BucketChanges represents the keys of a bucket changed by a transaction.
+type BucketChanges struct {
+	// Path holds the names of the bucket and of its parents, from the root.
+	Path [][]byte
+
+	// Deleted is set if the bucket was deleted. Keys then only holds the
+	// changes made after it was created again, if it was.
+	Deleted bool
+
+	// Keys holds the last change of each key, sorted by key.
+	Keys []KeyChange
+}
+
+// KeyChange represents a key changed by a transaction.
+type KeyChange struct {
+	Key     []byte
+	Deleted bool // set if the key was deleted, otherwise its value was put
+}
+
+// bucketChanges tracks the changes of a bucket during a transaction.
+type bucketChanges struct {
+	path    [][]byte
+	deleted bool
+	keys    map[string]bool // deleted flag by key
+}
+
+// trackKey records a change of a key of a bucket, if changes are tracked.
+func (tx *Tx) trackKey(b *Bucket, key []byte, deleted bool) {
+	if tx.changes == nil {
+		return
+	}
+	tx.bucketChanges(b.path()).keys[string(key)] = deleted
+}
+
+// trackDeleteBucket records the deletion of a bucket, which drops the changes
+// of the bucket and of its nested buckets, if changes are tracked.
+func (tx *Tx) trackDeleteBucket(path [][]byte) {
+	if tx.changes == nil {
+		return
+	}
+	id := pathID(path)
+	for other := range tx.changes {
+		if strings.HasPrefix(other, id) {
+			delete(tx.changes, other)
+		}
+	}
+	tx.bucketChanges(path).deleted = true
+}
+
+// bucketChanges returns the changes of the bucket at path.
+func (tx *Tx) bucketChanges(path [][]byte) *bucketChanges {
+	id := pathID(path)
+	bc := tx.changes[id]
+	if bc == nil {
+		bc = &bucketChanges{path: path, keys: make(map[string]bool)}
+		tx.changes[id] = bc
+	}
+	return bc
+}
+
+// changeSet returns the tracked changes, sorted by bucket path.
+func (tx *Tx) changeSet() []BucketChanges {
+	changes := make([]BucketChanges, 0, len(tx.changes))
+	for _, bc := range tx.changes {
+		c := BucketChanges{Path: bc.path, Deleted: bc.deleted}
+		for key, deleted := range bc.keys {
+			c.Keys = append(c.Keys, KeyChange{Key: []byte(key), Deleted: deleted})
+		}
+		sort.Slice(c.Keys, func(i, j int) bool {
+			return bytes.Compare(c.Keys[i].Key, c.Keys[j].Key) == -1
+		})
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return comparePaths(changes[i].Path, changes[j].Path) == -1
+	})
+	return changes
+}
+
+// pathID returns a map key for a bucket path, where the key of a bucket is
+// prefixed by the keys of its parents.
+func pathID(path [][]byte) string {
+	var buf bytes.Buffer
+	var n [binary.MaxVarintLen64]byte
+	for _, name := range path {
+		buf.Write(n[:binary.PutUvarint(n[:], uint64(len(name)))])
+		buf.Write(name)
+	}
+	return buf.String()
+}
+
+// comparePaths compares bucket paths name by name.
+func comparePaths(a, b [][]byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := bytes.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
@@ -146,6 +146,8 @@ func (c *Cursor) Delete() error {
 	}
 	c.node().del(key)
 
+	c.bucket.tx.trackKey(c.bucket, key, true)
+
 	return nil
 }
 
@@ -0,0 +1,197 @@
+package bbolt
+
+import "bytes"
+
+// Index represents a secondary index of the values of a bucket.
+//
+// The index is stored in a bucket of the root named after the index, where
+// each secondary key is a nested bucket holding the keys of the values it was
+// extracted from. Values must be changed with PutIndexed and DeleteIndexed to
+// keep their indexes up to date within the same transaction.
+type Index struct {
+	// Name is the name of the bucket of the index.
+	Name []byte
+
+	// Extract returns the secondary keys of a value of the indexed bucket.
+	// Secondary keys must not be blank, and they may reference the value.
+	Extract func(key, value []byte) ([][]byte, error)
+}
+
+// PutIndexed sets the value for a key in the bucket like Put, and updates
+// the indexes of the bucket accordingly.
+// Returns an error if Put or an extractor fails, in which case the
+// transaction should be rolled back.
+func PutIndexed(b *Bucket, key []byte, value []byte, indexes ...*Index) error {
+	old, exists := b.lookup(key)
+
+	// Extract all secondary keys before changing anything.
+	var olds, news [][][]byte
+	for _, idx := range indexes {
+		var oldKeys [][]byte
+		if exists {
+			var err error
+			if oldKeys, err = idx.Extract(key, old); err != nil {
+				return err
+			}
+		}
+		newKeys, err := idx.Extract(key, value)
+		if err != nil {
+			return err
+		}
+		olds, news = append(olds, oldKeys), append(news, newKeys)
+	}
+
+	if err := b.Put(key, value); err != nil {
+		return err
+	}
+	for i, idx := range indexes {
+		if err := idx.update(b.tx, key, olds[i], news[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteIndexed removes a key from the bucket like Delete, and removes it
+// from the indexes of the bucket.
+// Returns an error if Delete or an extractor fails, in which case the
+// transaction should be rolled back.
+func DeleteIndexed(b *Bucket, key []byte, indexes ...*Index) error {
+	old, exists := b.lookup(key)
+	if !exists {
+		return b.Delete(key)
+	}
+
+	var olds [][][]byte
+	for _, idx := range indexes {
+		oldKeys, err := idx.Extract(key, old)
+		if err != nil {
+			return err
+		}
+		olds = append(olds, oldKeys)
+	}
+
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+	for i, idx := range indexes {
+		if err := idx.update(b.tx, key, olds[i], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bucket returns the bucket of the index, whose nested buckets are named
+// after the secondary keys.
+// Returns nil if the index is empty.
+func (idx *Index) Bucket(tx *Tx) *Bucket {
+	return tx.Bucket(idx.Name)
+}
+
+// ForEach executes a function for each key of the values with the secondary
+// key skey, in key order.
+// If the provided function returns an error then the iteration is stopped and
+// the error is returned to the caller.
+func (idx *Index) ForEach(tx *Tx, skey []byte, fn func(key []byte) error) error {
+	ib := tx.Bucket(idx.Name)
+	if ib == nil {
+		return nil
+	}
+	sb := ib.Bucket(skey)
+	if sb == nil {
+		return nil
+	}
+	return sb.ForEach(func(k, _ []byte) error {
+		return fn(k)
+	})
+}
+
+// Rebuild recreates the index from the values of the bucket, eg. to index
+// the existing values of a new index.
+// Nested buckets are ignored.
+func (idx *Index) Rebuild(b *Bucket) error {
+	if err := b.tx.DeleteBucket(idx.Name); err != nil && err != ErrBucketNotFound {
+		return err
+	}
+	return b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			return nil
+		}
+		keys, err := idx.Extract(k, v)
+		if err != nil {
+			return err
+		}
+		return idx.update(b.tx, k, nil, keys)
+	})
+}
+
+// update moves key from the secondary keys olds to the secondary keys news.
+func (idx *Index) update(tx *Tx, key []byte, olds, news [][]byte) error {
+	removed, added := diffKeys(olds, news), diffKeys(news, olds)
+	if len(removed) == 0 && len(added) == 0 {
+		return nil
+	}
+
+	ib, err := tx.CreateBucketIfNotExists(idx.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, skey := range removed {
+		sb := ib.Bucket(skey)
+		if sb == nil {
+			continue
+		}
+		if err := sb.Delete(key); err != nil {
+			return err
+		}
+		// Drop the secondary key with its last value.
+		if k, _ := sb.Cursor().First(); k == nil {
+			if err := ib.DeleteBucket(skey); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, skey := range added {
+		sb, err := ib.CreateBucketIfNotExists(skey)
+		if err != nil {
+			return err
+		}
+		if err := sb.Put(key, []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffKeys returns the distinct keys of a which are not in b.
+func diffKeys(a, b [][]byte) [][]byte {
+	var diff [][]byte
+outer:
+	for i, k := range a {
+		for _, other := range b {
+			if bytes.Equal(k, other) {
+				continue outer
+			}
+		}
+		for _, other := range a[:i] {
+			if bytes.Equal(k, other) {
+				continue outer
+			}
+		}
+		diff = append(diff, k)
+	}
+	return diff
+}
+
+// lookup returns the value of a key of the bucket, and whether it exists.
+// Nested buckets are not values.
+func (b *Bucket) lookup(key []byte) ([]byte, bool) {
+	k, v, flags := b.Cursor().seek(key)
+	if !bytes.Equal(key, k) || (flags&bucketLeafFlag) != 0 {
+		return nil, false
+	}
+	return v, true
+}
@@ -0,0 +1,163 @@
+package bbolt_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// colorIndex indexes "name:color,color,..." values by color.
+var colorIndex = &bolt.Index{
+	Name: []byte("widgets_by_color"),
+	Extract: func(key, value []byte) ([][]byte, error) {
+		i := bytes.IndexByte(value, ':')
+		if i == -1 {
+			return nil, errors.New("missing colors")
+		}
+		if i == len(value)-1 {
+			return nil, nil
+		}
+		return bytes.Split(value[i+1:], []byte(",")), nil
+	},
+}
+
+// mustIndexKeys returns the keys indexed under each secondary key.
+func mustIndexKeys(t *testing.T, tx *bolt.Tx, idx *bolt.Index) map[string][]string {
+	keys := make(map[string][]string)
+	ib := idx.Bucket(tx)
+	if ib == nil {
+		return keys
+	}
+	if err := ib.ForEach(func(skey, _ []byte) error {
+		return idx.ForEach(tx, skey, func(key []byte) error {
+			keys[string(skey)] = append(keys[string(skey)], string(key))
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// Ensure that indexes follow the values put and deleted in a bucket.
+func TestIndex_PutDelete(t *testing.T) {
+	db := MustOpenDB()
+	defer db.MustClose()
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("widgets"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range map[string]string{"1": "foo:red,blue", "2": "bar:blue", "3": "baz:red,red"} {
+			if err := bolt.PutIndexed(b, []byte(k), []byte(v), colorIndex); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.View(func(tx *bolt.Tx) error {
+		expected := map[string][]string{"blue": {"1", "2"}, "red": {"1", "3"}}
+		if keys := mustIndexKeys(t, tx, colorIndex); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected index: %v", keys)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("widgets"))
+		if err := bolt.PutIndexed(b, []byte("1"), []byte("foo:green"), colorIndex); err != nil {
+			t.Fatal(err)
+		}
+		if err := bolt.DeleteIndexed(b, []byte("2"), colorIndex); err != nil {
+			t.Fatal(err)
+		}
+		if err := bolt.DeleteIndexed(b, []byte("missing"), colorIndex); err != nil {
+			t.Fatal(err)
+		}
+
+		// Empty secondary keys are dropped.
+		expected := map[string][]string{"green": {"1"}, "red": {"3"}}
+		if keys := mustIndexKeys(t, tx, colorIndex); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected index: %v", keys)
+		}
+		if v := b.Get([]byte("2")); v != nil {
+			t.Fatalf("unexpected value: %s", v)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure that an extractor error leaves the bucket and its index unchanged.
+func TestIndex_PutIndexed_ExtractError(t *testing.T) {
+	db := MustOpenDB()
+	defer db.MustClose()
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("widgets"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bolt.PutIndexed(b, []byte("1"), []byte("foo:red"), colorIndex); err != nil {
+			t.Fatal(err)
+		}
+		if err := bolt.PutIndexed(b, []byte("1"), []byte("invalid"), colorIndex); err == nil || err.Error() != "missing colors" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v := b.Get([]byte("1")); string(v) != "foo:red" {
+			t.Fatalf("unexpected value: %s", v)
+		}
+		expected := map[string][]string{"red": {"1"}}
+		if keys := mustIndexKeys(t, tx, colorIndex); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected index: %v", keys)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure that an index can be rebuilt from the values of a bucket.
+func TestIndex_Rebuild(t *testing.T) {
+	db := MustOpenDB()
+	defer db.MustClose()
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("widgets"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Put([]byte("1"), []byte("foo:red")); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Put([]byte("2"), []byte("bar:red,blue")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.CreateBucket([]byte("nested")); err != nil {
+			t.Fatal(err)
+		}
+		return colorIndex.Rebuild(b)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.View(func(tx *bolt.Tx) error {
+		expected := map[string][]string{"blue": {"2"}, "red": {"1", "2"}}
+		if keys := mustIndexKeys(t, tx, colorIndex); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected index: %v", keys)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
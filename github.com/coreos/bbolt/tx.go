@@ -30,6 +30,8 @@ type Tx struct {
 	pages          map[pgid]*page
 	stats          TxStats
 	commitHandlers []func()
+	changeHandlers []func([]BucketChanges)
+	changes        map[string]*bucketChanges
 
 	// WriteFlag specifies the flag for write-related methods like WriteTo().
 	// Tx opens the database file with the specified flag to copy the data.
@@ -135,6 +137,17 @@ func (tx *Tx) OnCommit(fn func()) {
 	tx.commitHandlers = append(tx.commitHandlers, fn)
 }
 
+// OnCommitChanges adds a handler function to be executed after the transaction
+// successfully commits, with the keys it changed in each bucket.
+// Changes are only tracked once a handler is added, so it must be added before
+// making the changes it should be notified of.
+func (tx *Tx) OnCommitChanges(fn func(changes []BucketChanges)) {
+	if tx.changes == nil {
+		tx.changes = make(map[string]*bucketChanges)
+	}
+	tx.changeHandlers = append(tx.changeHandlers, fn)
+}
+
 // Commit writes all changes to disk and updates the meta page.
 // Returns an error if a disk write error occurs, or if Commit is
 // called on a read-only transaction.
@@ -218,6 +231,12 @@ func (tx *Tx) Commit() error {
 	for _, fn := range tx.commitHandlers {
 		fn()
 	}
+	if len(tx.changeHandlers) > 0 {
+		changes := tx.changeSet()
+		for _, fn := range tx.changeHandlers {
+			fn(changes)
+		}
+	}
 
 	return nil
 }
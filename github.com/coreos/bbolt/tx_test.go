@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"reflect"
 	"testing"
 
 	bolt "go.etcd.io/bbolt"
@@ -529,6 +530,108 @@ func TestTx_OnCommit_Rollback(t *testing.T) {
 	}
 }
 
+// Ensure that Tx change handlers receive the keys changed in each bucket.
+func TestTx_OnCommitChanges(t *testing.T) {
+	db := MustOpenDB()
+	defer db.MustClose()
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("widgets"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range []string{"baz", "foo", "old"} {
+			if err := b.Put([]byte(k), []byte("0000")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := tx.CreateBucket([]byte("dropped")); err != nil {
+			t.Fatal(err)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []bolt.BucketChanges
+	if err := db.Update(func(tx *bolt.Tx) error {
+		tx.OnCommitChanges(func(c []bolt.BucketChanges) { changes = c })
+
+		b := tx.Bucket([]byte("widgets"))
+		if err := b.Put([]byte("foo"), []byte("0001")); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Put([]byte("bar"), []byte("0001")); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Delete([]byte("baz")); err != nil {
+			t.Fatal(err)
+		}
+		c := b.Cursor()
+		if k, _ := c.Seek([]byte("old")); string(k) != "old" {
+			t.Fatalf("unexpected key: %s", k)
+		}
+		if err := c.Delete(); err != nil {
+			t.Fatal(err)
+		}
+
+		nested, err := b.CreateBucket([]byte("nested"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := nested.Put([]byte("x"), []byte("1")); err != nil {
+			t.Fatal(err)
+		}
+
+		dropped := tx.Bucket([]byte("dropped"))
+		if err := dropped.Put([]byte("y"), []byte("1")); err != nil {
+			t.Fatal(err)
+		}
+		return tx.DeleteBucket([]byte("dropped"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []bolt.BucketChanges{
+		{Path: [][]byte{[]byte("dropped")}, Deleted: true},
+		{Path: [][]byte{[]byte("widgets")}, Keys: []bolt.KeyChange{
+			{Key: []byte("bar")},
+			{Key: []byte("baz"), Deleted: true},
+			{Key: []byte("foo")},
+			{Key: []byte("old"), Deleted: true},
+		}},
+		{Path: [][]byte{[]byte("widgets"), []byte("nested")}, Keys: []bolt.KeyChange{
+			{Key: []byte("x")},
+		}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+}
+
+// Ensure that Tx change handlers are NOT called after a transaction rolls back.
+func TestTx_OnCommitChanges_Rollback(t *testing.T) {
+	db := MustOpenDB()
+	defer db.MustClose()
+
+	var called bool
+	if err := db.Update(func(tx *bolt.Tx) error {
+		tx.OnCommitChanges(func([]bolt.BucketChanges) { called = true })
+		b, err := tx.CreateBucket([]byte("widgets"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Put([]byte("foo"), []byte("bar")); err != nil {
+			t.Fatal(err)
+		}
+		return errors.New("rollback this commit")
+	}); err == nil || err.Error() != "rollback this commit" {
+		t.Fatalf("unexpected error: %s", err)
+	} else if called {
+		t.Fatal("unexpected change handler call")
+	}
+}
+
 // Ensure that the database can be copied to a file path.
 func TestTx_CopyFile(t *testing.T) {
 	db := MustOpenDB()
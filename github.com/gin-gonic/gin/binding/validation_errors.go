@@ -0,0 +1,206 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/go-playground/validator.v8"
+)
+
+// ValidationError describes a single field that failed validation in terms
+// an API client understands: the field is named after its json/form tag and
+// the failure carries a stable code next to a localized message.
+type ValidationError struct {
+	// Field is the path of the field using tag names, e.g. "address.city" or "items[0].name".
+	Field string `json:"field"`
+	// Code is a stable, machine readable code derived from the validator tag, e.g. "required".
+	Code string `json:"code"`
+	// Param is the validator parameter, e.g. "3" for min=3.
+	Param string `json:"param,omitempty"`
+	// Message is the human readable message in the requested language.
+	Message string `json:"message"`
+}
+
+// ValidationErrors is a list of ValidationError sorted by field.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Field+": "+err.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validationCodes maps validator tags to the codes reported in ValidationError.Code.
+// Tags that are not listed are reported as "invalid_<tag>".
+var validationCodes = map[string]string{
+	"required":    "required",
+	"len":         "invalid_length",
+	"min":         "too_small",
+	"max":         "too_large",
+	"eq":          "not_equal",
+	"ne":          "equal",
+	"lt":          "too_large",
+	"lte":         "too_large",
+	"gt":          "too_small",
+	"gte":         "too_small",
+	"eqfield":     "field_mismatch",
+	"nefield":     "field_match",
+	"gtfield":     "too_small",
+	"gtefield":    "too_small",
+	"ltfield":     "too_large",
+	"ltefield":    "too_large",
+	"alpha":       "invalid_format",
+	"alphanum":    "invalid_format",
+	"numeric":     "invalid_format",
+	"number":      "invalid_format",
+	"hexadecimal": "invalid_format",
+	"email":       "invalid_email",
+	"url":         "invalid_url",
+	"uri":         "invalid_uri",
+	"uuid":        "invalid_uuid",
+	"ip":          "invalid_ip",
+	"ipv4":        "invalid_ip",
+	"ipv6":        "invalid_ip",
+	"contains":    "missing_substring",
+	"excludes":    "forbidden_substring",
+}
+
+// ValidationCode returns the stable error code reported for a validator tag.
+func ValidationCode(tag string) string {
+	if code, ok := validationCodes[tag]; ok {
+		return code
+	}
+	return "invalid_" + tag
+}
+
+// TagName returns the struct tag that names fields for the given binding,
+// e.g. "json" for binding.JSON and "form" for binding.Form.
+func TagName(b Binding) string {
+	switch b {
+	case Form, Query, FormPost, FormMultipart:
+		return "form"
+	case XML:
+		return "xml"
+	case YAML:
+		return "yaml"
+	default:
+		return "json"
+	}
+}
+
+// TranslateErrors converts the validator.ValidationErrors returned when
+// binding obj into ValidationErrors. Field paths use tagName (see TagName) and
+// messages are localized in lang, falling back to English. The second result
+// is false when err does not come from the validator.
+func TranslateErrors(err error, obj interface{}, tagName, lang string) (ValidationErrors, bool) {
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return nil, false
+	}
+	typ := reflect.TypeOf(obj)
+	errs := make(ValidationErrors, 0, len(verrs))
+	for _, fe := range verrs {
+		field := fieldPath(typ, fe.FieldNamespace, tagName)
+		errs = append(errs, ValidationError{
+			Field:   field,
+			Code:    ValidationCode(fe.Tag),
+			Param:   fe.Param,
+			Message: translate(lang, field, fe),
+		})
+	}
+	sort.Sort(byField(errs))
+	return errs, true
+}
+
+type byField ValidationErrors
+
+func (s byField) Len() int      { return len(s) }
+func (s byField) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byField) Less(i, j int) bool {
+	if s[i].Field == s[j].Field {
+		return s[i].Code < s[j].Code
+	}
+	return s[i].Field < s[j].Field
+}
+
+// fieldPath rewrites a validator namespace such as "User.Items[0].Name" into
+// the tag based path "items[0].name". Segments that cannot be resolved keep
+// their Go name.
+func fieldPath(typ reflect.Type, namespace, tagName string) string {
+	segments := splitNamespace(namespace)
+	if len(segments) > 0 {
+		// the first segment is the name of the validated struct itself
+		segments = segments[1:]
+	}
+	path := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		name, index := seg, ""
+		if i := strings.IndexByte(seg, '['); i >= 0 {
+			name, index = seg[:i], seg[i:]
+		}
+		typ = indirectType(typ)
+		if typ != nil && typ.Kind() == reflect.Struct {
+			if sf, ok := typ.FieldByName(name); ok {
+				name = fieldTagName(sf, tagName)
+				typ = sf.Type
+			} else {
+				typ = nil
+			}
+		} else {
+			typ = nil
+		}
+		for n := strings.Count(index, "["); n > 0 && typ != nil; n-- {
+			typ = indirectType(typ)
+			switch typ.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				typ = typ.Elem()
+			default:
+				typ = nil
+			}
+		}
+		path = append(path, name+index)
+	}
+	return strings.Join(path, ".")
+}
+
+// splitNamespace splits on dots that are not inside map key brackets.
+func splitNamespace(namespace string) []string {
+	var segments []string
+	depth, start := 0, 0
+	for i := 0; i < len(namespace); i++ {
+		switch namespace[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				segments = append(segments, namespace[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(segments, namespace[start:])
+}
+
+func fieldTagName(sf reflect.StructField, tagName string) string {
+	name := strings.SplitN(sf.Tag.Get(tagName), ",", 2)[0]
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
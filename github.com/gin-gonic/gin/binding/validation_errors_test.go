@@ -0,0 +1,90 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validationAddress struct {
+	City string `json:"city" form:"address_city" binding:"required"`
+}
+
+type validationItem struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type validationUser struct {
+	Name    string             `json:"name" form:"user_name" binding:"required,min=3"`
+	Age     int                `json:"age" binding:"min=18"`
+	Email   string             `json:"email,omitempty" binding:"omitempty,email"`
+	Address *validationAddress `json:"address" binding:"required"`
+	Items   []validationItem   `json:"items" binding:"dive"`
+	NoTag   string             `binding:"required"`
+}
+
+func TestTranslateErrors(t *testing.T) {
+	obj := &validationUser{
+		Name:    "ab",
+		Age:     10,
+		Email:   "nope",
+		Address: &validationAddress{},
+		Items:   []validationItem{{Name: "ok"}, {}},
+		NoTag:   "",
+	}
+	errs, ok := TranslateErrors(validate(obj), obj, "json", "en")
+	assert.True(t, ok)
+	assert.Equal(t, ValidationErrors{
+		{Field: "NoTag", Code: "required", Message: "NoTag is required"},
+		{Field: "address.city", Code: "required", Message: "address.city is required"},
+		{Field: "age", Code: "too_small", Param: "18", Message: "age must be 18 or greater"},
+		{Field: "email", Code: "invalid_email", Message: "email must be a valid email address"},
+		{Field: "items[1].name", Code: "required", Message: "items[1].name is required"},
+		{Field: "name", Code: "too_small", Param: "3", Message: "name must be at least 3 characters long"},
+	}, errs)
+	assert.Contains(t, errs.Error(), "age: age must be 18 or greater")
+}
+
+func TestTranslateErrorsFormTagAndLanguage(t *testing.T) {
+	obj := validationUser{Name: "gin", Age: 18, Address: &validationAddress{}, NoTag: "x"}
+	errs, ok := TranslateErrors(validate(obj), obj, TagName(Form), "zh")
+	assert.True(t, ok)
+	assert.Equal(t, ValidationErrors{
+		{Field: "Address.address_city", Code: "required", Message: "Address.address_city为必填字段"},
+	}, errs[:1])
+
+	errs, _ = TranslateErrors(validate(obj), obj, "json", "fr")
+	assert.Equal(t, "address.city is required", errs[0].Message)
+}
+
+func TestTranslateErrorsNotValidation(t *testing.T) {
+	errs, ok := TranslateErrors(errors.New("unexpected EOF"), &validationUser{}, "json", "en")
+	assert.False(t, ok)
+	assert.Nil(t, errs)
+}
+
+func TestRegisterMessage(t *testing.T) {
+	assert.False(t, HasLanguage("de"))
+	RegisterMessage("de", "required", "{field} ist erforderlich")
+	assert.True(t, HasLanguage("DE"))
+
+	obj := validationAddress{}
+	errs, _ := TranslateErrors(validate(obj), obj, "json", "de")
+	assert.Equal(t, "city ist erforderlich", errs[0].Message)
+	assert.Equal(t, "invalid_hexcolor", ValidationCode("hexcolor"))
+}
+
+func TestTagName(t *testing.T) {
+	assert.Equal(t, "json", TagName(JSON))
+	assert.Equal(t, "form", TagName(Form))
+	assert.Equal(t, "form", TagName(Query))
+	assert.Equal(t, "form", TagName(FormMultipart))
+	assert.Equal(t, "xml", TagName(XML))
+	assert.Equal(t, "yaml", TagName(YAML))
+	assert.Equal(t, "json", TagName(MsgPack))
+}
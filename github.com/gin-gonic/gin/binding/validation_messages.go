@@ -0,0 +1,137 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/go-playground/validator.v8"
+)
+
+// DefaultLanguage is used when no message is registered for the requested language.
+const DefaultLanguage = "en"
+
+// Messages are templates in which {field} is replaced by the field path and
+// {param} by the validator parameter. A key is either a validator tag or, for
+// length based checks on strings, slices and maps, the tag followed by ".len".
+var (
+	messagesMu sync.RWMutex
+	messages   = map[string]map[string]string{
+		"en": {
+			"":            "{field} is invalid",
+			"required":    "{field} is required",
+			"len":         "{field} must be {param}",
+			"len.len":     "{field} must be {param} characters long",
+			"min":         "{field} must be {param} or greater",
+			"min.len":     "{field} must be at least {param} characters long",
+			"max":         "{field} must be {param} or less",
+			"max.len":     "{field} must be at most {param} characters long",
+			"eq":          "{field} must be equal to {param}",
+			"ne":          "{field} must not be equal to {param}",
+			"lt":          "{field} must be less than {param}",
+			"lte":         "{field} must be {param} or less",
+			"gt":          "{field} must be greater than {param}",
+			"gte":         "{field} must be {param} or greater",
+			"eqfield":     "{field} must be equal to {param}",
+			"nefield":     "{field} must not be equal to {param}",
+			"gtfield":     "{field} must be greater than {param}",
+			"gtefield":    "{field} must be greater than or equal to {param}",
+			"ltfield":     "{field} must be less than {param}",
+			"ltefield":    "{field} must be less than or equal to {param}",
+			"alpha":       "{field} can only contain letters",
+			"alphanum":    "{field} can only contain letters and numbers",
+			"numeric":     "{field} must be a valid numeric value",
+			"number":      "{field} must be a valid number",
+			"hexadecimal": "{field} must be a valid hexadecimal",
+			"email":       "{field} must be a valid email address",
+			"url":         "{field} must be a valid URL",
+			"uri":         "{field} must be a valid URI",
+			"uuid":        "{field} must be a valid UUID",
+			"ip":          "{field} must be a valid IP address",
+			"ipv4":        "{field} must be a valid IPv4 address",
+			"ipv6":        "{field} must be a valid IPv6 address",
+			"contains":    "{field} must contain '{param}'",
+			"excludes":    "{field} must not contain '{param}'",
+		},
+		"zh": {
+			"":            "{field}无效",
+			"required":    "{field}为必填字段",
+			"len":         "{field}必须等于{param}",
+			"len.len":     "{field}长度必须是{param}个字符",
+			"min":         "{field}最小只能为{param}",
+			"min.len":     "{field}长度必须至少为{param}个字符",
+			"max":         "{field}必须小于或等于{param}",
+			"max.len":     "{field}长度不能超过{param}个字符",
+			"eq":          "{field}必须等于{param}",
+			"ne":          "{field}不能等于{param}",
+			"lt":          "{field}必须小于{param}",
+			"lte":         "{field}必须小于或等于{param}",
+			"gt":          "{field}必须大于{param}",
+			"gte":         "{field}必须大于或等于{param}",
+			"eqfield":     "{field}必须等于{param}",
+			"nefield":     "{field}不能等于{param}",
+			"gtfield":     "{field}必须大于{param}",
+			"gtefield":    "{field}必须大于或等于{param}",
+			"ltfield":     "{field}必须小于{param}",
+			"ltefield":    "{field}必须小于或等于{param}",
+			"alpha":       "{field}只能包含字母",
+			"alphanum":    "{field}只能包含字母和数字",
+			"numeric":     "{field}必须是一个有效的数值",
+			"number":      "{field}必须是一个有效的数字",
+			"hexadecimal": "{field}必须是一个有效的十六进制",
+			"email":       "{field}必须是一个有效的邮箱",
+			"url":         "{field}必须是一个有效的URL",
+			"uri":         "{field}必须是一个有效的URI",
+			"uuid":        "{field}必须是一个有效的UUID",
+			"ip":          "{field}必须是一个有效的IP地址",
+			"ipv4":        "{field}必须是一个有效的IPv4地址",
+			"ipv6":        "{field}必须是一个有效的IPv6地址",
+			"contains":    "{field}必须包含文本'{param}'",
+			"excludes":    "{field}不能包含文本'{param}'",
+		},
+	}
+)
+
+// RegisterMessage adds or replaces the message template used for key in lang.
+// See the messages table for the template syntax and key format.
+func RegisterMessage(lang, key, template string) {
+	lang = strings.ToLower(lang)
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	if messages[lang] == nil {
+		messages[lang] = make(map[string]string)
+	}
+	messages[lang][key] = template
+}
+
+// HasLanguage reports whether messages are registered for lang.
+func HasLanguage(lang string) bool {
+	messagesMu.RLock()
+	_, ok := messages[strings.ToLower(lang)]
+	messagesMu.RUnlock()
+	return ok
+}
+
+func translate(lang, field string, fe *validator.FieldError) string {
+	keys := []string{fe.Tag, ""}
+	switch fe.Kind {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		keys = []string{fe.Tag + ".len", fe.Tag, ""}
+	}
+
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	for _, l := range []string{strings.ToLower(lang), DefaultLanguage} {
+		table := messages[l]
+		for _, key := range keys {
+			if tmpl, ok := table[key]; ok {
+				return strings.NewReplacer("{field}", field, "{param}", fe.Param).Replace(tmpl)
+			}
+		}
+	}
+	return field + " is invalid"
+}
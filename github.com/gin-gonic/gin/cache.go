@@ -0,0 +1,344 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultCacheTTL = time.Minute
+
+// CacheConfig defines the config for Cache middleware.
+type CacheConfig struct {
+	// WeakETag makes the middleware send weak validators (W/"...") instead of strong ones.
+	// Optional. Default value is false.
+	WeakETag bool
+
+	// Store keeps complete GET responses between requests, except those with
+	// a Vary header. When nil, responses are only tagged with an ETag and
+	// conditional requests are answered.
+	// Optional.
+	Store CacheStore
+
+	// TTL is how long a response is kept in Store.
+	// Optional. Default value is one minute.
+	TTL time.Duration
+
+	// KeyFunc builds the Store key of a request.
+	// Optional. Default value is gin.CacheKey.
+	KeyFunc func(c *Context) string
+
+	// TagsFunc returns extra tags a stored response is filed under, see CacheStore.InvalidateTags.
+	// Every response is always tagged with its route template (Context.FullPath).
+	// Optional.
+	TagsFunc func(c *Context) []string
+}
+
+// ETag returns a middleware that adds a strong ETag computed over the response
+// body to successful GET and HEAD responses, and answers If-None-Match and
+// If-Modified-Since with 304 Not Modified.
+func ETag() HandlerFunc {
+	return CacheWithConfig(CacheConfig{})
+}
+
+// Cache returns a middleware that behaves like ETag and additionally keeps
+// successful responses in store for ttl.
+func Cache(store CacheStore, ttl time.Duration) HandlerFunc {
+	return CacheWithConfig(CacheConfig{
+		Store: store,
+		TTL:   ttl,
+	})
+}
+
+// CacheKey is the default CacheConfig.KeyFunc. It returns the request path
+// followed by the query string with its parameters sorted by key.
+func CacheKey(c *Context) string {
+	query := c.Request.URL.Query().Encode()
+	if query == "" {
+		return c.Request.URL.Path
+	}
+	return c.Request.URL.Path + "?" + query
+}
+
+// CacheWithConfig returns a caching middleware with config.
+func CacheWithConfig(conf CacheConfig) HandlerFunc {
+	ttl := conf.TTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+
+	keyFunc := conf.KeyFunc
+	if keyFunc == nil {
+		keyFunc = CacheKey
+	}
+
+	return func(c *Context) {
+		method := c.Request.Method
+		if method != http.MethodGet && method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		var key string
+		if conf.Store != nil {
+			key = keyFunc(c)
+			if resp, err := conf.Store.Get(key); err == nil {
+				serveCachedResponse(c, resp)
+				c.Abort()
+				return
+			} else if err != ErrCacheMiss {
+				c.Error(err) // nolint: errcheck
+			}
+		}
+
+		w := &cacheWriter{ResponseWriter: c.Writer, status: defaultStatus, size: noWritten}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.passthrough {
+			return
+		}
+
+		header := w.Header()
+		if w.status != http.StatusOK {
+			w.flush()
+			return
+		}
+		if header.Get("ETag") == "" {
+			header.Set("ETag", computeETag(w.body.Bytes(), conf.WeakETag))
+		}
+
+		// HEAD responses have no body to serve to GET requests, while HEAD
+		// requests are served from stored GET responses
+		if conf.Store != nil && method == http.MethodGet && storableResponse(header) {
+			if header.Get("Last-Modified") == "" {
+				header.Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			}
+			tags := []string{c.FullPath()}
+			if conf.TagsFunc != nil {
+				tags = append(tags, conf.TagsFunc(c)...)
+			}
+			resp := &CachedResponse{
+				Status: w.status,
+				Header: cloneHeader(header),
+				Data:   append([]byte(nil), w.body.Bytes()...),
+			}
+			if err := conf.Store.Set(key, resp, ttl, tags...); err != nil {
+				c.Error(err) // nolint: errcheck
+			}
+		}
+
+		if notModified(c.Request, header) {
+			writeNotModified(w.ResponseWriter)
+			return
+		}
+		w.flush()
+	}
+}
+
+func serveCachedResponse(c *Context, resp *CachedResponse) {
+	header := c.Writer.Header()
+	for k, v := range resp.Header {
+		header[k] = v
+	}
+	if resp.Status == http.StatusOK && notModified(c.Request, header) {
+		writeNotModified(c.Writer)
+		return
+	}
+	c.Writer.WriteHeader(resp.Status)
+	c.Writer.WriteHeaderNow()
+	if c.Request.Method != http.MethodHead {
+		c.Writer.Write(resp.Data) // nolint: errcheck
+	}
+}
+
+func writeNotModified(w ResponseWriter) {
+	// RFC 7232 section 4.1: a 304 response carries the validators but no
+	// representation metadata.
+	header := w.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	w.WriteHeaderNow()
+}
+
+func computeETag(body []byte, weak bool) string {
+	sum := sha1.Sum(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// storableResponse reports whether the Cache-Control header of a response
+// allows a shared cache to keep it. Responses varying with request headers,
+// like compressed or negotiated ones, are not kept since the key only holds
+// the URL.
+func storableResponse(header http.Header) bool {
+	if len(header["Vary"]) > 0 {
+		return false
+	}
+	cc := strings.ToLower(header.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since against the
+// response validators. If-Modified-Since is ignored when If-None-Match is
+// present (RFC 7232 section 6).
+func notModified(req *http.Request, header http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return etagWeakMatch(inm, header.Get("ETag"))
+	}
+	ims := req.Header.Get("If-Modified-Since")
+	lm := header.Get("Last-Modified")
+	if ims == "" || lm == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lm)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// etagWeakMatch reports whether etag matches one of the entity tags in the
+// If-None-Match list using the weak comparison function.
+func etagWeakMatch(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneHeader(h http.Header) http.Header {
+	h2 := make(http.Header, len(h))
+	for k, v := range h {
+		h2[k] = append([]string(nil), v...)
+	}
+	return h2
+}
+
+// cacheWriter buffers a response so its validators can be computed before
+// anything is sent. Flush and Hijack switch it to passthrough mode, in which
+// the buffered data is sent and the response is neither validated nor cached.
+type cacheWriter struct {
+	ResponseWriter
+	status      int
+	size        int
+	body        bytes.Buffer
+	passthrough bool
+}
+
+var _ ResponseWriter = &cacheWriter{}
+
+func (w *cacheWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *cacheWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	if w.size == noWritten {
+		w.size = 0
+	}
+}
+
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	w.WriteHeaderNow()
+	n, err := w.body.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.WriteString(s)
+	}
+	w.WriteHeaderNow()
+	n, err := w.body.WriteString(s)
+	w.size += n
+	return n, err
+}
+
+func (w *cacheWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *cacheWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	return w.size
+}
+
+func (w *cacheWriter) Written() bool {
+	if w.passthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.size != noWritten
+}
+
+// Flush implements the http.Flush interface.
+func (w *cacheWriter) Flush() {
+	w.flush()
+	w.ResponseWriter.Flush()
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *cacheWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.passthrough = true
+	return w.ResponseWriter.Hijack()
+}
+
+// flush sends the buffered status and body and switches to passthrough mode.
+func (w *cacheWriter) flush() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.size == noWritten {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	if w.body.Len() > 0 {
+		io.Copy(w.ResponseWriter, &w.body) // nolint: errcheck
+	}
+}
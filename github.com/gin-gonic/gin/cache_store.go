@@ -0,0 +1,271 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCacheMiss is returned by CacheStore.Get when the key is not cached.
+var ErrCacheMiss = errors.New("gin: cache miss")
+
+// CachedResponse is a complete response kept by a CacheStore.
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Data   []byte
+}
+
+// CacheStore keeps responses for the Cache middleware.
+type CacheStore interface {
+	// Get returns the response stored under key, or ErrCacheMiss.
+	Get(key string) (*CachedResponse, error)
+
+	// Set stores resp under key for ttl and files it under the given tags.
+	Set(key string, resp *CachedResponse, ttl time.Duration, tags ...string) error
+
+	// Delete removes key from the store.
+	Delete(key string) error
+
+	// InvalidateTags removes every key filed under any of the given tags.
+	InvalidateTags(tags ...string) error
+}
+
+type memoryCacheEntry struct {
+	resp    *CachedResponse
+	expires time.Time
+	tags    []string
+}
+
+// MemoryCacheStore is a CacheStore that keeps responses in process memory.
+// Expired entries are evicted lazily on access and by Purge.
+type MemoryCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string]memoryCacheEntry
+	tags    map[string]map[string]struct{}
+}
+
+var _ CacheStore = &MemoryCacheStore{}
+
+// NewMemoryCacheStore returns an empty MemoryCacheStore.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{
+		entries: make(map[string]memoryCacheEntry),
+		tags:    make(map[string]map[string]struct{}),
+	}
+}
+
+// Get implements the CacheStore interface.
+func (s *MemoryCacheStore) Get(key string) (*CachedResponse, error) {
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	if time.Now().After(entry.expires) {
+		s.Delete(key) // nolint: errcheck
+		return nil, ErrCacheMiss
+	}
+	return entry.resp, nil
+}
+
+// Set implements the CacheStore interface.
+func (s *MemoryCacheStore) Set(key string, resp *CachedResponse, ttl time.Duration, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(key)
+	s.entries[key] = memoryCacheEntry{
+		resp:    resp,
+		expires: time.Now().Add(ttl),
+		tags:    tags,
+	}
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	return nil
+}
+
+// Delete implements the CacheStore interface.
+func (s *MemoryCacheStore) Delete(key string) error {
+	s.mu.Lock()
+	s.remove(key)
+	s.mu.Unlock()
+	return nil
+}
+
+// InvalidateTags implements the CacheStore interface.
+func (s *MemoryCacheStore) InvalidateTags(tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			s.remove(key)
+		}
+	}
+	return nil
+}
+
+// Purge evicts all expired entries.
+func (s *MemoryCacheStore) Purge() {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			s.remove(key)
+		}
+	}
+}
+
+// remove deletes key and its tag references. s.mu must be held.
+func (s *MemoryCacheStore) remove(key string) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	delete(s.entries, key)
+	for _, tag := range entry.tags {
+		keys := s.tags[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
+
+// RedisConn is the subset of a Redis connection used by RedisCacheStore.
+// A github.com/gomodule/redigo/redis.Conn satisfies it.
+type RedisConn interface {
+	Do(commandName string, args ...interface{}) (reply interface{}, err error)
+	Close() error
+}
+
+// RedisCacheStore is a CacheStore backed by Redis. Responses are stored with
+// SET PX and tags are kept as Redis sets expiring with their last entry, so
+// entries are shared by every process using the same server and prefix.
+type RedisCacheStore struct {
+	dial   func() (RedisConn, error)
+	prefix string
+}
+
+var _ CacheStore = &RedisCacheStore{}
+
+// NewRedisCacheStore returns a RedisCacheStore that obtains a connection from
+// dial for every operation and closes it afterwards. With a redigo pool:
+//     store := gin.NewRedisCacheStore(func() (gin.RedisConn, error) {
+//         return pool.Get(), nil
+//     }, "cache:")
+func NewRedisCacheStore(dial func() (RedisConn, error), prefix string) *RedisCacheStore {
+	assert1(dial != nil, "dial function can not be nil")
+	return &RedisCacheStore{dial: dial, prefix: prefix}
+}
+
+func (s *RedisCacheStore) do(commandName string, args ...interface{}) (interface{}, error) {
+	conn, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.Do(commandName, args...)
+}
+
+func (s *RedisCacheStore) tagKey(tag string) string {
+	return s.prefix + "tag:" + tag
+}
+
+// Get implements the CacheStore interface.
+func (s *RedisCacheStore) Get(key string) (*CachedResponse, error) {
+	reply, err := s.do("GET", s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	var resp CachedResponse
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Set implements the CacheStore interface.
+func (s *RedisCacheStore) Set(key string, resp *CachedResponse, ttl time.Duration, tags ...string) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(resp); err != nil {
+		return err
+	}
+	ms := int64(ttl / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	args := make([]interface{}, 0, len(tags)+6)
+	args = append(args, redisSetScript, len(tags)+1, s.prefix+key)
+	for _, tag := range tags {
+		args = append(args, s.tagKey(tag))
+	}
+	args = append(args, buf.Bytes(), ms, key)
+	_, err := s.do("EVAL", args...)
+	return err
+}
+
+// redisSetScript stores a response and adds its key to the tag sets in a
+// single atomic step. A tag set lives at least as long as its entries, so its
+// TTL is only ever extended.
+const redisSetScript = `
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+for i = 2, #KEYS do
+	redis.call('SADD', KEYS[i], ARGV[3])
+	if redis.call('PTTL', KEYS[i]) < tonumber(ARGV[2]) then
+		redis.call('PEXPIRE', KEYS[i], ARGV[2])
+	end
+end
+return 1
+`
+
+// Delete implements the CacheStore interface.
+func (s *RedisCacheStore) Delete(key string) error {
+	_, err := s.do("DEL", s.prefix+key)
+	return err
+}
+
+// InvalidateTags implements the CacheStore interface.
+func (s *RedisCacheStore) InvalidateTags(tags ...string) error {
+	conn, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for _, tag := range tags {
+		reply, err := conn.Do("SMEMBERS", s.tagKey(tag))
+		if err != nil {
+			return err
+		}
+		members, _ := reply.([]interface{})
+		args := make([]interface{}, 0, len(members)+1)
+		args = append(args, s.tagKey(tag))
+		for _, m := range members {
+			if key, ok := m.([]byte); ok {
+				args = append(args, s.prefix+string(key))
+			}
+		}
+		if _, err := conn.Do("DEL", args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	router := New()
+	router.Use(ETag())
+	router.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	w := performRequest(router, "GET", "/")
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+	assert.True(t, strings.HasPrefix(etag, `"`))
+
+	w = performRequest(router, "GET", "/", header{"If-None-Match", etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Type"))
+
+	w = performRequest(router, "GET", "/", header{"If-None-Match", `"other", W/` + etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = performRequest(router, "GET", "/", header{"If-None-Match", `"other"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+}
+
+func TestWeakETag(t *testing.T) {
+	router := New()
+	router.Use(CacheWithConfig(CacheConfig{WeakETag: true}))
+	router.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	w := performRequest(router, "GET", "/")
+	assert.True(t, strings.HasPrefix(w.Header().Get("ETag"), `W/"`))
+}
+
+func TestETagSkipsErrorsAndUnsafeMethods(t *testing.T) {
+	router := New()
+	router.Use(ETag())
+	router.GET("/fail", func(c *Context) {
+		c.String(http.StatusInternalServerError, "boom")
+	})
+	router.POST("/", func(c *Context) {
+		c.String(http.StatusOK, "posted")
+	})
+
+	w := performRequest(router, "GET", "/fail")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "boom", w.Body.String())
+	assert.Empty(t, w.Header().Get("ETag"))
+
+	w = performRequest(router, "POST", "/")
+	assert.Equal(t, "posted", w.Body.String())
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestETagIfModifiedSince(t *testing.T) {
+	modified := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	router := New()
+	router.Use(ETag())
+	router.GET("/", func(c *Context) {
+		c.Header("Last-Modified", modified.Format(http.TimeFormat))
+		c.String(http.StatusOK, "hello")
+	})
+
+	w := performRequest(router, "GET", "/", header{"If-Modified-Since", modified.Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = performRequest(router, "GET", "/", header{"If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCacheStoresResponses(t *testing.T) {
+	calls := 0
+	store := NewMemoryCacheStore()
+	router := New()
+	router.Use(Cache(store, time.Minute))
+	router.GET("/products/:id", func(c *Context) {
+		calls++
+		c.JSON(http.StatusOK, H{"id": c.Param("id"), "sort": c.Query("sort")})
+	})
+
+	w := performRequest(router, "GET", "/products/1?sort=asc&a=b")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+
+	w = performRequest(router, "GET", "/products/1?a=b&sort=asc")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	assert.Equal(t, MIMEJSON+"; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+	assert.Equal(t, 1, calls)
+
+	w = performRequest(router, "GET", "/products/1?a=b&sort=asc", header{"If-None-Match", w.Header().Get("ETag")})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, 1, calls)
+
+	performRequest(router, "GET", "/products/2")
+	assert.Equal(t, 2, calls)
+
+	assert.NoError(t, store.InvalidateTags("/products/:id"))
+	performRequest(router, "GET", "/products/1?a=b&sort=asc")
+	assert.Equal(t, 3, calls)
+}
+
+func TestCacheSkipsPrivateResponses(t *testing.T) {
+	calls := 0
+	router := New()
+	router.Use(Cache(NewMemoryCacheStore(), time.Minute))
+	router.GET("/", func(c *Context) {
+		calls++
+		c.Header("Cache-Control", "private")
+		c.String(http.StatusOK, "mine")
+	})
+
+	performRequest(router, "GET", "/")
+	performRequest(router, "GET", "/")
+	assert.Equal(t, 2, calls)
+}
+
+func TestCacheSkipsVaryingResponses(t *testing.T) {
+	calls := 0
+	router := New()
+	router.Use(Cache(NewMemoryCacheStore(), time.Minute), Compress())
+	router.GET("/", func(c *Context) {
+		calls++
+		c.String(http.StatusOK, strings.Repeat("a", 2048))
+	})
+
+	w := performRequest(router, "GET", "/", header{"Accept-Encoding", "gzip"})
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	w = performRequest(router, "GET", "/")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, strings.Repeat("a", 2048), w.Body.String())
+	assert.Equal(t, 2, calls)
+}
+
+func TestCacheStoresOnlyGetResponses(t *testing.T) {
+	calls := 0
+	router := New()
+	router.Use(Cache(NewMemoryCacheStore(), time.Minute))
+	router.HEAD("/", func(c *Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/", func(c *Context) {
+		calls++
+		c.String(http.StatusOK, "body")
+	})
+
+	performRequest(router, "HEAD", "/")
+	w := performRequest(router, "GET", "/")
+	assert.Equal(t, "body", w.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// HEAD requests are served from stored GET responses
+	w = performRequest(router, "HEAD", "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	w = performRequest(router, "GET", "/")
+	assert.Equal(t, "body", w.Body.String())
+	assert.Equal(t, 1, calls)
+}
+
+func TestCacheStreamingPassthrough(t *testing.T) {
+	store := NewMemoryCacheStore()
+	router := New()
+	router.Use(Cache(store, time.Minute))
+	router.GET("/stream", func(c *Context) {
+		c.String(http.StatusOK, "a")
+		c.Writer.Flush()
+		c.String(http.StatusOK, "b")
+	})
+
+	w := performRequest(router, "GET", "/stream")
+	assert.Equal(t, "ab", w.Body.String())
+	assert.True(t, w.Flushed)
+	assert.Empty(t, w.Header().Get("ETag"))
+	_, err := store.Get("/stream")
+	assert.Equal(t, ErrCacheMiss, err)
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore()
+	resp := &CachedResponse{Status: http.StatusOK, Data: []byte("x")}
+
+	assert.NoError(t, store.Set("a", resp, time.Minute, "t1"))
+	assert.NoError(t, store.Set("b", resp, time.Minute, "t1", "t2"))
+	assert.NoError(t, store.Set("c", resp, -time.Second))
+
+	got, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, resp, got)
+
+	_, err = store.Get("c")
+	assert.Equal(t, ErrCacheMiss, err)
+
+	assert.NoError(t, store.InvalidateTags("t2"))
+	_, err = store.Get("b")
+	assert.Equal(t, ErrCacheMiss, err)
+	_, err = store.Get("a")
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Delete("a"))
+	_, err = store.Get("a")
+	assert.Equal(t, ErrCacheMiss, err)
+	assert.Empty(t, store.tags)
+}
+
+// fakeRedis emulates the Redis commands used by RedisCacheStore, including
+// its EVAL script, and records the TTL of each key in milliseconds.
+type fakeRedis struct {
+	values map[string][]byte
+	sets   map[string]map[string]bool
+	ttls   map[string]int64
+	open   int
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: map[string][]byte{}, sets: map[string]map[string]bool{}, ttls: map[string]int64{}}
+}
+
+func (r *fakeRedis) dial() (RedisConn, error) {
+	r.open++
+	return fakeRedisConn{r}, nil
+}
+
+type fakeRedisConn struct{ r *fakeRedis }
+
+func (c fakeRedisConn) Close() error {
+	c.r.open--
+	return nil
+}
+
+func (c fakeRedisConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	r := c.r
+	switch commandName {
+	case "GET":
+		if v, ok := r.values[args[0].(string)]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case "SMEMBERS":
+		var members []interface{}
+		for m := range r.sets[args[0].(string)] {
+			members = append(members, []byte(m))
+		}
+		return members, nil
+	case "DEL":
+		for _, key := range args {
+			delete(r.values, key.(string))
+			delete(r.sets, key.(string))
+			delete(r.ttls, key.(string))
+		}
+		return int64(len(args)), nil
+	case "EVAL":
+		if args[0] != redisSetScript {
+			return nil, errors.New("unknown script")
+		}
+		keys := args[2 : 2+args[1].(int)]
+		argv := args[2+args[1].(int):]
+		ms := argv[1].(int64)
+		r.values[keys[0].(string)] = argv[0].([]byte)
+		r.ttls[keys[0].(string)] = ms
+		for _, key := range keys[1:] {
+			if r.sets[key.(string)] == nil {
+				r.sets[key.(string)] = map[string]bool{}
+			}
+			r.sets[key.(string)][argv[2].(string)] = true
+			if r.ttls[key.(string)] < ms {
+				r.ttls[key.(string)] = ms
+			}
+		}
+		return int64(1), nil
+	}
+	return nil, errors.New("unknown command " + commandName)
+}
+
+func TestRedisCacheStore(t *testing.T) {
+	r := newFakeRedis()
+	store := NewRedisCacheStore(r.dial, "cache:")
+	resp := &CachedResponse{Status: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}}, Data: []byte("x")}
+
+	assert.NoError(t, store.Set("a", resp, time.Minute, "t1"))
+	assert.NoError(t, store.Set("b", resp, time.Second, "t1", "t2"))
+	assert.NoError(t, store.Set("c", resp, time.Microsecond))
+
+	got, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, resp, got)
+	_, err = store.Get("missing")
+	assert.Equal(t, ErrCacheMiss, err)
+
+	// Tag sets expire with their last entry.
+	assert.Equal(t, int64(60000), r.ttls["cache:a"])
+	assert.Equal(t, int64(60000), r.ttls["cache:tag:t1"])
+	assert.Equal(t, int64(1000), r.ttls["cache:tag:t2"])
+	assert.Equal(t, int64(1), r.ttls["cache:c"])
+
+	assert.NoError(t, store.InvalidateTags("t2"))
+	_, err = store.Get("b")
+	assert.Equal(t, ErrCacheMiss, err)
+	_, err = store.Get("a")
+	assert.NoError(t, err)
+	assert.NotContains(t, r.sets, "cache:tag:t2")
+
+	assert.NoError(t, store.Delete("a"))
+	_, err = store.Get("a")
+	assert.Equal(t, ErrCacheMiss, err)
+	assert.Zero(t, r.open)
+}
@@ -0,0 +1,347 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultCompressMinLength = 1024
+
+var defaultCompressContentTypes = []string{
+	"text/*",
+	MIMEJSON,
+	MIMEXML,
+	MIMEYAML,
+	"application/javascript",
+	"image/svg+xml",
+}
+
+// Compressor creates the writer for one content coding. The returned writer
+// is closed once the response is complete; if it implements Flush() error it
+// is also flushed whenever the response is flushed.
+type Compressor struct {
+	// Encoding is the content coding token, such as "gzip" or "br".
+	Encoding string
+	// NewWriter wraps w with a compressing writer for the configured level.
+	NewWriter func(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+// GzipCompressor compresses responses with the gzip content coding.
+var GzipCompressor = Compressor{
+	Encoding: "gzip",
+	NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	},
+}
+
+// DeflateCompressor compresses responses with the deflate content coding.
+var DeflateCompressor = Compressor{
+	Encoding: "deflate",
+	NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	},
+}
+
+// CompressConfig defines the config for Compress middleware.
+type CompressConfig struct {
+	// Level is the compression level handed to Compressor.NewWriter.
+	// Optional. Default value is gzip.DefaultCompression.
+	Level int
+
+	// MinLength is the smallest response body, in bytes, that gets compressed.
+	// Flushed (streamed) responses are compressed regardless of their length.
+	// Optional. Default value is 1024.
+	MinLength int
+
+	// ContentTypes lists the media types that are compressed. An entry such as
+	// "text/*" matches every subtype.
+	// Optional. Default value is text/*, JSON, XML, YAML, JavaScript and SVG.
+	ContentTypes []string
+
+	// Compressors are the supported content codings in order of server
+	// preference. Brotli or other codings can be plugged in here.
+	// Optional. Default value is gin.GzipCompressor and gin.DeflateCompressor.
+	Compressors []Compressor
+}
+
+// Compress returns a middleware that compresses responses with gzip or deflate,
+// as accepted by the client's Accept-Encoding header.
+func Compress() HandlerFunc {
+	return CompressWithConfig(CompressConfig{})
+}
+
+// CompressWithConfig returns a compression middleware with config.
+func CompressWithConfig(conf CompressConfig) HandlerFunc {
+	level := conf.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	minLength := conf.MinLength
+	if minLength <= 0 {
+		minLength = defaultCompressMinLength
+	}
+
+	contentTypes := conf.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = defaultCompressContentTypes
+	}
+
+	compressors := conf.Compressors
+	if len(compressors) == 0 {
+		compressors = []Compressor{GzipCompressor, DeflateCompressor}
+	}
+
+	return func(c *Context) {
+		compressor, ok := negotiateEncoding(c.requestHeader("Accept-Encoding"), compressors)
+		if !ok || c.Request.Method == http.MethodHead || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			compressor:     compressor,
+			level:          level,
+			minLength:      minLength,
+			contentTypes:   contentTypes,
+			status:         defaultStatus,
+			size:           noWritten,
+		}
+		c.Writer = w
+		defer func() {
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+
+		if err := w.close(); err != nil {
+			c.Error(err) // nolint: errcheck
+		}
+	}
+}
+
+// negotiateEncoding picks the compressor with the highest quality value in
+// the Accept-Encoding header. Ties are broken by the order of compressors.
+func negotiateEncoding(header string, compressors []Compressor) (Compressor, bool) {
+	if header == "" {
+		return Compressor{}, false
+	}
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		coding, q := parseQuality(part)
+		if coding != "" {
+			qualities[coding] = q
+		}
+	}
+	var best Compressor
+	bestQ := 0.0
+	for _, compressor := range compressors {
+		q, ok := qualities[compressor.Encoding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = compressor, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// parseQuality splits an Accept-Encoding element such as "gzip;q=0.8".
+func parseQuality(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	coding := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		v, err := strconv.ParseFloat(param[2:], 64)
+		if err != nil {
+			return "", 0
+		}
+		q = v
+	}
+	return coding, q
+}
+
+func compressibleContentType(contentType string, allowed []string) bool {
+	contentType = strings.ToLower(filterFlags(contentType))
+	for _, t := range allowed {
+		if strings.HasSuffix(t, "/*") {
+			if strings.HasPrefix(contentType, t[:len(t)-1]) {
+				return true
+			}
+		} else if contentType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter holds back the start of a response until it knows whether
+// it should be compressed: once MinLength bytes were written, the response
+// is flushed, or the handler returns.
+type compressWriter struct {
+	ResponseWriter
+	compressor   Compressor
+	level        int
+	minLength    int
+	contentTypes []string
+
+	status  int
+	size    int
+	buf     bytes.Buffer
+	decided bool
+	encoder io.WriteCloser
+}
+
+var _ ResponseWriter = &compressWriter{}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	if w.size == noWritten {
+		w.size = 0
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	w.size += len(data)
+	if !w.decided {
+		w.buf.Write(data)
+		if w.buf.Len() < w.minLength {
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Status() int {
+	if w.decided {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+// Size returns the number of uncompressed bytes written by the handlers.
+func (w *compressWriter) Size() int {
+	return w.size
+}
+
+func (w *compressWriter) Written() bool {
+	return w.size != noWritten
+}
+
+// Flush implements the http.Flush interface.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true) // nolint: errcheck
+	}
+	if f, ok := w.encoder.(interface {
+		Flush() error
+	}); ok {
+		f.Flush() // nolint: errcheck
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// decide sends the response header, with or without a Content-Encoding,
+// followed by the buffered body.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	header := w.Header()
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" && w.buf.Len() > 0 {
+		contentType = http.DetectContentType(w.buf.Bytes())
+		header.Set("Content-Type", contentType)
+	}
+	compressible := compressibleContentType(contentType, w.contentTypes)
+	if compressible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+
+	if large && compressible && bodyAllowedForStatus(w.status) && header.Get("Content-Encoding") == "" {
+		encoder, err := w.compressor.NewWriter(w.ResponseWriter, w.level)
+		if err != nil {
+			return err
+		}
+		w.encoder = encoder
+		header.Set("Content-Encoding", w.compressor.Encoding)
+		header.Del("Content-Length")
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.size == noWritten {
+		return nil
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// close finishes the response once the handlers returned.
+func (w *compressWriter) close() error {
+	if !w.decided {
+		if err := w.decide(w.buf.Len() >= w.minLength); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
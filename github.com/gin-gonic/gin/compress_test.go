@@ -0,0 +1,132 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var largeBody = strings.Repeat("gin compresses this body. ", 100)
+
+func TestCompressGzip(t *testing.T) {
+	router := New()
+	router.Use(Compress())
+	router.GET("/", func(c *Context) {
+		c.String(http.StatusOK, largeBody)
+	})
+
+	w := performRequest(router, "GET", "/", header{"Accept-Encoding", "gzip, deflate"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, MIMEPlain+"; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, w.Body.Len() < len(largeBody))
+
+	gr, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, largeBody, string(body))
+}
+
+func TestCompressDeflatePreferredByQuality(t *testing.T) {
+	router := New()
+	router.Use(Compress())
+	router.GET("/", func(c *Context) {
+		c.String(http.StatusOK, largeBody)
+	})
+
+	w := performRequest(router, "GET", "/", header{"Accept-Encoding", "gzip;q=0.5, deflate"})
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+	body, err := ioutil.ReadAll(flate.NewReader(w.Body))
+	assert.NoError(t, err)
+	assert.Equal(t, largeBody, string(body))
+}
+
+func TestCompressSkips(t *testing.T) {
+	router := New()
+	router.Use(Compress())
+	router.GET("/small", func(c *Context) {
+		c.String(http.StatusOK, "tiny")
+	})
+	router.GET("/png", func(c *Context) {
+		c.Data(http.StatusOK, "image/png", []byte(largeBody))
+	})
+	router.GET("/large", func(c *Context) {
+		c.String(http.StatusOK, largeBody)
+	})
+
+	w := performRequest(router, "GET", "/small", header{"Accept-Encoding", "gzip"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "tiny", w.Body.String())
+
+	w = performRequest(router, "GET", "/png", header{"Accept-Encoding", "gzip"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Vary"))
+	assert.Equal(t, largeBody, w.Body.String())
+
+	w = performRequest(router, "GET", "/large")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, largeBody, w.Body.String())
+
+	w = performRequest(router, "GET", "/large", header{"Accept-Encoding", "gzip;q=0"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+func TestCompressStreaming(t *testing.T) {
+	router := New()
+	router.Use(CompressWithConfig(CompressConfig{MinLength: 1 << 20}))
+	router.GET("/stream", func(c *Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, "data: 1\n\n")
+		c.Writer.Flush()
+		c.String(http.StatusOK, "data: 2\n\n")
+	})
+
+	w := performRequest(router, "GET", "/stream", header{"Accept-Encoding", "gzip"})
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	gr, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", string(body))
+}
+
+func TestCompressCustomCompressor(t *testing.T) {
+	identity := Compressor{
+		Encoding: "x-identity",
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return nopWriteCloser{w}, nil
+		},
+	}
+	router := New()
+	router.Use(CompressWithConfig(CompressConfig{
+		MinLength:    1,
+		ContentTypes: []string{MIMEJSON},
+		Compressors:  []Compressor{identity, GzipCompressor},
+	}))
+	router.GET("/", func(c *Context) {
+		c.JSON(http.StatusOK, H{"foo": "bar"})
+	})
+
+	w := performRequest(router, "GET", "/", header{"Accept-Encoding", "*"})
+	assert.Equal(t, "x-identity", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"foo":"bar"}`, w.Body.String())
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
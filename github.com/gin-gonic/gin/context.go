@@ -48,6 +48,7 @@ type Context struct {
 	Params   Params
 	handlers HandlersChain
 	index    int8
+	fullPath string
 
 	engine *Engine
 
@@ -70,6 +71,7 @@ func (c *Context) reset() {
 	c.Params = c.Params[0:0]
 	c.handlers = nil
 	c.index = -1
+	c.fullPath = ""
 	c.Keys = nil
 	c.Errors = c.Errors[0:0]
 	c.Accepted = nil
@@ -111,6 +113,15 @@ func (c *Context) Handler() HandlerFunc {
 	return c.handlers.Last()
 }
 
+// FullPath returns a matched route full path. For not found routes
+// returns an empty string.
+//     router.GET("/user/:id", func(c *gin.Context) {
+//         c.FullPath() == "/user/:id" // true
+//     })
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 /************************************/
 /*********** FLOW CONTROL ***********/
 /************************************/
@@ -711,7 +722,7 @@ func bodyAllowedForStatus(status int) bool {
 
 // Status sets the HTTP response code.
 func (c *Context) Status(code int) {
-	c.writermem.WriteHeader(code)
+	c.Writer.WriteHeader(code)
 }
 
 // Header is a intelligent shortcut for c.Writer.Header().Set(key, value).
@@ -929,9 +940,26 @@ type Negotiate struct {
 	Data     interface{}
 }
 
+// RendererFunc builds the render.Render used by Negotiate for a registered content type.
+type RendererFunc func(data interface{}) render.Render
+
+func defaultRenderers() map[string]RendererFunc {
+	yaml := func(data interface{}) render.Render { return render.YAML{Data: data} }
+	msgpack := func(data interface{}) render.Render { return render.MsgPack{Data: data} }
+	return map[string]RendererFunc{
+		binding.MIMEYAML:     yaml,
+		binding.MIMEMSGPACK:  msgpack,
+		binding.MIMEMSGPACK2: msgpack,
+	}
+}
+
 // Negotiate calls different Render according acceptable Accept format.
+// Formats other than JSON, HTML and XML are rendered with the renderer registered
+// through Engine.RegisterRenderer and Negotiate.Data, or the default ones for
+// contexts without engine.
 func (c *Context) Negotiate(code int, config Negotiate) {
-	switch c.NegotiateFormat(config.Offered...) {
+	format := c.NegotiateFormat(config.Offered...)
+	switch format {
 	case binding.MIMEJSON:
 		data := chooseData(config.JSONData, config.Data)
 		c.JSON(code, data)
@@ -945,6 +973,14 @@ func (c *Context) Negotiate(code int, config Negotiate) {
 		c.XML(code, data)
 
 	default:
+		renderers := defaultRenderers()
+		if c.engine != nil {
+			renderers = c.engine.renderers
+		}
+		if fn, ok := renderers[format]; ok {
+			c.Render(code, fn(chooseData(nil, config.Data)))
+			return
+		}
 		c.AbortWithError(http.StatusNotAcceptable, errors.New("the accepted formats are not offered by the server")) // nolint: errcheck
 	}
 }
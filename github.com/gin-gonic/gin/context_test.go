@@ -20,6 +20,7 @@ import (
 
 	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
@@ -1145,6 +1146,59 @@ func TestContextNegotiationWithHTML(t *testing.T) {
 	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
 }
 
+func TestContextNegotiationWithYAML(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "", nil)
+	c.Request.Header.Add("Accept", MIMEYAML)
+
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{MIMEJSON, MIMEYAML},
+		Data:    H{"foo": "bar"},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "foo: bar\n", w.Body.String())
+	assert.Equal(t, "application/x-yaml; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestContextNegotiationWithRegisteredRenderer(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, router := CreateTestContext(w)
+	router.RegisterRenderer("text/csv", func(data interface{}) render.Render {
+		return render.CSV{Data: data.([][]string)}
+	})
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.Header.Add("Accept", "text/csv, application/json;q=0.5")
+
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{MIMEJSON, "text/csv"},
+		Data:    [][]string{{"a", "b"}},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a,b\n", w.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Panics(t, func() { router.RegisterRenderer("", nil) })
+}
+
+func TestContextNegotiationWithoutEngine(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := &Context{}
+	c.reset()
+	c.writermem.reset(w)
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.Header.Add("Accept", MIMEYAML)
+
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{MIMEYAML},
+		Data:    H{"foo": "bar"},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "foo: bar\n", w.Body.String())
+}
+
 func TestContextNegotiationNotSupport(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := CreateTestContext(w)
@@ -1812,3 +1866,23 @@ func TestContextResetInHandler(t *testing.T) {
 		c.Next()
 	})
 }
+
+func TestContextFullPath(t *testing.T) {
+	router := New()
+	var fullPath string
+	handler := func(c *Context) { fullPath = c.FullPath() }
+	router.GET("/user/:name", handler)
+	router.GET("/user/:name/*action", handler)
+	router.GET("/src/*filepath", handler)
+
+	performRequest(router, "GET", "/user/gin")
+	assert.Equal(t, "/user/:name", fullPath)
+	performRequest(router, "GET", "/user/gin/send")
+	assert.Equal(t, "/user/:name/*action", fullPath)
+	performRequest(router, "GET", "/src/some/file.go")
+	assert.Equal(t, "/src/*filepath", fullPath)
+
+	router.NoRoute(handler)
+	performRequest(router, "GET", "/nope")
+	assert.Empty(t, fullPath)
+}
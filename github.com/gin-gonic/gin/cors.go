@@ -0,0 +1,155 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"}
+	defaultCORSHeaders = []string{"Origin", "Content-Length", "Content-Type"}
+)
+
+// CORSConfig defines the config for CORS middleware.
+type CORSConfig struct {
+	// AllowOrigins is a list of origins a cross-domain request can be executed from.
+	// "*" allows every origin and an entry may contain one wildcard,
+	// e.g. "https://*.example.com".
+	// Optional. Default value is []string{"*"} unless AllowOriginFunc is set.
+	AllowOrigins []string
+
+	// AllowOriginFunc is a custom function to validate the origin. It is
+	// consulted when the origin does not match AllowOrigins.
+	// Optional.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowMethods is the list of methods the client is allowed to use.
+	// Optional. Default value is GET, POST, PUT, PATCH, DELETE and HEAD.
+	AllowMethods []string
+
+	// AllowHeaders is the list of non simple headers the client is allowed to use.
+	// Optional. Default value is Origin, Content-Length and Content-Type.
+	AllowHeaders []string
+
+	// ExposeHeaders lists the response headers that are safe to expose to the client.
+	// Optional.
+	ExposeHeaders []string
+
+	// AllowCredentials indicates whether the request can include user
+	// credentials like cookies or HTTP authentication. It can not be combined
+	// with the "*" origin, list the allowed origins or use AllowOriginFunc.
+	// Optional. Default value is false.
+	AllowCredentials bool
+
+	// MaxAge indicates how long the results of a preflight request can be cached.
+	// Optional. Default value is zero, no Access-Control-Max-Age is sent.
+	MaxAge time.Duration
+}
+
+// CORS returns a middleware that allows cross-origin requests from any origin.
+// Register it with Engine.Use so that preflight requests reach it even for
+// routes without an OPTIONS handler.
+func CORS() HandlerFunc {
+	return CORSWithConfig(CORSConfig{})
+}
+
+// CORSWithConfig returns a CORS middleware with config.
+func CORSWithConfig(conf CORSConfig) HandlerFunc {
+	origins := conf.AllowOrigins
+	if len(origins) == 0 && conf.AllowOriginFunc == nil {
+		origins = []string{"*"}
+	}
+
+	allowAll := false
+	for _, origin := range origins {
+		assert1(strings.Count(origin, "*") <= 1, "only one wildcard per origin is allowed, has: '"+origin+"'")
+		if origin == "*" {
+			allowAll = true
+		}
+	}
+	// echoing any origin with credentials would let every site make
+	// credentialed requests
+	assert1(!allowAll || !conf.AllowCredentials, "AllowCredentials can not be used with the '*' origin")
+
+	methods := conf.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := conf.AllowHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+
+	allowMethods := strings.ToUpper(strings.Join(methods, ", "))
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(conf.ExposeHeaders, ", ")
+	maxAge := ""
+	if conf.MaxAge > 0 {
+		maxAge = strconv.FormatInt(int64(conf.MaxAge/time.Second), 10)
+	}
+
+	return func(c *Context) {
+		origin := c.requestHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		if !originAllowed(origin, origins, conf.AllowOriginFunc) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		if allowAll {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if conf.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		preflight := c.Request.Method == "OPTIONS" && c.requestHeader("Access-Control-Request-Method") != ""
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		header.Set("Access-Control-Allow-Headers", allowHeaders)
+		if maxAge != "" {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func originAllowed(origin string, allowed []string, fn func(string) bool) bool {
+	for _, pattern := range allowed {
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		if i := strings.IndexByte(pattern, '*'); i >= 0 {
+			prefix, suffix := pattern[:i], pattern[i+1:]
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return fn != nil && fn(origin)
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSRouter(conf CORSConfig) *Engine {
+	router := New()
+	router.Use(CORSWithConfig(conf))
+	router.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return router
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	router := New()
+	router.Use(CORS())
+	router.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := performRequest(router, "GET", "/", header{"Origin", "http://example.com"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	w = performRequest(router, "GET", "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newCORSRouter(CORSConfig{
+		AllowOrigins:     []string{"https://*.example.com"},
+		AllowMethods:     []string{"get", "put"},
+		AllowHeaders:     []string{"Authorization"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	})
+
+	// no OPTIONS route is registered, the middleware answers from the 404 chain
+	w := performRequest(router, "OPTIONS", "/",
+		header{"Origin", "https://api.example.com"},
+		header{"Access-Control-Request-Method", "PUT"})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://api.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, PUT", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "43200", w.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestCORSOriginRejected(t *testing.T) {
+	router := newCORSRouter(CORSConfig{
+		AllowOrigins:    []string{"https://*.example.com"},
+		AllowOriginFunc: func(origin string) bool { return origin == "http://localhost:3000" },
+		ExposeHeaders:   []string{"X-Total-Count"},
+	})
+
+	w := performRequest(router, "GET", "/", header{"Origin", "https://evil.com"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = performRequest(router, "GET", "/", header{"Origin", "https://example.com"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = performRequest(router, "GET", "/", header{"Origin", "http://localhost:3000"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Total-Count", w.Header().Get("Access-Control-Expose-Headers"))
+}
+
+func TestCORSInvalidConfig(t *testing.T) {
+	assert.Panics(t, func() {
+		CORSWithConfig(CORSConfig{AllowOrigins: []string{"https://*.*.example.com"}})
+	})
+	assert.Panics(t, func() {
+		CORSWithConfig(CORSConfig{AllowCredentials: true})
+	})
+	assert.Panics(t, func() {
+		CORSWithConfig(CORSConfig{AllowOrigins: []string{"https://example.com", "*"}, AllowCredentials: true})
+	})
+	assert.NotPanics(t, func() {
+		CORSWithConfig(CORSConfig{AllowOriginFunc: func(string) bool { return true }, AllowCredentials: true})
+	})
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+)
+
+// CSRFTokenKey is the context key under which the CSRF middleware stores the
+// token of the current request. Read it with c.GetString(gin.CSRFTokenKey) or CSRFToken.
+const CSRFTokenKey = "_gin-gonic/gin/csrftoken"
+
+const csrfTokenLength = 32
+
+// ErrCSRFToken is attached to the context when the CSRF token is missing or wrong.
+var ErrCSRFToken = errors.New("gin: invalid CSRF token")
+
+// CSRFConfig defines the config for CSRF middleware.
+type CSRFConfig struct {
+	// CookieName is the name of the cookie holding the token.
+	// Optional. Default value is "_csrf".
+	CookieName string
+
+	// CookiePath, CookieDomain and CookieMaxAge are passed to Context.SetCookie.
+	// Optional. Default values are "/", the request host and a session cookie.
+	CookiePath   string
+	CookieDomain string
+	CookieMaxAge int
+
+	// Secure marks the cookie as secure.
+	// Optional. Default value is false.
+	Secure bool
+
+	// HeaderName is the request header the token is expected in.
+	// Optional. Default value is "X-CSRF-Token".
+	HeaderName string
+
+	// FormField is the form field the token is expected in when the header is absent.
+	// Optional. Default value is "_csrf".
+	FormField string
+}
+
+// CSRF returns a middleware protecting against cross-site request forgery
+// with the double submit cookie pattern: a random token is stored in a cookie
+// and every unsafe request (anything but GET, HEAD, OPTIONS and TRACE) must
+// echo it in the X-CSRF-Token header or the _csrf form field. Requests that
+// fail the check are aborted with 403 Forbidden.
+func CSRF() HandlerFunc {
+	return CSRFWithConfig(CSRFConfig{})
+}
+
+// CSRFWithConfig returns a CSRF middleware with config.
+func CSRFWithConfig(conf CSRFConfig) HandlerFunc {
+	if conf.CookieName == "" {
+		conf.CookieName = "_csrf"
+	}
+	if conf.HeaderName == "" {
+		conf.HeaderName = "X-CSRF-Token"
+	}
+	if conf.FormField == "" {
+		conf.FormField = "_csrf"
+	}
+
+	return func(c *Context) {
+		token, err := c.Cookie(conf.CookieName)
+		if err != nil || len(token) == 0 {
+			token = newCSRFToken()
+			// The cookie must stay readable by scripts so they can submit it back.
+			c.SetCookie(conf.CookieName, token, conf.CookieMaxAge, conf.CookiePath, conf.CookieDomain, conf.Secure, false)
+		}
+		c.Set(CSRFTokenKey, token)
+
+		switch c.Request.Method {
+		case "GET", "HEAD", "OPTIONS", "TRACE":
+			c.Next()
+			return
+		}
+
+		sent := c.requestHeader(conf.HeaderName)
+		if sent == "" {
+			sent = c.PostForm(conf.FormField)
+		}
+		if err != nil || sent == "" || !secureCompare(sent, token) {
+			c.AbortWithError(http.StatusForbidden, ErrCSRFToken) // nolint: errcheck
+			return
+		}
+		c.Next()
+	}
+}
+
+// CSRFToken returns the CSRF token of the current request, to be embedded in
+// forms or handed to scripts. It is empty when the CSRF middleware is not used.
+func CSRFToken(c *Context) string {
+	return c.GetString(CSRFTokenKey)
+}
+
+func newCSRFToken() string {
+	b := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
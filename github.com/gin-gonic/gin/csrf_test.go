@@ -0,0 +1,68 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCSRFRouter() *Engine {
+	router := New()
+	router.Use(CSRF())
+	router.GET("/form", func(c *Context) {
+		c.String(http.StatusOK, CSRFToken(c))
+	})
+	router.POST("/form", func(c *Context) {
+		c.String(http.StatusOK, "saved")
+	})
+	return router
+}
+
+func TestCSRFIssuesToken(t *testing.T) {
+	router := newCSRFRouter()
+
+	w := performRequest(router, "GET", "/form")
+	assert.Equal(t, http.StatusOK, w.Code)
+	token := w.Body.String()
+	assert.NotEmpty(t, token)
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "_csrf="+token)
+	assert.NotContains(t, w.Header().Get("Set-Cookie"), "HttpOnly")
+
+	// an existing cookie is reused
+	w = performRequest(router, "GET", "/form", header{"Cookie", "_csrf=" + token})
+	assert.Equal(t, token, w.Body.String())
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+}
+
+func TestCSRFValidatesUnsafeMethods(t *testing.T) {
+	router := newCSRFRouter()
+	token := performRequest(router, "GET", "/form").Body.String()
+	cookie := header{"Cookie", "_csrf=" + token}
+
+	w := performRequest(router, "POST", "/form", cookie, header{"X-CSRF-Token", token})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "saved", w.Body.String())
+
+	w = performRequest(router, "POST", "/form", cookie, header{"X-CSRF-Token", "forged"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = performRequest(router, "POST", "/form", cookie)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = performRequest(router, "POST", "/form", header{"X-CSRF-Token", token})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	req, _ := http.NewRequest("POST", "/form", bytes.NewBufferString("_csrf="+token))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	req.Header.Set("Cookie", "_csrf="+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
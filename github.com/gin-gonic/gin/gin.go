@@ -101,6 +101,7 @@ type Engine struct {
 	secureJsonPrefix string
 	HTMLRender       render.HTMLRender
 	FuncMap          template.FuncMap
+	renderers        map[string]RendererFunc
 	allNoRoute       HandlersChain
 	allNoMethod      HandlersChain
 	noRoute          HandlersChain
@@ -139,6 +140,7 @@ func New() *Engine {
 		trees:                  make(methodTrees, 0, 9),
 		delims:                 render.Delims{Left: "{{", Right: "}}"},
 		secureJsonPrefix:       "while(1);",
+		renderers:              defaultRenderers(),
 	}
 	engine.RouterGroup.engine = engine
 	engine.pool.New = func() interface{} {
@@ -208,6 +210,17 @@ func (engine *Engine) SetHTMLTemplate(templ *template.Template) {
 	engine.HTMLRender = render.HTMLProduction{Template: templ.Funcs(engine.FuncMap)}
 }
 
+// RegisterRenderer makes Context.Negotiate able to answer with the given content type.
+// fn builds the render.Render for the negotiated data, for example:
+//     router.RegisterRenderer("text/csv", func(data interface{}) render.Render {
+//         return render.CSV{Data: data.([][]string)}
+//     })
+func (engine *Engine) RegisterRenderer(contentType string, fn RendererFunc) {
+	assert1(contentType != "", "content type can not be empty")
+	assert1(fn != nil, "renderer can not be nil")
+	engine.renderers[contentType] = fn
+}
+
 // SetFuncMap sets the FuncMap used for template.FuncMap.
 func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 	engine.FuncMap = funcMap
@@ -381,10 +394,11 @@ func (engine *Engine) handleHTTPRequest(c *Context) {
 		}
 		root := t[i].root
 		// Find route in tree
-		handlers, params, tsr := root.getValue(rPath, c.Params, unescape)
+		handlers, params, tsr, fullPath := root.getValue(rPath, c.Params, unescape)
 		if handlers != nil {
 			c.handlers = handlers
 			c.Params = params
+			c.fullPath = fullPath
 			c.Next()
 			c.writermem.WriteHeaderNow()
 			return
@@ -406,7 +420,7 @@ func (engine *Engine) handleHTTPRequest(c *Context) {
 			if tree.method == httpMethod {
 				continue
 			}
-			if handlers, _, _ := tree.root.getValue(rPath, nil, unescape); handlers != nil {
+			if handlers, _, _, _ := tree.root.getValue(rPath, nil, unescape); handlers != nil {
 				c.handlers = engine.allNoMethod
 				serveError(c, http.StatusMethodNotAllowed, default405Body)
 				return
@@ -0,0 +1,255 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsContentType is the content type of the Prometheus text exposition format.
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// unmatchedRoute is the route label of requests that did not match any route.
+const unmatchedRoute = "<unmatched>"
+
+var (
+	// DefaultLatencyBuckets are the upper bounds, in seconds, of the request latency histogram.
+	DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the upper bounds, in bytes, of the response size histogram.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// MetricsConfig defines the config for Metrics.
+type MetricsConfig struct {
+	// Namespace prefixes every metric name.
+	// Optional. Default value is "gin".
+	Namespace string
+
+	// LatencyBuckets are the upper bounds of the latency histogram in seconds.
+	// Optional. Default value is gin.DefaultLatencyBuckets.
+	LatencyBuckets []float64
+
+	// SizeBuckets are the upper bounds of the response size histogram in bytes.
+	// Optional. Default value is gin.DefaultSizeBuckets.
+	SizeBuckets []float64
+}
+
+// Metrics collects per route request metrics and exports them in the
+// Prometheus text exposition format. Requests are labelled with the route
+// template (see Context.FullPath) rather than the raw URL, so that the number
+// of series stays bounded.
+//     metrics := gin.NewMetrics(gin.MetricsConfig{})
+//     router.Use(metrics.Middleware())
+//     router.GET("/metrics", metrics.Handler())
+type Metrics struct {
+	namespace      string
+	latencyBuckets []float64
+	sizeBuckets    []float64
+
+	mu       sync.Mutex
+	inFlight int64
+	requests map[requestLabels]uint64
+	latency  map[routeLabels]*histogram
+	size     map[routeLabels]*histogram
+}
+
+type routeLabels struct {
+	method string
+	route  string
+}
+
+type requestLabels struct {
+	routeLabels
+	status int
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(buckets []float64, v float64) {
+	i := sort.SearchFloat64s(buckets, v)
+	if i < len(buckets) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// NewMetrics returns a Metrics with config.
+func NewMetrics(conf MetricsConfig) *Metrics {
+	m := &Metrics{
+		namespace:      conf.Namespace,
+		latencyBuckets: conf.LatencyBuckets,
+		sizeBuckets:    conf.SizeBuckets,
+		requests:       make(map[requestLabels]uint64),
+		latency:        make(map[routeLabels]*histogram),
+		size:           make(map[routeLabels]*histogram),
+	}
+	if m.namespace == "" {
+		m.namespace = "gin"
+	}
+	if len(m.latencyBuckets) == 0 {
+		m.latencyBuckets = DefaultLatencyBuckets
+	}
+	if len(m.sizeBuckets) == 0 {
+		m.sizeBuckets = DefaultSizeBuckets
+	}
+	assert1(sort.Float64sAreSorted(m.latencyBuckets), "latency buckets must be sorted")
+	assert1(sort.Float64sAreSorted(m.sizeBuckets), "size buckets must be sorted")
+	return m
+}
+
+// Middleware returns the handler that records the metrics of every request.
+func (m *Metrics) Middleware() HandlerFunc {
+	return func(c *Context) {
+		start := time.Now()
+		m.mu.Lock()
+		m.inFlight++
+		m.mu.Unlock()
+		defer func() {
+			m.mu.Lock()
+			m.inFlight--
+			m.mu.Unlock()
+		}()
+
+		c.Next()
+
+		latency := time.Since(start).Seconds()
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		labels := routeLabels{method: c.Request.Method, route: route}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.requests[requestLabels{routeLabels: labels, status: c.Writer.Status()}]++
+		m.histogram(m.latency, labels, m.latencyBuckets).observe(m.latencyBuckets, latency)
+		m.histogram(m.size, labels, m.sizeBuckets).observe(m.sizeBuckets, float64(size))
+	}
+}
+
+func (m *Metrics) histogram(series map[routeLabels]*histogram, labels routeLabels, buckets []float64) *histogram {
+	h, ok := series[labels]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		series[labels] = h
+	}
+	return h
+}
+
+// Handler returns a handler serving the collected metrics in the Prometheus
+// text exposition format.
+func (m *Metrics) Handler() HandlerFunc {
+	return func(c *Context) {
+		c.Header("Content-Type", MetricsContentType)
+		c.Status(http.StatusOK)
+		if _, err := m.WriteTo(c.Writer); err != nil {
+			c.Error(err) // nolint: errcheck
+		}
+	}
+}
+
+// WriteTo writes the collected metrics to w in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	m.mu.Lock()
+	m.format(&buf)
+	m.mu.Unlock()
+	return buf.WriteTo(w)
+}
+
+// format writes the exposition into buf. m.mu must be held.
+func (m *Metrics) format(buf *bytes.Buffer) {
+	name := m.namespace + "_requests_total"
+	fmt.Fprintf(buf, "# HELP %s Total number of HTTP requests.\n# TYPE %s counter\n", name, name)
+	requests := make([]requestLabels, 0, len(m.requests))
+	for labels := range m.requests {
+		requests = append(requests, labels)
+	}
+	sort.Sort(byRequestLabels(requests))
+	for _, labels := range requests {
+		fmt.Fprintf(buf, "%s{method=%s,route=%s,status=\"%d\"} %d\n",
+			name, quoteLabel(labels.method), quoteLabel(labels.route), labels.status, m.requests[labels])
+	}
+
+	name = m.namespace + "_requests_in_flight"
+	fmt.Fprintf(buf, "# HELP %s Number of HTTP requests being served.\n# TYPE %s gauge\n%s %d\n", name, name, name, m.inFlight)
+
+	writeHistograms(buf, m.namespace+"_request_duration_seconds", "HTTP request latency in seconds.", m.latency, m.latencyBuckets)
+	writeHistograms(buf, m.namespace+"_response_size_bytes", "HTTP response body size in bytes.", m.size, m.sizeBuckets)
+}
+
+func writeHistograms(w io.Writer, name, help string, series map[routeLabels]*histogram, buckets []float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	keys := make([]routeLabels, 0, len(series))
+	for labels := range series {
+		keys = append(keys, labels)
+	}
+	sort.Sort(byRouteLabels(keys))
+	for _, labels := range keys {
+		h := series[labels]
+		base := "method=" + quoteLabel(labels.method) + ",route=" + quoteLabel(labels.route)
+		var cumulative uint64
+		for i, bound := range buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, base, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, base, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, base, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, base, h.count)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type byRouteLabels []routeLabels
+
+func (s byRouteLabels) Len() int      { return len(s) }
+func (s byRouteLabels) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byRouteLabels) Less(i, j int) bool {
+	if s[i].route != s[j].route {
+		return s[i].route < s[j].route
+	}
+	return s[i].method < s[j].method
+}
+
+type byRequestLabels []requestLabels
+
+func (s byRequestLabels) Len() int      { return len(s) }
+func (s byRequestLabels) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byRequestLabels) Less(i, j int) bool {
+	if s[i].routeLabels != s[j].routeLabels {
+		return byRouteLabels{s[i].routeLabels, s[j].routeLabels}.Less(0, 1)
+	}
+	return s[i].status < s[j].status
+}
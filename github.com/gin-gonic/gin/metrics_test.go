@@ -0,0 +1,66 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(MetricsConfig{
+		Namespace:      "app",
+		LatencyBuckets: []float64{1, 60},
+		SizeBuckets:    []float64{2, 100},
+	})
+	router := New()
+	router.Use(metrics.Middleware())
+	router.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "user")
+	})
+	router.GET("/metrics", metrics.Handler())
+
+	performRequest(router, "GET", "/users/1")
+	performRequest(router, "GET", "/users/2")
+	performRequest(router, "GET", "/missing")
+
+	w := performRequest(router, "GET", "/metrics")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MetricsContentType, w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE app_requests_total counter\n")
+	assert.Contains(t, body, `app_requests_total{method="GET",route="/users/:id",status="200"} 2`+"\n")
+	assert.Contains(t, body, `app_requests_total{method="GET",route="<unmatched>",status="404"} 1`+"\n")
+	assert.Contains(t, body, "app_requests_in_flight 1\n")
+	assert.Contains(t, body, "# TYPE app_request_duration_seconds histogram\n")
+	assert.Contains(t, body, `app_request_duration_seconds_bucket{method="GET",route="/users/:id",le="60"} 2`+"\n")
+	assert.Contains(t, body, `app_request_duration_seconds_count{method="GET",route="/users/:id"} 2`+"\n")
+	assert.Contains(t, body, `app_response_size_bytes_bucket{method="GET",route="/users/:id",le="2"} 0`+"\n")
+	assert.Contains(t, body, `app_response_size_bytes_bucket{method="GET",route="/users/:id",le="100"} 2`+"\n")
+	assert.Contains(t, body, `app_response_size_bytes_bucket{method="GET",route="/users/:id",le="+Inf"} 2`+"\n")
+	assert.Contains(t, body, `app_response_size_bytes_sum{method="GET",route="/users/:id"} 8`+"\n")
+	assert.NotContains(t, body, "/users/1")
+}
+
+func TestMetricsWriteTo(t *testing.T) {
+	metrics := NewMetrics(MetricsConfig{})
+	var buf bytes.Buffer
+	n, err := metrics.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.Contains(t, buf.String(), "gin_requests_in_flight 0\n")
+
+	assert.Panics(t, func() {
+		NewMetrics(MetricsConfig{LatencyBuckets: []float64{2, 1}})
+	})
+}
+
+func TestQuoteLabel(t *testing.T) {
+	assert.Equal(t, `"a\"b\\c\nd"`, quoteLabel("a\"b\\c\nd"))
+}
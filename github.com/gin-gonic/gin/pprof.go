@@ -0,0 +1,68 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// DefaultPprofPrefix is the path the pprof handlers are registered under by RegisterPprof.
+const DefaultPprofPrefix = "/debug/pprof"
+
+// RegisterPprof registers the runtime profiles of package net/http/pprof on r
+// under prefix, "/debug/pprof" by default. Named profiles such as heap,
+// goroutine or mutex are served from prefix + "/<name>".
+func RegisterPprof(r IRoutes, prefix ...string) {
+	p := DefaultPprofPrefix
+	if len(prefix) > 0 {
+		p = prefix[0]
+	}
+	p = strings.TrimSuffix(p, "/")
+	r.GET(p+"/*profile", pprofHandler)
+	r.POST(p+"/*profile", pprofHandler)
+}
+
+func pprofHandler(c *Context) {
+	switch name := strings.Trim(c.Param("profile"), "/"); name {
+	case "":
+		// pprof.Index expects to be mounted at /debug/pprof/.
+		c.Request.URL.Path = DefaultPprofPrefix + "/"
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		if c.Request.Method != "GET" {
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+			return
+		}
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// RoutesHandler returns a handler that lists the routes registered on engine
+// as JSON, see Engine.Routes.
+func RoutesHandler(engine *Engine) HandlerFunc {
+	type route struct {
+		Method  string `json:"method"`
+		Path    string `json:"path"`
+		Handler string `json:"handler"`
+	}
+	return func(c *Context) {
+		routes := engine.Routes()
+		out := make([]route, 0, len(routes))
+		for _, r := range routes {
+			out = append(out, route{Method: r.Method, Path: r.Path, Handler: r.Handler})
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
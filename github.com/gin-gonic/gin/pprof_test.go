@@ -0,0 +1,51 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin/internal/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterPprof(t *testing.T) {
+	router := New()
+	RegisterPprof(router)
+	RegisterPprof(router.Group("/admin"), "/pprof/")
+
+	w := performRequest(router, "GET", "/debug/pprof/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine")
+
+	w = performRequest(router, "GET", "/debug/pprof/goroutine?debug=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine profile")
+
+	w = performRequest(router, "GET", "/admin/pprof/cmdline")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(router, "POST", "/debug/pprof/heap")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestRoutesHandler(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", func(c *Context) {})
+	router.GET("/debug/routes", RoutesHandler(router))
+
+	w := performRequest(router, "GET", "/debug/routes")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var routes []map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &routes))
+	assert.Len(t, routes, 2)
+	assert.Contains(t, routes, map[string]string{
+		"method":  "GET",
+		"path":    "/users/:id",
+		"handler": "github.com/gin-gonic/gin.TestRoutesHandler.func1",
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// MIMEProblemJSON is the media type of RFC 7807 problem details documents.
+const MIMEProblemJSON = "application/problem+json"
+
+// ProblemDetails is an RFC 7807 problem details object. Errors is an
+// extension member listing the fields that failed validation.
+type ProblemDetails struct {
+	Type     string                   `json:"type,omitempty"`
+	Title    string                   `json:"title"`
+	Status   int                      `json:"status"`
+	Detail   string                   `json:"detail,omitempty"`
+	Instance string                   `json:"instance,omitempty"`
+	Errors   binding.ValidationErrors `json:"errors,omitempty"`
+}
+
+// AbortWithProblem writes p as application/problem+json with p.Status and
+// stops the handler chain.
+func (c *Context) AbortWithProblem(p ProblemDetails) {
+	if p.Status == 0 {
+		p.Status = http.StatusBadRequest
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", MIMEProblemJSON+"; charset=utf-8")
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// AbortWithBindError answers a failed ShouldBind* call for obj with a 400
+// problem details document. Validation failures are listed per field, named
+// after the struct tag of the binding chosen for the request and described in
+// the language preferred by the client's Accept-Language header.
+// Any other error, such as malformed JSON, is reported in the detail member.
+func (c *Context) AbortWithBindError(err error, obj interface{}) {
+	b := binding.Default(c.Request.Method, c.ContentType())
+	p := ProblemDetails{Status: http.StatusBadRequest}
+	if errs, ok := binding.TranslateErrors(err, obj, binding.TagName(b), c.preferredLanguage()); ok {
+		p.Title = "Validation failed"
+		p.Errors = errs
+	} else {
+		p.Detail = err.Error()
+	}
+	c.Error(err).SetType(ErrorTypeBind) // nolint: errcheck
+	c.AbortWithProblem(p)
+}
+
+// preferredLanguage returns the first language of the Accept-Language header
+// that binding has messages for, trying "zh" for "zh-CN" as well.
+func (c *Context) preferredLanguage() string {
+	for _, lang := range parseAccept(c.requestHeader("Accept-Language")) {
+		if binding.HasLanguage(lang) {
+			return lang
+		}
+		if i := strings.IndexByte(lang, '-'); i > 0 && binding.HasLanguage(lang[:i]) {
+			return lang[:i]
+		}
+	}
+	return binding.DefaultLanguage
+}
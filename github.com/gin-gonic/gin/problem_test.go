@@ -0,0 +1,89 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin/internal/json"
+	"github.com/stretchr/testify/assert"
+)
+
+type problemSignup struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+func performBindRequest(body, lang string) *httptest.ResponseRecorder {
+	router := New()
+	router.POST("/signup", func(c *Context) {
+		var obj problemSignup
+		if err := c.ShouldBind(&obj); err != nil {
+			c.AbortWithBindError(err, &obj)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	req, _ := http.NewRequest("POST", "/signup", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", MIMEJSON)
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAbortWithBindErrorValidation(t *testing.T) {
+	w := performBindRequest(`{"email":"gin","password":"short"}`, "zh-CN,zh;q=0.9,en;q=0.8")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var p ProblemDetails
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, "Validation failed", p.Title)
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, "/signup", p.Instance)
+	assert.Len(t, p.Errors, 2)
+	assert.Equal(t, "email", p.Errors[0].Field)
+	assert.Equal(t, "invalid_email", p.Errors[0].Code)
+	assert.Equal(t, "email必须是一个有效的邮箱", p.Errors[0].Message)
+	assert.Equal(t, "password", p.Errors[1].Field)
+	assert.Equal(t, "too_small", p.Errors[1].Code)
+	assert.Equal(t, "8", p.Errors[1].Param)
+
+	w = performBindRequest(`{"email":"gin@example.com"}`, "")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, "password is required", p.Errors[0].Message)
+
+	w = performBindRequest(`{"email":"gin@example.com","password":"long enough"}`, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestAbortWithBindErrorMalformed(t *testing.T) {
+	w := performBindRequest(`{"email":`, "en")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var p ProblemDetails
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, "Bad Request", p.Title)
+	assert.NotEmpty(t, p.Detail)
+	assert.Empty(t, p.Errors)
+}
+
+func TestAbortWithProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/orders/1", nil)
+
+	c.AbortWithProblem(ProblemDetails{Status: http.StatusConflict, Detail: "order is locked"})
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"title":"Conflict","status":409,"detail":"order is locked","instance":"/orders/1"}`, w.Body.String())
+}
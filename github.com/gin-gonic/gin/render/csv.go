@@ -0,0 +1,33 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"encoding/csv"
+	"net/http"
+)
+
+// CSV contains the given records.
+type CSV struct {
+	Data [][]string
+}
+
+var csvContentType = []string{"text/csv; charset=utf-8"}
+
+// Render (CSV) writes the records as comma separated values with custom ContentType.
+func (r CSV) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(r.Data); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteContentType (CSV) writes CSV ContentType for response.
+func (r CSV) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, csvContentType)
+}
@@ -269,6 +269,18 @@ func TestRenderYAMLFail(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRenderCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	data := [][]string{{"id", "name"}, {"1", "gin, tonic"}}
+
+	(CSV{data}).WriteContentType(w)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+	err := (CSV{data}).Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,name\n1,\"gin, tonic\"\n", w.Body.String())
+}
+
 // test Protobuf rendering
 func TestRenderProtoBuf(t *testing.T) {
 	w := httptest.NewRecorder()
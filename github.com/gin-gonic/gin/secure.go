@@ -0,0 +1,100 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SecureConfig defines the config for Secure middleware.
+// Empty values disable the corresponding header.
+type SecureConfig struct {
+	// STSSeconds is the max-age of the Strict-Transport-Security header. The
+	// header is only sent on HTTPS requests, including requests forwarded by a
+	// proxy with X-Forwarded-Proto: https, unless ForceSTSHeader is set.
+	STSSeconds int64
+	// STSIncludeSubdomains adds the includeSubDomains directive.
+	STSIncludeSubdomains bool
+	// STSPreload adds the preload directive.
+	STSPreload bool
+	// ForceSTSHeader sends Strict-Transport-Security on plain HTTP requests too.
+	ForceSTSHeader bool
+
+	// ContentSecurityPolicy is the value of the Content-Security-Policy header.
+	ContentSecurityPolicy string
+
+	// FrameOptions is the value of the X-Frame-Options header, e.g. "DENY" or "SAMEORIGIN".
+	FrameOptions string
+
+	// ContentTypeNosniff sends X-Content-Type-Options: nosniff.
+	ContentTypeNosniff bool
+
+	// ReferrerPolicy is the value of the Referrer-Policy header.
+	ReferrerPolicy string
+
+	// XSSProtection is the value of the X-XSS-Protection header, e.g. "1; mode=block".
+	XSSProtection string
+}
+
+// DefaultSecureConfig returns the configuration used by Secure:
+// one year of HSTS including subdomains, X-Frame-Options DENY, nosniff,
+// a strict-origin-when-cross-origin referrer policy and XSS filtering in block mode.
+func DefaultSecureConfig() SecureConfig {
+	return SecureConfig{
+		STSSeconds:           31536000,
+		STSIncludeSubdomains: true,
+		FrameOptions:         "DENY",
+		ContentTypeNosniff:   true,
+		ReferrerPolicy:       "strict-origin-when-cross-origin",
+		XSSProtection:        "1; mode=block",
+	}
+}
+
+// Secure returns a middleware that sets common security headers with DefaultSecureConfig.
+func Secure() HandlerFunc {
+	return SecureWithConfig(DefaultSecureConfig())
+}
+
+// SecureWithConfig returns a security headers middleware with config.
+func SecureWithConfig(conf SecureConfig) HandlerFunc {
+	sts := ""
+	if conf.STSSeconds > 0 {
+		sts = "max-age=" + strconv.FormatInt(conf.STSSeconds, 10)
+		if conf.STSIncludeSubdomains {
+			sts += "; includeSubDomains"
+		}
+		if conf.STSPreload {
+			sts += "; preload"
+		}
+	}
+
+	return func(c *Context) {
+		header := c.Writer.Header()
+		if sts != "" && (conf.ForceSTSHeader || isHTTPS(c)) {
+			header.Set("Strict-Transport-Security", sts)
+		}
+		if conf.ContentSecurityPolicy != "" {
+			header.Set("Content-Security-Policy", conf.ContentSecurityPolicy)
+		}
+		if conf.FrameOptions != "" {
+			header.Set("X-Frame-Options", conf.FrameOptions)
+		}
+		if conf.ContentTypeNosniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		}
+		if conf.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", conf.ReferrerPolicy)
+		}
+		if conf.XSSProtection != "" {
+			header.Set("X-XSS-Protection", conf.XSSProtection)
+		}
+		c.Next()
+	}
+}
+
+func isHTTPS(c *Context) bool {
+	return c.Request.TLS != nil || strings.EqualFold(c.requestHeader("X-Forwarded-Proto"), "https")
+}
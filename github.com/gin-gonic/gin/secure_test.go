@@ -0,0 +1,49 @@
+// Copyright 2019 Gin Core Team.  All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureDefaults(t *testing.T) {
+	router := New()
+	router.Use(Secure())
+	router.GET("/", func(c *Context) {})
+
+	w := performRequest(router, "GET", "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "1; mode=block", w.Header().Get("X-XSS-Protection"))
+	assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+
+	w = performRequest(router, "GET", "/", header{"X-Forwarded-Proto", "https"})
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+}
+
+func TestSecureWithConfig(t *testing.T) {
+	router := New()
+	router.Use(SecureWithConfig(SecureConfig{
+		STSSeconds:            600,
+		STSPreload:            true,
+		ForceSTSHeader:        true,
+		ContentSecurityPolicy: "default-src 'self'",
+		FrameOptions:          "SAMEORIGIN",
+	}))
+	router.GET("/", func(c *Context) {})
+
+	w := performRequest(router, "GET", "/")
+	assert.Equal(t, "max-age=600; preload", w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "default-src 'self'", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+	assert.Empty(t, w.Header().Get("X-Content-Type-Options"))
+	assert.Empty(t, w.Header().Get("Referrer-Policy"))
+}
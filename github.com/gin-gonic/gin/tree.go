@@ -94,6 +94,7 @@ type node struct {
 	nType     nodeType
 	maxParams uint8
 	wildChild bool
+	fullPath  string
 }
 
 // increments priority of the given child and reorders if necessary.
@@ -154,6 +155,7 @@ func (n *node) addRoute(path string, handlers HandlersChain) {
 					children:  n.children,
 					handlers:  n.handlers,
 					priority:  n.priority - 1,
+					fullPath:  n.fullPath,
 				}
 
 				// Update maxParams (max of all children)
@@ -169,6 +171,7 @@ func (n *node) addRoute(path string, handlers HandlersChain) {
 				n.path = path[:i]
 				n.handlers = nil
 				n.wildChild = false
+				n.fullPath = ""
 			}
 
 			// Make new node a child of this node
@@ -242,6 +245,7 @@ func (n *node) addRoute(path string, handlers HandlersChain) {
 					panic("handlers are already registered for path '" + fullPath + "'")
 				}
 				n.handlers = handlers
+				n.fullPath = fullPath
 			}
 			return
 		}
@@ -352,6 +356,7 @@ func (n *node) insertChild(numParams uint8, path string, fullPath string, handle
 				maxParams: 1,
 				handlers:  handlers,
 				priority:  1,
+				fullPath:  fullPath,
 			}
 			n.children = []*node{child}
 
@@ -362,6 +367,7 @@ func (n *node) insertChild(numParams uint8, path string, fullPath string, handle
 	// insert remaining path part and handle to the leaf
 	n.path = path[offset:]
 	n.handlers = handlers
+	n.fullPath = fullPath
 }
 
 // getValue returns the handle registered with the given path (key). The values of
@@ -369,7 +375,8 @@ func (n *node) insertChild(numParams uint8, path string, fullPath string, handle
 // If no handle can be found, a TSR (trailing slash redirect) recommendation is
 // made if a handle exists with an extra (without the) trailing slash for the
 // given path.
-func (n *node) getValue(path string, po Params, unescape bool) (handlers HandlersChain, p Params, tsr bool) {
+// fullPath is the route template the handle was registered with, e.g. "/user/:name".
+func (n *node) getValue(path string, po Params, unescape bool) (handlers HandlersChain, p Params, tsr bool, fullPath string) {
 	p = po
 walk: // Outer loop for walking the tree
 	for {
@@ -436,6 +443,7 @@ walk: // Outer loop for walking the tree
 					}
 
 					if handlers = n.handlers; handlers != nil {
+						fullPath = n.fullPath
 						return
 					}
 					if len(n.children) == 1 {
@@ -465,6 +473,7 @@ walk: // Outer loop for walking the tree
 					}
 
 					handlers = n.handlers
+					fullPath = n.fullPath
 					return
 
 				default:
@@ -475,6 +484,7 @@ walk: // Outer loop for walking the tree
 			// We should have reached the node containing the handle.
 			// Check if this node has a handle registered.
 			if handlers = n.handlers; handlers != nil {
+				fullPath = n.fullPath
 				return
 			}
 
@@ -0,0 +1,376 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package binlog streams the binary log of a MySQL server as a replica, to
+// capture the changes of its tables without polling.
+//
+// The server must log in row-based format (binlog_format=ROW):
+//
+//  cfg := mysql.NewConfig()
+//  cfg.User, cfg.Passwd, cfg.Net, cfg.Addr = "repl", "secret", "tcp", "127.0.0.1:3306"
+//
+//  s := binlog.NewStreamer(binlog.Config{MySQL: cfg, ServerID: 1001})
+//  if err := s.Start(savedPosition); err != nil {
+//  	log.Fatal(err)
+//  }
+//  for {
+//  	ev, err := s.Next()
+//  	if err != nil {
+//  		log.Fatal(err)
+//  	}
+//  	if rows, ok := ev.Data.(*binlog.RowsEvent); ok {
+//  		// rows.Action, rows.Table.Table, rows.Rows
+//  	}
+//  	savedPosition = s.Position()
+//  }
+package binlog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Various errors the parser might return.
+var (
+	ErrChecksum   = errors.New("binlog: event checksum mismatch")
+	ErrShortEvent = errors.New("binlog: event too short")
+)
+
+// EventType is the type of a binlog event.
+// https://dev.mysql.com/doc/internals/en/binlog-event-type.html
+type EventType byte
+
+// Event types
+const (
+	TypeUnknown           EventType = 0
+	TypeQuery             EventType = 2
+	TypeStop              EventType = 3
+	TypeRotate            EventType = 4
+	TypeFormatDescription EventType = 15
+	TypeXID               EventType = 16
+	TypeTableMap          EventType = 19
+	TypeWriteRowsV1       EventType = 23
+	TypeUpdateRowsV1      EventType = 24
+	TypeDeleteRowsV1      EventType = 25
+	TypeHeartbeat         EventType = 27
+	TypeRowsQuery         EventType = 29
+	TypeWriteRowsV2       EventType = 30
+	TypeUpdateRowsV2      EventType = 31
+	TypeDeleteRowsV2      EventType = 32
+	TypeGTID              EventType = 33
+	TypeAnonymousGTID     EventType = 34
+	TypePreviousGTIDs     EventType = 35
+)
+
+const (
+	eventHeaderLength   = 19
+	checksumLength      = 4
+	checksumAlgCRC32    = 1
+	checksumAlgVersion  = 50601 // 5.6.1
+	defaultTableIDWidth = 6
+)
+
+// EventHeader is the common header of all events.
+// https://dev.mysql.com/doc/internals/en/binlog-event-header.html
+type EventHeader struct {
+	Timestamp uint32
+	Type      EventType
+	ServerID  uint32
+	Size      uint32
+	// LogPos is the position of the next event in the binlog file, 0 for
+	// the events which are generated for the replica and are not logged
+	LogPos uint32
+	Flags  uint16
+}
+
+// Event is a binlog event.
+type Event struct {
+	Header EventHeader
+	// Data is the decoded body of the event: *FormatDescriptionEvent,
+	// *RotateEvent, *QueryEvent, *XIDEvent, *TableMapEvent, *RowsEvent or
+	// *GTIDEvent, or the raw []byte body of other events
+	Data interface{}
+}
+
+// FormatDescriptionEvent describes the format of the following events.
+// https://dev.mysql.com/doc/internals/en/format-description-event.html
+type FormatDescriptionEvent struct {
+	Version                uint16
+	ServerVersion          string
+	CreateTimestamp        uint32
+	EventHeaderLength      uint8
+	EventTypeHeaderLengths []byte
+	ChecksumAlgorithm      byte
+}
+
+// postHeaderLength returns the length of the post-header of events of type t,
+// or -1 if it is unknown.
+func (e *FormatDescriptionEvent) postHeaderLength(t EventType) int {
+	if e == nil || t == 0 || int(t) > len(e.EventTypeHeaderLengths) {
+		return -1
+	}
+	return int(e.EventTypeHeaderLengths[t-1])
+}
+
+// RotateEvent is sent when the server switches to the next binlog file, and
+// at the start of the stream with the file it starts from.
+// https://dev.mysql.com/doc/internals/en/rotate-event.html
+type RotateEvent struct {
+	Position uint64
+	NextFile string
+}
+
+// QueryEvent is a statement, like BEGIN, a DDL statement or, with statement
+// based logging, the changes.
+// https://dev.mysql.com/doc/internals/en/query-event.html
+type QueryEvent struct {
+	ThreadID      uint32
+	ExecutionTime uint32
+	ErrorCode     uint16
+	Schema        string
+	Query         string
+}
+
+// XIDEvent is the commit of a transaction.
+// https://dev.mysql.com/doc/internals/en/xid-event.html
+type XIDEvent struct {
+	XID uint64
+}
+
+// GTIDEvent is the global transaction identifier of the next transaction.
+type GTIDEvent struct {
+	// SID is the UUID of the server which originated the transaction
+	SID string
+	GNO int64
+}
+
+// String returns the GTID in the format of the server, eg.
+// 3e11fa47-71ca-11e1-9e33-c80aa9429562:23.
+func (e *GTIDEvent) String() string {
+	return e.SID + ":" + strconv.FormatInt(e.GNO, 10)
+}
+
+// Parser decodes the events of a binlog stream, keeping the format
+// description and table maps which are needed to decode the later events.
+type Parser struct {
+	loc    *time.Location
+	format *FormatDescriptionEvent
+	tables map[uint64]*TableMapEvent
+
+	// checksum is set when the replica announced it supports checksums, so
+	// that the events sent before the format description event have one
+	checksum bool
+}
+
+// NewParser returns a Parser decoding DATETIME and TIMESTAMP values as
+// time.Time in loc, which defaults to UTC.
+func NewParser(loc *time.Location) *Parser {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Parser{loc: loc, tables: map[uint64]*TableMapEvent{}}
+}
+
+// Parse decodes the event in data, with its header.
+func (p *Parser) Parse(data []byte) (*Event, error) {
+	if len(data) < eventHeaderLength {
+		return nil, ErrShortEvent
+	}
+
+	ev := &Event{Header: EventHeader{
+		Timestamp: binary.LittleEndian.Uint32(data[0:]),
+		Type:      EventType(data[4]),
+		ServerID:  binary.LittleEndian.Uint32(data[5:]),
+		Size:      binary.LittleEndian.Uint32(data[9:]),
+		LogPos:    binary.LittleEndian.Uint32(data[13:]),
+		Flags:     binary.LittleEndian.Uint16(data[17:]),
+	}}
+	if int(ev.Header.Size) != len(data) {
+		return nil, fmt.Errorf("binlog: event size %d does not match its header %d", len(data), ev.Header.Size)
+	}
+
+	// the format description event always ends with the checksum algorithm,
+	// and its checksum if enabled
+	if ev.Header.Type == TypeFormatDescription {
+		format, err := parseFormatDescription(data[eventHeaderLength:])
+		if err != nil {
+			return nil, err
+		}
+		if format.ChecksumAlgorithm == checksumAlgCRC32 {
+			if err := verifyChecksum(data); err != nil {
+				return nil, err
+			}
+		}
+		p.format = format
+		ev.Data = format
+		return ev, nil
+	}
+
+	checksum := p.checksum
+	if p.format != nil {
+		checksum = p.format.ChecksumAlgorithm == checksumAlgCRC32
+	} else if !checksum && ev.Header.Type == TypeRotate {
+		// the fake rotate event starting a stream precedes the format
+		// description event, detect its checksum when the parser does not
+		// know whether the replica announced support for them
+		checksum = verifyChecksum(data) == nil
+	}
+	if checksum {
+		if err := verifyChecksum(data); err != nil {
+			return nil, err
+		}
+		data = data[:len(data)-checksumLength]
+	}
+	body := data[eventHeaderLength:]
+
+	var err error
+	switch ev.Header.Type {
+	case TypeRotate:
+		ev.Data, err = parseRotate(body)
+	case TypeQuery:
+		ev.Data, err = parseQuery(body)
+	case TypeXID:
+		if len(body) < 8 {
+			return nil, ErrShortEvent
+		}
+		ev.Data = &XIDEvent{XID: binary.LittleEndian.Uint64(body)}
+	case TypeGTID:
+		ev.Data, err = parseGTID(body)
+	case TypeTableMap:
+		var table *TableMapEvent
+		table, err = p.parseTableMap(body)
+		if err == nil {
+			p.tables[table.TableID] = table
+			ev.Data = table
+		}
+	case TypeWriteRowsV1, TypeUpdateRowsV1, TypeDeleteRowsV1, TypeWriteRowsV2, TypeUpdateRowsV2, TypeDeleteRowsV2:
+		ev.Data, err = p.parseRows(ev.Header.Type, body)
+	default:
+		raw := make([]byte, len(body))
+		copy(raw, body)
+		ev.Data = raw
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+func verifyChecksum(data []byte) error {
+	if len(data) < eventHeaderLength+checksumLength {
+		return ErrShortEvent
+	}
+	n := len(data) - checksumLength
+	if crc32.ChecksumIEEE(data[:n]) != binary.LittleEndian.Uint32(data[n:]) {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// https://dev.mysql.com/doc/internals/en/format-description-event.html
+func parseFormatDescription(data []byte) (*FormatDescriptionEvent, error) {
+	if len(data) < 2+50+4+1 {
+		return nil, ErrShortEvent
+	}
+	e := &FormatDescriptionEvent{
+		Version:           binary.LittleEndian.Uint16(data[0:]),
+		ServerVersion:     string(trimNull(data[2:52])),
+		CreateTimestamp:   binary.LittleEndian.Uint32(data[52:]),
+		EventHeaderLength: data[56],
+	}
+	lengths := data[57:]
+
+	// since 5.6.1 the lengths are followed by the checksum algorithm [1 byte]
+	// and the checksum [4 bytes]
+	if serverVersion(e.ServerVersion) >= checksumAlgVersion {
+		if len(lengths) < 1+checksumLength {
+			return nil, ErrShortEvent
+		}
+		e.ChecksumAlgorithm = lengths[len(lengths)-1-checksumLength]
+		lengths = lengths[:len(lengths)-1-checksumLength]
+	}
+	e.EventTypeHeaderLengths = append([]byte(nil), lengths...)
+	return e, nil
+}
+
+// serverVersion returns the server version as a number, eg. 50722 for
+// 5.7.22-log.
+func serverVersion(version string) int {
+	n := 0
+	for i, part := range strings.SplitN(version, ".", 3) {
+		if i == 2 {
+			// the patch version may be followed by a suffix
+			end := 0
+			for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+				end++
+			}
+			part = part[:end]
+		}
+		v, _ := strconv.Atoi(part)
+		n = n*100 + v
+	}
+	return n
+}
+
+func trimNull(b []byte) []byte {
+	for i, c := range b {
+		if c == 0 {
+			return b[:i]
+		}
+	}
+	return b
+}
+
+func parseRotate(data []byte) (*RotateEvent, error) {
+	if len(data) < 8 {
+		return nil, ErrShortEvent
+	}
+	return &RotateEvent{
+		Position: binary.LittleEndian.Uint64(data),
+		NextFile: string(data[8:]),
+	}, nil
+}
+
+func parseQuery(data []byte) (*QueryEvent, error) {
+	// thread id [4 bytes], execution time [4 bytes], schema length [1 byte],
+	// error code [2 bytes], status vars length [2 bytes]
+	if len(data) < 13 {
+		return nil, ErrShortEvent
+	}
+	e := &QueryEvent{
+		ThreadID:      binary.LittleEndian.Uint32(data[0:]),
+		ExecutionTime: binary.LittleEndian.Uint32(data[4:]),
+		ErrorCode:     binary.LittleEndian.Uint16(data[9:]),
+	}
+	schemaLen := int(data[8])
+	pos := 13 + int(binary.LittleEndian.Uint16(data[11:]))
+
+	// schema [schema length bytes], 0x00, query [EOF]
+	if len(data) < pos+schemaLen+1 {
+		return nil, ErrShortEvent
+	}
+	e.Schema = string(data[pos : pos+schemaLen])
+	e.Query = string(data[pos+schemaLen+1:])
+	return e, nil
+}
+
+func parseGTID(data []byte) (*GTIDEvent, error) {
+	// commit flag [1 byte], sid [16 bytes], gno [8 bytes]
+	if len(data) < 25 {
+		return nil, ErrShortEvent
+	}
+	return &GTIDEvent{
+		SID: formatUUID(data[1:17]),
+		GNO: int64(binary.LittleEndian.Uint64(data[17:])),
+	}, nil
+}
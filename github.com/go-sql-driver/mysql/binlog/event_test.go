@@ -0,0 +1,367 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package binlog
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// event returns an event of type t with body, at the position logPos.
+func event(t EventType, logPos uint32, body []byte, checksum bool) []byte {
+	size := eventHeaderLength + len(body)
+	if checksum {
+		size += checksumLength
+	}
+	data := make([]byte, eventHeaderLength, size)
+	binary.LittleEndian.PutUint32(data[0:], 1527854096)
+	data[4] = byte(t)
+	binary.LittleEndian.PutUint32(data[5:], 1)
+	binary.LittleEndian.PutUint32(data[9:], uint32(size))
+	binary.LittleEndian.PutUint32(data[13:], logPos)
+	data = append(data, body...)
+	if checksum {
+		var crc [4]byte
+		binary.LittleEndian.PutUint32(crc[:], crc32.ChecksumIEEE(data))
+		data = append(data, crc[:]...)
+	}
+	return data
+}
+
+func formatDescription(version string, checksum bool) []byte {
+	body := make([]byte, 57)
+	binary.LittleEndian.PutUint16(body, 4)
+	copy(body[2:52], version)
+	body[56] = eventHeaderLength
+
+	// post-header lengths of the events up to PREVIOUS_GTIDS_EVENT
+	lengths := make([]byte, TypePreviousGTIDs)
+	lengths[TypeTableMap-1] = 8
+	lengths[TypeWriteRowsV2-1] = 10
+	lengths[TypeUpdateRowsV2-1] = 10
+	lengths[TypeDeleteRowsV2-1] = 10
+	body = append(body, lengths...)
+
+	if checksum {
+		body = append(body, checksumAlgCRC32)
+	} else {
+		body = append(body, 0)
+	}
+	return event(TypeFormatDescription, 120, body, checksum)
+}
+
+func lengthEncoded(b []byte) []byte {
+	return append([]byte{byte(len(b))}, b...)
+}
+
+func datetime2(year, month, day, hour, min, sec int) []byte {
+	ym := uint64(year*13 + month)
+	v := (ym<<5|uint64(day))<<17 | uint64(hour<<12|min<<6|sec)
+	v += 0x8000000000
+	return []byte{byte(v >> 32), byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+// {"a": 1, "b": [true, "x"]}
+var jsonDocument = []byte{
+	0x00,       // small object
+	0x02, 0x00, // count
+	0x20, 0x00, // size
+	0x12, 0x00, 0x01, 0x00, // key "a"
+	0x13, 0x00, 0x01, 0x00, // key "b"
+	0x05, 0x01, 0x00, // int16 1
+	0x02, 0x14, 0x00, // small array at 20
+	'a', 'b',
+	0x02, 0x00, // count
+	0x0c, 0x00, // size
+	0x04, 0x01, 0x00, // true
+	0x0c, 0x0a, 0x00, // string at 10
+	0x01, 'x',
+}
+
+func TestParseRows(t *testing.T) {
+	p := NewParser(nil)
+	if _, err := p.Parse(formatDescription("5.7.22-log", true)); err != nil {
+		t.Fatal(err)
+	}
+
+	// CREATE TABLE shop.orders (id INT, name VARCHAR(50), amount DECIMAL(10,2),
+	// created DATETIME, qty TINYINT UNSIGNED, doc JSON, state ENUM('a', 'b'))
+	tableMap := []byte{0x2a, 0, 0, 0, 0, 0, 0x01, 0x00}
+	tableMap = append(tableMap, lengthEncoded([]byte("shop"))...)
+	tableMap = append(tableMap, 0)
+	tableMap = append(tableMap, lengthEncoded([]byte("orders"))...)
+	tableMap = append(tableMap, 0)
+	tableMap = append(tableMap, lengthEncoded([]byte{typeLong, typeVarChar, typeNewDecimal, typeDateTime2, typeTiny, typeJSON, typeString})...)
+	tableMap = append(tableMap, lengthEncoded([]byte{
+		200, 0, // VARCHAR(50) of 4 byte characters
+		10, 2, // DECIMAL(10,2)
+		0,           // DATETIME(0)
+		4,           // JSON
+		typeEnum, 1, // ENUM
+	})...)
+	tableMap = append(tableMap, 0x7e) // null bitmap
+	tableMap = append(tableMap, metadataSignedness)
+	tableMap = append(tableMap, lengthEncoded([]byte{0x20})...) // qty, the 4th numeric column
+	names := []byte{}
+	for _, name := range []string{"id", "name", "amount", "created", "qty", "doc", "state"} {
+		names = append(names, lengthEncoded([]byte(name))...)
+	}
+	tableMap = append(tableMap, metadataColumnName)
+	tableMap = append(tableMap, lengthEncoded(names)...)
+
+	ev, err := p.Parse(event(TypeTableMap, 300, tableMap, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := ev.Data.(*TableMapEvent)
+	if table.TableID != 42 || table.Schema != "shop" || table.Table != "orders" {
+		t.Fatalf("unexpected table map %+v", table)
+	}
+	if !reflect.DeepEqual(table.Columns, []string{"id", "name", "amount", "created", "qty", "doc", "state"}) {
+		t.Errorf("unexpected column names %v", table.Columns)
+	}
+
+	rows := []byte{0x2a, 0, 0, 0, 0, 0, 0x01, 0x00, 0x02, 0x00, 0x07, 0x7f}
+	// first row
+	rows = append(rows, 0x00)
+	rows = append(rows, 0x01, 0x00, 0x00, 0x00)
+	rows = append(rows, lengthEncoded([]byte("tea"))...)
+	rows = append(rows, 0x80, 0x00, 0x04, 0xd2, 0x38)
+	rows = append(rows, datetime2(2018, 6, 1, 12, 34, 56)...)
+	rows = append(rows, 0xfa)
+	rows = append(rows, byte(len(jsonDocument)), 0, 0, 0)
+	rows = append(rows, jsonDocument...)
+	rows = append(rows, 0x02)
+	// second row, with NULL name and doc
+	rows = append(rows, 0x22)
+	rows = append(rows, 0xfe, 0xff, 0xff, 0xff)
+	rows = append(rows, 0x7f, 0xff, 0xfb, 0x2d, 0xc7)
+	rows = append(rows, datetime2(0, 0, 0, 0, 0, 0)...)
+	rows = append(rows, 0x01)
+	rows = append(rows, 0x01)
+
+	ev, err = p.Parse(event(TypeWriteRowsV2, 400, rows, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := ev.Data.(*RowsEvent)
+	if e.Action != ActionInsert || e.Table != table || e.Before != nil {
+		t.Fatalf("unexpected rows event %+v", e)
+	}
+	expected := [][]interface{}{
+		{int32(1), "tea", "1234.56", time.Date(2018, 6, 1, 12, 34, 56, 0, time.UTC), uint8(250),
+			map[string]interface{}{"a": int64(1), "b": []interface{}{true, "x"}}, uint16(2)},
+		{int32(-2), nil, "-1234.56", time.Time{}, uint8(1), nil, uint16(1)},
+	}
+	if !reflect.DeepEqual(e.Rows, expected) {
+		t.Errorf("unexpected rows\n%#v\nexpected\n%#v", e.Rows, expected)
+	}
+}
+
+func TestParseUpdateRows(t *testing.T) {
+	p := NewParser(nil)
+	if _, err := p.Parse(formatDescription("8.0.11", false)); err != nil {
+		t.Fatal(err)
+	}
+
+	tableMap := []byte{0x07, 0, 0, 0, 0, 0, 0x01, 0x00}
+	tableMap = append(tableMap, lengthEncoded([]byte("shop"))...)
+	tableMap = append(tableMap, 0)
+	tableMap = append(tableMap, lengthEncoded([]byte("stock"))...)
+	tableMap = append(tableMap, 0)
+	tableMap = append(tableMap, lengthEncoded([]byte{typeLongLong, typeShort})...)
+	tableMap = append(tableMap, 0, 0x02)
+	if _, err := p.Parse(event(TypeTableMap, 300, tableMap, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the before image only has the key, like with binlog_row_image=MINIMAL
+	rows := []byte{0x07, 0, 0, 0, 0, 0, 0x01, 0x00, 0x02, 0x00, 0x02, 0x01, 0x03}
+	rows = append(rows, 0x00, 0x05, 0, 0, 0, 0, 0, 0, 0)
+	rows = append(rows, 0x00, 0x05, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff)
+
+	ev, err := p.Parse(event(TypeUpdateRowsV2, 400, rows, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := ev.Data.(*RowsEvent)
+	if e.Action != ActionUpdate {
+		t.Fatalf("expected an update, got %s", e.Action)
+	}
+	if !reflect.DeepEqual(e.Before, [][]interface{}{{int64(5), nil}}) {
+		t.Errorf("unexpected before image %#v", e.Before)
+	}
+	if !reflect.DeepEqual(e.Rows, [][]interface{}{{int64(5), int16(-1)}}) {
+		t.Errorf("unexpected after image %#v", e.Rows)
+	}
+
+	// rows of a table without table map
+	rows[0] = 0x08
+	if _, err := p.Parse(event(TypeDeleteRowsV2, 500, rows, false)); err == nil {
+		t.Error("expected an error for an unknown table")
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	p := NewParser(nil)
+	if _, err := p.Parse(formatDescription("5.7.22-log", true)); err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := p.Parse(event(TypeRotate, 0, append([]byte{4, 0, 0, 0, 0, 0, 0, 0}, "bin.000002"...), true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotate := ev.Data.(*RotateEvent); rotate.NextFile != "bin.000002" || rotate.Position != 4 {
+		t.Errorf("unexpected rotate event %+v", rotate)
+	}
+
+	query := []byte{0x05, 0, 0, 0, 0x01, 0, 0, 0, 0x04, 0, 0, 0x02, 0x00, 0xff, 0xff}
+	query = append(query, "shop"...)
+	query = append(query, 0)
+	query = append(query, "ALTER TABLE orders ADD note TEXT"...)
+	ev, err = p.Parse(event(TypeQuery, 200, query, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := ev.Data.(*QueryEvent); q.ThreadID != 5 || q.Schema != "shop" || q.Query != "ALTER TABLE orders ADD note TEXT" {
+		t.Errorf("unexpected query event %+v", q)
+	}
+
+	ev, err = p.Parse(event(TypeXID, 300, []byte{0x39, 0x30, 0, 0, 0, 0, 0, 0}, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xid := ev.Data.(*XIDEvent); xid.XID != 12345 || ev.Header.LogPos != 300 {
+		t.Errorf("unexpected xid event %+v %+v", ev.Header, xid)
+	}
+
+	gtid := []byte{0x01, 0x3e, 0x11, 0xfa, 0x47, 0x71, 0xca, 0x11, 0xe1, 0x9e, 0x33, 0xc8, 0x0a, 0xa9, 0x42, 0x95, 0x62, 23, 0, 0, 0, 0, 0, 0, 0}
+	ev, err = p.Parse(event(TypeGTID, 400, gtid, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := ev.Data.(*GTIDEvent); g.String() != "3e11fa47-71ca-11e1-9e33-c80aa9429562:23" {
+		t.Errorf("unexpected GTID %s", g)
+	}
+
+	corrupted := event(TypeXID, 300, []byte{0x39, 0x30, 0, 0, 0, 0, 0, 0}, true)
+	corrupted[20]++
+	if _, err = p.Parse(corrupted); err != ErrChecksum {
+		t.Errorf("expected ErrChecksum, got %v", err)
+	}
+}
+
+// Parse the fake rotate event which starts a stream, before the format
+// description event, with and without checksum.
+func TestParseRotateBeforeFormat(t *testing.T) {
+	body := append([]byte{4, 0, 0, 0, 0, 0, 0, 0}, "bin.000002"...)
+	tests := []struct {
+		negotiated, checksum bool
+	}{
+		{false, false},
+		{false, true},
+		{true, true},
+	}
+	for _, test := range tests {
+		p := NewParser(nil)
+		p.checksum = test.negotiated
+		ev, err := p.Parse(event(TypeRotate, 0, body, test.checksum))
+		if err != nil {
+			t.Fatalf("checksum %v: %v", test.checksum, err)
+		}
+		if rotate := ev.Data.(*RotateEvent); rotate.NextFile != "bin.000002" || rotate.Position != 4 {
+			t.Errorf("checksum %v: unexpected rotate event %+v", test.checksum, rotate)
+		}
+	}
+
+	p := NewParser(nil)
+	p.checksum = true
+	corrupted := event(TypeRotate, 0, body, true)
+	corrupted[len(corrupted)-5]++
+	if _, err := p.Parse(corrupted); err != ErrChecksum {
+		t.Errorf("expected ErrChecksum, got %v", err)
+	}
+}
+
+func TestDecodeValues(t *testing.T) {
+	loc := time.FixedZone("test", 3600)
+	tests := []struct {
+		t        byte
+		meta     uint16
+		unsigned bool
+		data     []byte
+		expected interface{}
+	}{
+		{typeInt24, 0, false, []byte{0xff, 0xff, 0xff}, int32(-1)},
+		{typeInt24, 0, true, []byte{0xff, 0xff, 0xff}, uint32(0xffffff)},
+		{typeDouble, 8, false, []byte{0, 0, 0, 0, 0, 0, 0xf8, 0x3f}, 1.5},
+		{typeYear, 0, false, []byte{118}, 2018},
+		{typeDate, 0, false, []byte{0xc1, 0xcc, 0x0f}, time.Date(2022, 6, 1, 0, 0, 0, 0, loc)},
+		{typeTimestamp2, 3, false, []byte{0x5b, 0x11, 0x2c, 0x10, 0x13, 0x88}, time.Unix(1527852048, 500000000).In(loc)},
+		{typeTime2, 0, false, []byte{0x80, 0xf0, 0x8a}, 15*time.Hour + 2*time.Minute + 10*time.Second},
+		{typeTime2, 0, false, []byte{0x7f, 0xf0, 0x00}, -time.Hour},
+		{typeNewDecimal, 4<<8 | 4, false, []byte{0x80, 0x0c}, "0.0012"},
+		{typeString, uint16(typeString)<<8 | 10, false, []byte{0x02, 'o', 'k'}, "ok"},
+		{typeString, uint16(typeSet)<<8 | 1, false, []byte{0x05}, uint64(5)},
+		{typeBit, 1<<8 | 1, false, []byte{0x01, 0x02}, uint64(0x0102)},
+		{typeBLOB, 2, false, []byte{0x03, 0x00, 'a', 'b', 'c'}, []byte("abc")},
+	}
+	for _, test := range tests {
+		v, n, err := decodeValue(test.data, test.t, test.meta, test.unsigned, loc)
+		if err != nil {
+			t.Errorf("type %d: %v", test.t, err)
+			continue
+		}
+		if n != len(test.data) {
+			t.Errorf("type %d: expected length %d, got %d", test.t, len(test.data), n)
+		}
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Errorf("type %d: expected %#v, got %#v", test.t, test.expected, v)
+		}
+	}
+}
+
+func TestStreamerPosition(t *testing.T) {
+	s := &Streamer{gtids: &GTIDSet{}}
+	sid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	events := []*Event{
+		{Data: &RotateEvent{Position: 4, NextFile: "bin.000002"}},
+		{Header: EventHeader{LogPos: 120}, Data: &FormatDescriptionEvent{}},
+		{Header: EventHeader{LogPos: 190}, Data: &GTIDEvent{SID: sid, GNO: 7}},
+		{Header: EventHeader{LogPos: 260}, Data: &QueryEvent{Query: "BEGIN"}},
+		{Header: EventHeader{LogPos: 320}, Data: &TableMapEvent{}},
+		{Header: EventHeader{LogPos: 400}, Data: &RowsEvent{}},
+	}
+	for _, ev := range events {
+		s.track(ev)
+	}
+	if pos := s.Position(); pos != (Position{"bin.000002", 4}) {
+		t.Errorf("expected the position not to move inside a transaction, got %s", pos)
+	}
+	if s.GTIDSet().Contains(sid, 7) {
+		t.Error("expected the transaction not to be committed")
+	}
+
+	s.track(&Event{Header: EventHeader{LogPos: 431}, Data: &XIDEvent{}})
+	if pos := s.Position(); pos != (Position{"bin.000002", 431}) {
+		t.Errorf("expected the position after the commit, got %s", pos)
+	}
+	if !s.GTIDSet().Contains(sid, 7) {
+		t.Error("expected the transaction to be committed")
+	}
+
+	s.track(&Event{Header: EventHeader{LogPos: 500}, Data: &QueryEvent{Query: "DROP TABLE tmp"}})
+	if pos := s.Position(); pos.Pos != 500 {
+		t.Errorf("expected the position after the DDL statement, got %s", pos)
+	}
+}
@@ -0,0 +1,190 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package binlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gtidInterval is a range of transaction numbers, from start to end excluded.
+type gtidInterval struct {
+	start, end int64
+}
+
+// GTIDSet is a set of global transaction identifiers, like gtid_executed.
+// The zero value is an empty set.
+type GTIDSet struct {
+	sets map[string][]gtidInterval
+}
+
+// ParseGTIDSet parses a GTID set in the format of the server, eg.
+// 3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:7,4f12ab58-82db-22f2-af44-d91bb0530673:1
+func ParseGTIDSet(s string) (*GTIDSet, error) {
+	set := &GTIDSet{}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return set, nil
+	}
+
+	for _, sidSet := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(sidSet), ":")
+		sid, err := parseUUID(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("binlog: GTID set %q without transaction numbers", sidSet)
+		}
+		for _, interval := range parts[1:] {
+			bounds := strings.SplitN(interval, "-", 2)
+			start, err := strconv.ParseInt(bounds[0], 10, 64)
+			if err != nil || start < 1 {
+				return nil, fmt.Errorf("binlog: invalid GTID interval %q", interval)
+			}
+			end := start
+			if len(bounds) == 2 {
+				end, err = strconv.ParseInt(bounds[1], 10, 64)
+				if err != nil || end < start {
+					return nil, fmt.Errorf("binlog: invalid GTID interval %q", interval)
+				}
+			}
+			set.addInterval(sid, gtidInterval{start, end + 1})
+		}
+	}
+	return set, nil
+}
+
+// Add adds the transaction gno of the server sid to the set.
+func (s *GTIDSet) Add(sid string, gno int64) error {
+	uuid, err := parseUUID(sid)
+	if err != nil {
+		return err
+	}
+	s.addInterval(uuid, gtidInterval{gno, gno + 1})
+	return nil
+}
+
+// Contains tells if the transaction gno of the server sid is in the set.
+func (s *GTIDSet) Contains(sid string, gno int64) bool {
+	uuid, err := parseUUID(sid)
+	if err != nil {
+		return false
+	}
+	for _, interval := range s.sets[uuid] {
+		if gno >= interval.start && gno < interval.end {
+			return true
+		}
+	}
+	return false
+}
+
+// addInterval adds an interval, merging it with the ones it overlaps or
+// touches.
+func (s *GTIDSet) addInterval(sid string, add gtidInterval) {
+	if s.sets == nil {
+		s.sets = map[string][]gtidInterval{}
+	}
+	intervals := append(s.sets[sid], add)
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+
+	merged := intervals[:1]
+	for _, interval := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if interval.start <= last.end {
+			if interval.end > last.end {
+				last.end = interval.end
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	s.sets[sid] = merged
+}
+
+// Clone returns a copy of the set.
+func (s *GTIDSet) Clone() *GTIDSet {
+	clone := &GTIDSet{sets: make(map[string][]gtidInterval, len(s.sets))}
+	for sid, intervals := range s.sets {
+		clone.sets[sid] = append([]gtidInterval(nil), intervals...)
+	}
+	return clone
+}
+
+func (s *GTIDSet) sids() []string {
+	sids := make([]string, 0, len(s.sets))
+	for sid := range s.sets {
+		sids = append(sids, sid)
+	}
+	sort.Strings(sids)
+	return sids
+}
+
+// String returns the set in the format of the server.
+func (s *GTIDSet) String() string {
+	var buf bytes.Buffer
+	for i, sid := range s.sids() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(sid)
+		for _, interval := range s.sets[sid] {
+			buf.WriteByte(':')
+			buf.WriteString(strconv.FormatInt(interval.start, 10))
+			if interval.end-1 > interval.start {
+				buf.WriteByte('-')
+				buf.WriteString(strconv.FormatInt(interval.end-1, 10))
+			}
+		}
+	}
+	return buf.String()
+}
+
+// Encode returns the set in the binary format of COM_BINLOG_DUMP_GTID:
+// the number of servers [8 bytes], and for each server its UUID [16 bytes],
+// the number of intervals [8 bytes] and their start and end [8 bytes each].
+func (s *GTIDSet) Encode() []byte {
+	sids := s.sids()
+	buf := make([]byte, 8, 8+len(sids)*(16+8+16))
+	binary.LittleEndian.PutUint64(buf, uint64(len(sids)))
+
+	var b [8]byte
+	for _, sid := range sids {
+		uuid, _ := hex.DecodeString(strings.Replace(sid, "-", "", -1))
+		buf = append(buf, uuid...)
+		binary.LittleEndian.PutUint64(b[:], uint64(len(s.sets[sid])))
+		buf = append(buf, b[:]...)
+		for _, interval := range s.sets[sid] {
+			binary.LittleEndian.PutUint64(b[:], uint64(interval.start))
+			buf = append(buf, b[:]...)
+			binary.LittleEndian.PutUint64(b[:], uint64(interval.end))
+			buf = append(buf, b[:]...)
+		}
+	}
+	return buf
+}
+
+// parseUUID returns the canonical form of a server UUID.
+func parseUUID(s string) (string, error) {
+	b, err := hex.DecodeString(strings.Replace(strings.TrimSpace(s), "-", "", -1))
+	if err != nil || len(b) != 16 {
+		return "", fmt.Errorf("binlog: invalid server UUID %q", s)
+	}
+	return formatUUID(b), nil
+}
+
+func formatUUID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,71 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package binlog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseGTIDSet(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"", ""},
+		{"3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5", "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"},
+		{"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:6:9-10", "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-6:9-10"},
+		{"4f12ab58-82db-22f2-af44-d91bb0530673:3,\n3e11fa47-71ca-11e1-9e33-c80aa9429562:2-4:1",
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-4,4f12ab58-82db-22f2-af44-d91bb0530673:3"},
+	}
+	for _, test := range tests {
+		set, err := ParseGTIDSet(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if set.String() != test.out {
+			t.Errorf("%q: expected %q, got %q", test.in, test.out, set.String())
+		}
+	}
+
+	for _, in := range []string{"3e11fa47:1", "3e11fa47-71ca-11e1-9e33-c80aa9429562", "3e11fa47-71ca-11e1-9e33-c80aa9429562:5-1", "3e11fa47-71ca-11e1-9e33-c80aa9429562:0"} {
+		if _, err := ParseGTIDSet(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestGTIDSetAdd(t *testing.T) {
+	sid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	set, _ := ParseGTIDSet(sid + ":1-3:5")
+	clone := set.Clone()
+	if err := set.Add(sid, 4); err != nil {
+		t.Fatal(err)
+	}
+	if set.String() != sid+":1-5" {
+		t.Errorf("expected the intervals to be merged, got %s", set)
+	}
+	if clone.Contains(sid, 4) || !set.Contains(sid, 4) {
+		t.Error("expected the transaction to only be added to the set")
+	}
+}
+
+func TestGTIDSetEncode(t *testing.T) {
+	set, _ := ParseGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5")
+	expected := []byte{
+		1, 0, 0, 0, 0, 0, 0, 0,
+		0x3e, 0x11, 0xfa, 0x47, 0x71, 0xca, 0x11, 0xe1, 0x9e, 0x33, 0xc8, 0x0a, 0xa9, 0x42, 0x95, 0x62,
+		1, 0, 0, 0, 0, 0, 0, 0,
+		1, 0, 0, 0, 0, 0, 0, 0,
+		6, 0, 0, 0, 0, 0, 0, 0,
+	}
+	if encoded := set.Encode(); !bytes.Equal(encoded, expected) {
+		t.Errorf("unexpected encoding\n%v\nexpected\n%v", encoded, expected)
+	}
+}
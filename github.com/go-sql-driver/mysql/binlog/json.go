@@ -0,0 +1,230 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package binlog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Value types of the binary JSON format of MySQL.
+// https://dev.mysql.com/worklog/task/?id=8132
+const (
+	jsonSmallObject byte = iota
+	jsonLargeObject
+	jsonSmallArray
+	jsonLargeArray
+	jsonLiteral
+	jsonInt16
+	jsonUint16
+	jsonInt32
+	jsonUint32
+	jsonInt64
+	jsonUint64
+	jsonDouble
+	jsonString
+	jsonOpaque byte = 0x0f
+)
+
+const (
+	jsonLiteralNull  byte = 0x00
+	jsonLiteralTrue  byte = 0x01
+	jsonLiteralFalse byte = 0x02
+)
+
+// decodeJSON decodes a JSON document in the binary format of MySQL.
+func decodeJSON(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return decodeJSONValue(data[0], data[1:])
+}
+
+func decodeJSONValue(t byte, data []byte) (interface{}, error) {
+	switch t {
+	case jsonSmallObject, jsonLargeObject:
+		return decodeJSONComposite(data, t == jsonLargeObject, true)
+
+	case jsonSmallArray, jsonLargeArray:
+		return decodeJSONComposite(data, t == jsonLargeArray, false)
+
+	case jsonLiteral:
+		if len(data) < 1 {
+			return nil, ErrShortEvent
+		}
+		switch data[0] {
+		case jsonLiteralNull:
+			return nil, nil
+		case jsonLiteralTrue:
+			return true, nil
+		case jsonLiteralFalse:
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid JSON literal %d", data[0])
+
+	case jsonInt16, jsonUint16:
+		if len(data) < 2 {
+			return nil, ErrShortEvent
+		}
+		v := binary.LittleEndian.Uint16(data)
+		if t == jsonUint16 {
+			return uint64(v), nil
+		}
+		return int64(int16(v)), nil
+
+	case jsonInt32, jsonUint32:
+		if len(data) < 4 {
+			return nil, ErrShortEvent
+		}
+		v := binary.LittleEndian.Uint32(data)
+		if t == jsonUint32 {
+			return uint64(v), nil
+		}
+		return int64(int32(v)), nil
+
+	case jsonInt64, jsonUint64:
+		if len(data) < 8 {
+			return nil, ErrShortEvent
+		}
+		v := binary.LittleEndian.Uint64(data)
+		if t == jsonUint64 {
+			return v, nil
+		}
+		return int64(v), nil
+
+	case jsonDouble:
+		if len(data) < 8 {
+			return nil, ErrShortEvent
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+
+	case jsonString:
+		length, n := readJSONVariableLength(data)
+		if n == 0 || len(data) < n+length {
+			return nil, ErrShortEvent
+		}
+		return string(data[n : n+length]), nil
+
+	case jsonOpaque:
+		// column type [1 byte], length [variable length], value
+		if len(data) < 1 {
+			return nil, ErrShortEvent
+		}
+		length, n := readJSONVariableLength(data[1:])
+		if n == 0 || len(data) < 1+n+length {
+			return nil, ErrShortEvent
+		}
+		value := data[1+n : 1+n+length]
+		if data[0] == typeNewDecimal && len(value) >= 2 {
+			// precision [1 byte], scale [1 byte], value
+			v, _, err := decodeDecimal(value[2:], int(value[0]), int(value[1]))
+			return v, err
+		}
+		return append([]byte{}, value...), nil
+	}
+	return nil, fmt.Errorf("invalid JSON value type %d", t)
+}
+
+// decodeJSONComposite decodes an object or an array:  its element count and
+// size, the key entries of objects, the value entries, and the keys and
+// values which do not fit in their entries.  Offsets and counts use 2 bytes
+// in small composites and 4 bytes in large ones.
+func decodeJSONComposite(data []byte, large bool, object bool) (interface{}, error) {
+	size := 2
+	if large {
+		size = 4
+	}
+	if len(data) < 2*size {
+		return nil, ErrShortEvent
+	}
+	count := int(readUint(data[:size]))
+	length := int(readUint(data[size : 2*size]))
+	if len(data) < length {
+		return nil, ErrShortEvent
+	}
+	data = data[:length]
+
+	pos := 2 * size
+	var keys []string
+	if object {
+		// key offset [size bytes], key length [2 bytes]
+		keys = make([]string, count)
+		for i := range keys {
+			if len(data) < pos+size+2 {
+				return nil, ErrShortEvent
+			}
+			offset := int(readUint(data[pos : pos+size]))
+			keyLength := int(binary.LittleEndian.Uint16(data[pos+size:]))
+			if len(data) < offset+keyLength {
+				return nil, ErrShortEvent
+			}
+			keys[i] = string(data[offset : offset+keyLength])
+			pos += size + 2
+		}
+	}
+
+	// value type [1 byte], inlined value or offset [size bytes]
+	values := make([]interface{}, count)
+	for i := range values {
+		if len(data) < pos+1+size {
+			return nil, ErrShortEvent
+		}
+		t := data[pos]
+		entry := data[pos+1 : pos+1+size]
+		pos += 1 + size
+
+		var err error
+		if jsonInlined(t, large) {
+			values[i], err = decodeJSONValue(t, entry)
+		} else {
+			offset := int(readUint(entry))
+			if len(data) < offset {
+				return nil, ErrShortEvent
+			}
+			values[i], err = decodeJSONValue(t, data[offset:])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !object {
+		return values, nil
+	}
+	m := make(map[string]interface{}, count)
+	for i, key := range keys {
+		m[key] = values[i]
+	}
+	return m, nil
+}
+
+// jsonInlined tells if values of type t are stored in their value entry.
+func jsonInlined(t byte, large bool) bool {
+	switch t {
+	case jsonLiteral, jsonInt16, jsonUint16:
+		return true
+	case jsonInt32, jsonUint32:
+		return large
+	}
+	return false
+}
+
+// readJSONVariableLength reads a length stored in 7 bits per byte, with the
+// highest bit set if more bytes follow.
+func readJSONVariableLength(data []byte) (int, int) {
+	length := 0
+	for i := 0; i < 5 && i < len(data); i++ {
+		length |= int(data[i]&0x7f) << uint(7*i)
+		if data[i]&0x80 == 0 {
+			return length, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,815 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package binlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column types of the binlog, which extends the ones of the protocol with the
+// fractional seconds time types.
+const (
+	typeDecimal byte = iota
+	typeTiny
+	typeShort
+	typeLong
+	typeFloat
+	typeDouble
+	typeNULL
+	typeTimestamp
+	typeLongLong
+	typeInt24
+	typeDate
+	typeTime
+	typeDateTime
+	typeYear
+	typeNewDate
+	typeVarChar
+	typeBit
+	typeTimestamp2
+	typeDateTime2
+	typeTime2
+)
+const (
+	typeJSON byte = iota + 0xf5
+	typeNewDecimal
+	typeEnum
+	typeSet
+	typeTinyBLOB
+	typeMediumBLOB
+	typeLongBLOB
+	typeBLOB
+	typeVarString
+	typeString
+	typeGeometry
+)
+
+// Optional metadata of table maps, logged with binlog_row_metadata=FULL
+// since MySQL 8.0.1.
+const (
+	metadataSignedness = 1
+	metadataColumnName = 4
+)
+
+// Row actions
+const (
+	ActionInsert = "insert"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// TableMapEvent maps a table id to the table and the types of its columns,
+// before the rows events of the table.
+// https://dev.mysql.com/doc/internals/en/table-map-event.html
+type TableMapEvent struct {
+	TableID     uint64
+	Schema      string
+	Table       string
+	ColumnTypes []byte
+	ColumnMeta  []uint16
+	NullBitmap  []byte
+	// Columns are the names of the columns, only logged by servers with
+	// binlog_row_metadata=FULL
+	Columns []string
+	// Unsigned tells which columns are unsigned numbers, only logged by
+	// servers with binlog_row_metadata=FULL
+	Unsigned []bool
+}
+
+// RowsEvent holds the rows inserted, updated or deleted by a statement.
+// https://dev.mysql.com/doc/internals/en/rows-event.html
+//
+// The values of the columns are decoded as:
+//
+//  TINYINT ... BIGINT     int8, int16, int32 or int64, or the unsigned types
+//                         for unsigned columns of servers logging their
+//                         signedness
+//  FLOAT, DOUBLE          float32, float64
+//  DECIMAL                string
+//  DATE, DATETIME,
+//  TIMESTAMP              time.Time, the zero time.Time for zero dates
+//  TIME                   time.Duration
+//  YEAR                   int
+//  CHAR, VARCHAR          string
+//  BINARY, BLOB, TEXT,
+//  GEOMETRY               []byte
+//  ENUM                   uint16, the index of the value
+//  SET                    uint64, the bitmask of the values
+//  BIT                    uint64
+//  JSON                   nil, bool, int64, uint64, float64, string,
+//                         []interface{} or map[string]interface{}
+//
+// Columns which are NULL or were not logged, with binlog_row_image=MINIMAL,
+// are nil.
+type RowsEvent struct {
+	Action  string
+	TableID uint64
+	Table   *TableMapEvent
+	Flags   uint16
+	// Rows are the inserted or deleted rows, or the rows after an update
+	Rows [][]interface{}
+	// Before are the rows before an update, in the order of Rows
+	Before [][]interface{}
+}
+
+func (p *Parser) tableIDWidth(t EventType) int {
+	if p.format.postHeaderLength(t) == 6 {
+		return 4
+	}
+	return defaultTableIDWidth
+}
+
+func (p *Parser) parseTableMap(data []byte) (*TableMapEvent, error) {
+	width := p.tableIDWidth(TypeTableMap)
+	if len(data) < width+2+1 {
+		return nil, ErrShortEvent
+	}
+
+	// table id [4 or 6 bytes], flags [2 bytes]
+	e := &TableMapEvent{TableID: readUint(data[:width])}
+	pos := width + 2
+
+	// schema and table [length prefixed strings, followed by 0x00]
+	for _, name := range []*string{&e.Schema, &e.Table} {
+		if len(data) < pos+1 || len(data) < pos+1+int(data[pos])+1 {
+			return nil, ErrShortEvent
+		}
+		n := int(data[pos])
+		*name = string(data[pos+1 : pos+1+n])
+		pos += 1 + n + 1
+	}
+
+	// column count [length encoded integer], column types [column count bytes]
+	count, n := readLengthEncodedInteger(data[pos:])
+	pos += n
+	if n == 0 || len(data) < pos+int(count) {
+		return nil, ErrShortEvent
+	}
+	e.ColumnTypes = append([]byte(nil), data[pos:pos+int(count)]...)
+	pos += int(count)
+
+	// column metadata [length encoded string]
+	metaLen, n := readLengthEncodedInteger(data[pos:])
+	pos += n
+	if n == 0 || len(data) < pos+int(metaLen) {
+		return nil, ErrShortEvent
+	}
+	meta, err := parseColumnMeta(e.ColumnTypes, data[pos:pos+int(metaLen)])
+	if err != nil {
+		return nil, err
+	}
+	e.ColumnMeta = meta
+	pos += int(metaLen)
+
+	// null bitmap [(column count + 7) / 8 bytes]
+	bitmapLen := (int(count) + 7) / 8
+	if len(data) < pos+bitmapLen {
+		return nil, ErrShortEvent
+	}
+	e.NullBitmap = append([]byte(nil), data[pos:pos+bitmapLen]...)
+	pos += bitmapLen
+
+	return e, e.parseOptionalMeta(data[pos:])
+}
+
+// parseColumnMeta returns the metadata of each column, which gives the
+// length or precision of its values.
+func parseColumnMeta(types []byte, data []byte) ([]uint16, error) {
+	meta := make([]uint16, len(types))
+	pos := 0
+	for i, t := range types {
+		var n int
+		switch t {
+		case typeString, typeNewDecimal, typeEnum, typeSet:
+			// real type or precision [1 byte], length or scale [1 byte]
+			n = 2
+			if len(data) >= pos+n {
+				meta[i] = uint16(data[pos])<<8 | uint16(data[pos+1])
+			}
+		case typeVarChar, typeVarString, typeBit:
+			n = 2
+			if len(data) >= pos+n {
+				meta[i] = binary.LittleEndian.Uint16(data[pos:])
+			}
+		case typeFloat, typeDouble, typeBLOB, typeGeometry, typeJSON,
+			typeTimestamp2, typeDateTime2, typeTime2:
+			n = 1
+			if len(data) >= pos+n {
+				meta[i] = uint16(data[pos])
+			}
+		}
+		if len(data) < pos+n {
+			return nil, ErrShortEvent
+		}
+		pos += n
+	}
+	return meta, nil
+}
+
+// parseOptionalMeta parses the column names and signedness, if logged.
+func (e *TableMapEvent) parseOptionalMeta(data []byte) error {
+	for len(data) > 0 {
+		// type [1 byte], length [length encoded integer], value
+		t := data[0]
+		length, n := readLengthEncodedInteger(data[1:])
+		if n == 0 || len(data) < 1+n+int(length) {
+			return ErrShortEvent
+		}
+		value := data[1+n : 1+n+int(length)]
+		data = data[1+n+int(length):]
+
+		switch t {
+		case metadataSignedness:
+			// one bit for each numeric column, most significant bit first
+			e.Unsigned = make([]bool, len(e.ColumnTypes))
+			numeric := 0
+			for i, typ := range e.ColumnTypes {
+				if !isNumeric(typ) {
+					continue
+				}
+				if numeric/8 < len(value) {
+					e.Unsigned[i] = value[numeric/8]&(0x80>>uint(numeric%8)) != 0
+				}
+				numeric++
+			}
+		case metadataColumnName:
+			for len(value) > 0 {
+				name, n := readLengthEncodedInteger(value)
+				if n == 0 || len(value) < n+int(name) {
+					return ErrShortEvent
+				}
+				e.Columns = append(e.Columns, string(value[n:n+int(name)]))
+				value = value[n+int(name):]
+			}
+		}
+	}
+	return nil
+}
+
+func isNumeric(t byte) bool {
+	switch t {
+	case typeTiny, typeShort, typeInt24, typeLong, typeLongLong,
+		typeNewDecimal, typeFloat, typeDouble:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parseRows(t EventType, data []byte) (*RowsEvent, error) {
+	width := p.tableIDWidth(t)
+	if len(data) < width+2 {
+		return nil, ErrShortEvent
+	}
+
+	// table id [4 or 6 bytes], flags [2 bytes]
+	e := &RowsEvent{TableID: readUint(data[:width]), Flags: binary.LittleEndian.Uint16(data[width:])}
+	pos := width + 2
+
+	// extra data [length including itself, 2 bytes] of version 2 events
+	if t >= TypeWriteRowsV2 {
+		if len(data) < pos+2 {
+			return nil, ErrShortEvent
+		}
+		pos += int(binary.LittleEndian.Uint16(data[pos:]))
+	}
+
+	switch t {
+	case TypeWriteRowsV1, TypeWriteRowsV2:
+		e.Action = ActionInsert
+	case TypeUpdateRowsV1, TypeUpdateRowsV2:
+		e.Action = ActionUpdate
+	default:
+		e.Action = ActionDelete
+	}
+
+	table, ok := p.tables[e.TableID]
+	if !ok {
+		return nil, fmt.Errorf("binlog: rows event for unknown table id %d", e.TableID)
+	}
+	e.Table = table
+
+	// column count [length encoded integer], present columns [bitmap]
+	if len(data) < pos {
+		return nil, ErrShortEvent
+	}
+	count, n := readLengthEncodedInteger(data[pos:])
+	pos += n
+	if n == 0 || int(count) != len(table.ColumnTypes) {
+		return nil, fmt.Errorf("binlog: rows event of %d columns for table %s.%s of %d columns", count, table.Schema, table.Table, len(table.ColumnTypes))
+	}
+	bitmapLen := (int(count) + 7) / 8
+	if len(data) < pos+bitmapLen {
+		return nil, ErrShortEvent
+	}
+	present := data[pos : pos+bitmapLen]
+	pos += bitmapLen
+
+	// columns present after the update [bitmap]
+	presentAfter := present
+	if e.Action == ActionUpdate {
+		if len(data) < pos+bitmapLen {
+			return nil, ErrShortEvent
+		}
+		presentAfter = data[pos : pos+bitmapLen]
+		pos += bitmapLen
+	}
+
+	for pos < len(data) {
+		row, n, err := p.parseRow(table, present, data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+
+		if e.Action != ActionUpdate {
+			e.Rows = append(e.Rows, row)
+			continue
+		}
+		after, n, err := p.parseRow(table, presentAfter, data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+		e.Before = append(e.Before, row)
+		e.Rows = append(e.Rows, after)
+	}
+	return e, nil
+}
+
+// parseRow parses a row image, and returns it with its length.
+func (p *Parser) parseRow(table *TableMapEvent, present []byte, data []byte) ([]interface{}, int, error) {
+	// null bitmap [one bit for each present column]
+	presentCount := 0
+	for i := range table.ColumnTypes {
+		if isBitSet(present, i) {
+			presentCount++
+		}
+	}
+	nullBitmapLen := (presentCount + 7) / 8
+	if len(data) < nullBitmapLen {
+		return nil, 0, ErrShortEvent
+	}
+	nulls := data[:nullBitmapLen]
+	pos := nullBitmapLen
+
+	row := make([]interface{}, len(table.ColumnTypes))
+	nullIndex := 0
+	for i, t := range table.ColumnTypes {
+		if !isBitSet(present, i) {
+			continue
+		}
+		isNull := isBitSet(nulls, nullIndex)
+		nullIndex++
+		if isNull {
+			continue
+		}
+
+		unsigned := table.Unsigned != nil && table.Unsigned[i]
+		value, n, err := decodeValue(data[pos:], t, table.ColumnMeta[i], unsigned, p.loc)
+		if err != nil {
+			return nil, 0, fmt.Errorf("binlog: column %d of %s.%s: %v", i, table.Schema, table.Table, err)
+		}
+		row[i] = value
+		pos += n
+	}
+	return row, pos, nil
+}
+
+// decodeValue decodes a value of a row, and returns it with its length.
+func decodeValue(data []byte, t byte, meta uint16, unsigned bool, loc *time.Location) (interface{}, int, error) {
+	length := 0
+
+	// the real type of CHAR, ENUM and SET columns is in their metadata
+	if t == typeString {
+		realType, b := byte(meta>>8), int(meta&0xff)
+		if realType&0x30 != 0x30 {
+			// lengths above 255 use the spare bits of the real type
+			b |= int((realType&0x30)^0x30) << 4
+			realType |= 0x30
+		}
+		switch realType {
+		case typeEnum, typeSet:
+			t = realType
+		}
+		length = b
+	}
+
+	switch t {
+	case typeNULL:
+		return nil, 0, nil
+
+	case typeTiny:
+		if len(data) < 1 {
+			return nil, 0, ErrShortEvent
+		}
+		if unsigned {
+			return data[0], 1, nil
+		}
+		return int8(data[0]), 1, nil
+
+	case typeShort:
+		if len(data) < 2 {
+			return nil, 0, ErrShortEvent
+		}
+		v := binary.LittleEndian.Uint16(data)
+		if unsigned {
+			return v, 2, nil
+		}
+		return int16(v), 2, nil
+
+	case typeInt24:
+		if len(data) < 3 {
+			return nil, 0, ErrShortEvent
+		}
+		v := uint32(readUint(data[:3]))
+		if unsigned {
+			return v, 3, nil
+		}
+		if v&0x800000 != 0 {
+			v |= 0xff000000
+		}
+		return int32(v), 3, nil
+
+	case typeLong:
+		if len(data) < 4 {
+			return nil, 0, ErrShortEvent
+		}
+		v := binary.LittleEndian.Uint32(data)
+		if unsigned {
+			return v, 4, nil
+		}
+		return int32(v), 4, nil
+
+	case typeLongLong:
+		if len(data) < 8 {
+			return nil, 0, ErrShortEvent
+		}
+		v := binary.LittleEndian.Uint64(data)
+		if unsigned {
+			return v, 8, nil
+		}
+		return int64(v), 8, nil
+
+	case typeFloat:
+		if len(data) < 4 {
+			return nil, 0, ErrShortEvent
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(data)), 4, nil
+
+	case typeDouble:
+		if len(data) < 8 {
+			return nil, 0, ErrShortEvent
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), 8, nil
+
+	case typeNewDecimal:
+		return decodeDecimal(data, int(meta>>8), int(meta&0xff))
+
+	case typeYear:
+		if len(data) < 1 {
+			return nil, 0, ErrShortEvent
+		}
+		if data[0] == 0 {
+			return 0, 1, nil
+		}
+		return 1900 + int(data[0]), 1, nil
+
+	case typeDate, typeNewDate:
+		if len(data) < 3 {
+			return nil, 0, ErrShortEvent
+		}
+		v := readUint(data[:3])
+		return date(int(v>>9), int(v>>5&0x0f), int(v&0x1f), 0, 0, 0, 0, loc), 3, nil
+
+	case typeTimestamp:
+		if len(data) < 4 {
+			return nil, 0, ErrShortEvent
+		}
+		sec := binary.LittleEndian.Uint32(data)
+		if sec == 0 {
+			return time.Time{}, 4, nil
+		}
+		return time.Unix(int64(sec), 0).In(loc), 4, nil
+
+	case typeTimestamp2:
+		n := 4 + (int(meta)+1)/2
+		if len(data) < n {
+			return nil, 0, ErrShortEvent
+		}
+		sec := binary.BigEndian.Uint32(data)
+		usec := fractionalSeconds(data[4:n], int(meta))
+		if sec == 0 && usec == 0 {
+			return time.Time{}, n, nil
+		}
+		return time.Unix(int64(sec), int64(usec)*1000).In(loc), n, nil
+
+	case typeDateTime:
+		if len(data) < 8 {
+			return nil, 0, ErrShortEvent
+		}
+		// YYYYMMDDhhmmss as a number
+		v := binary.LittleEndian.Uint64(data)
+		d, t := int(v/1000000), int(v%1000000)
+		return date(d/10000, d%10000/100, d%100, t/10000, t%10000/100, t%100, 0, loc), 8, nil
+
+	case typeDateTime2:
+		n := 5 + (int(meta)+1)/2
+		if len(data) < n {
+			return nil, 0, ErrShortEvent
+		}
+		// sign [1 bit], year * 13 + month [17 bits], day [5 bits],
+		// hour [5 bits], minute [6 bits], second [6 bits]
+		v := readUintBE(data[:5]) - 0x8000000000
+		ymd, hms := v>>17, v%(1<<17)
+		ym := ymd >> 5
+		usec := fractionalSeconds(data[5:n], int(meta))
+		return date(int(ym/13), int(ym%13), int(ymd%(1<<5)), int(hms>>12), int(hms>>6%(1<<6)), int(hms%(1<<6)), usec, loc), n, nil
+
+	case typeTime:
+		if len(data) < 3 {
+			return nil, 0, ErrShortEvent
+		}
+		// hhmmss as a number
+		v := int64(readUint(data[:3]))
+		if v&0x800000 != 0 {
+			v -= 1 << 24
+		}
+		sign := time.Duration(1)
+		if v < 0 {
+			sign, v = -1, -v
+		}
+		d := time.Duration(v/10000)*time.Hour + time.Duration(v%10000/100)*time.Minute + time.Duration(v%100)*time.Second
+		return sign * d, 3, nil
+
+	case typeTime2:
+		return decodeTime2(data, int(meta))
+
+	case typeVarChar, typeVarString:
+		return decodeString(data, int(meta))
+
+	case typeString:
+		return decodeString(data, length)
+
+	case typeEnum:
+		switch length {
+		case 1:
+			if len(data) < 1 {
+				return nil, 0, ErrShortEvent
+			}
+			return uint16(data[0]), 1, nil
+		case 2:
+			if len(data) < 2 {
+				return nil, 0, ErrShortEvent
+			}
+			return binary.LittleEndian.Uint16(data), 2, nil
+		}
+		return nil, 0, fmt.Errorf("invalid ENUM length %d", length)
+
+	case typeSet:
+		if length > 8 || len(data) < length {
+			return nil, 0, ErrShortEvent
+		}
+		return readUint(data[:length]), length, nil
+
+	case typeBit:
+		// bits % 8 [1 byte], bytes [1 byte]
+		bits := int(meta>>8)*8 + int(meta&0xff)
+		n := (bits + 7) / 8
+		if len(data) < n {
+			return nil, 0, ErrShortEvent
+		}
+		return readUintBE(data[:n]), n, nil
+
+	case typeBLOB, typeGeometry, typeTinyBLOB, typeMediumBLOB, typeLongBLOB:
+		b, n, err := readBlob(data, int(meta))
+		if err != nil {
+			return nil, 0, err
+		}
+		return append([]byte{}, b...), n, nil
+
+	case typeJSON:
+		b, n, err := readBlob(data, int(meta))
+		if err != nil {
+			return nil, 0, err
+		}
+		v, err := decodeJSON(b)
+		return v, n, err
+	}
+	return nil, 0, fmt.Errorf("unsupported column type %d", t)
+}
+
+// date returns the time.Time of a DATE or DATETIME, or the zero time.Time for
+// zero dates.
+func date(year, month, day, hour, min, sec, usec int, loc *time.Location) time.Time {
+	if year == 0 && month == 0 && day == 0 {
+		return time.Time{}
+	}
+	return time.Date(year, time.Month(month), day, hour, min, sec, usec*1000, loc)
+}
+
+// fractionalSeconds returns the microseconds of the fractional seconds of
+// precision fsp, stored in (fsp + 1) / 2 bytes.
+func fractionalSeconds(data []byte, fsp int) int {
+	v := int(readUintBE(data))
+	switch (fsp + 1) / 2 {
+	case 1:
+		return v * 10000
+	case 2:
+		return v * 100
+	}
+	return v
+}
+
+// https://dev.mysql.com/doc/refman/5.7/en/storage-requirements.html#data-types-storage-reqs-date-time
+func decodeTime2(data []byte, fsp int) (interface{}, int, error) {
+	n := 3 + (fsp+1)/2
+	if len(data) < n {
+		return nil, 0, ErrShortEvent
+	}
+
+	// sign [1 bit], hour [10 bits], minute [6 bits], second [6 bits] and the
+	// fractional seconds, as an offset binary
+	var v int64
+	intPart := int64(readUintBE(data[:3])) - 0x800000
+	switch (fsp + 1) / 2 {
+	case 0:
+		v = intPart << 24
+	case 1:
+		frac := int64(data[3])
+		if intPart < 0 && frac > 0 {
+			intPart++
+			frac -= 0x100
+		}
+		v = intPart<<24 + frac*10000
+	case 2:
+		frac := int64(binary.BigEndian.Uint16(data[3:]))
+		if intPart < 0 && frac > 0 {
+			intPart++
+			frac -= 0x10000
+		}
+		v = intPart<<24 + frac*100
+	default:
+		v = int64(readUintBE(data[:6])) - 0x800000000000
+	}
+
+	sign := time.Duration(1)
+	if v < 0 {
+		sign, v = -1, -v
+	}
+	hms := v >> 24
+	d := time.Duration(hms>>12%(1<<10))*time.Hour +
+		time.Duration(hms>>6%(1<<6))*time.Minute +
+		time.Duration(hms%(1<<6))*time.Second +
+		time.Duration(v%(1<<24))*time.Microsecond
+	return sign * d, n, nil
+}
+
+// decodeString decodes a string prefixed with its length, on 1 byte if the
+// column is at most 255 bytes long, or 2 bytes otherwise.
+func decodeString(data []byte, maxLength int) (interface{}, int, error) {
+	size := 1
+	if maxLength > 255 {
+		size = 2
+	}
+	b, n, err := readBlob(data, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	return string(b), n, nil
+}
+
+// readBlob returns a value prefixed with its length on size bytes, and the
+// length of both.
+func readBlob(data []byte, size int) ([]byte, int, error) {
+	if size < 1 || size > 4 || len(data) < size {
+		return nil, 0, ErrShortEvent
+	}
+	length := int(readUint(data[:size]))
+	if len(data) < size+length {
+		return nil, 0, ErrShortEvent
+	}
+	return data[size : size+length], size + length, nil
+}
+
+// decimal digits stored in n bytes
+var decimalDigitBytes = [...]int{0, 1, 1, 2, 2, 3, 3, 4, 4, 4}
+
+// decodeDecimal decodes a DECIMAL value as a string, to not lose precision.
+// The digits are stored in big endian groups of 9 digits in 4 bytes, with the
+// leftover digits of the integral part first and of the fractional part last
+// using as many bytes as needed. Negative values have all bits inverted, and
+// the highest bit is inverted for all values.
+func decodeDecimal(data []byte, precision, scale int) (interface{}, int, error) {
+	integral := precision - scale
+	size := integral/9*4 + decimalDigitBytes[integral%9] + scale/9*4 + decimalDigitBytes[scale%9]
+	if len(data) < size {
+		return nil, 0, ErrShortEvent
+	}
+	buf := append([]byte(nil), data[:size]...)
+	negative := buf[0]&0x80 == 0
+	buf[0] ^= 0x80
+	if negative {
+		for i := range buf {
+			buf[i] ^= 0xff
+		}
+	}
+
+	var digits bytes.Buffer
+	pos := 0
+	group := func(n int) {
+		width := 4
+		if n < 9 {
+			width = decimalDigitBytes[n]
+		}
+		v := strconv.FormatUint(readUintBE(buf[pos:pos+width]), 10)
+		pos += width
+		digits.WriteString(strings.Repeat("0", n-len(v)))
+		digits.WriteString(v)
+	}
+
+	if integral%9 > 0 {
+		group(integral % 9)
+	}
+	for i := 0; i < integral/9; i++ {
+		group(9)
+	}
+	intPart := strings.TrimLeft(digits.String(), "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	digits.Reset()
+	for i := 0; i < scale/9; i++ {
+		group(9)
+	}
+	if scale%9 > 0 {
+		group(scale % 9)
+	}
+
+	s := intPart
+	if scale > 0 {
+		s += "." + digits.String()
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s, size, nil
+}
+
+func isBitSet(bitmap []byte, i int) bool {
+	return bitmap[i/8]&(1<<uint(i%8)) != 0
+}
+
+// readUint reads a little endian unsigned integer of up to 8 bytes.
+func readUint(b []byte) uint64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+// readUintBE reads a big endian unsigned integer of up to 8 bytes.
+func readUintBE(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+// readLengthEncodedInteger returns the integer and its length, or a length of
+// 0 if it is truncated.
+func readLengthEncodedInteger(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	size := 1
+	switch b[0] {
+	case 0xfc:
+		size = 3
+	case 0xfd:
+		size = 4
+	case 0xfe:
+		size = 9
+	}
+	if len(b) < size {
+		return 0, 0
+	}
+	if size == 1 {
+		return uint64(b[0]), 1
+	}
+	return readUint(b[1:size]), size
+}
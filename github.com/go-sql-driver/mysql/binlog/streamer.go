@@ -0,0 +1,215 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package binlog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Position is a position in the binary log.
+type Position struct {
+	File string
+	Pos  uint32
+}
+
+// String returns the position as file:pos.
+func (p Position) String() string {
+	return p.File + ":" + strconv.FormatUint(uint64(p.Pos), 10)
+}
+
+// Config is the configuration of a Streamer.
+type Config struct {
+	// MySQL is the configuration of the connection. Its user needs the
+	// REPLICATION SLAVE privilege, and DATETIME and TIMESTAMP values are
+	// decoded in its Loc.
+	MySQL *mysql.Config
+
+	// ServerID identifies the replica, it must differ from the ids of the
+	// server and of its other replicas.
+	ServerID uint32
+
+	// Host and Port are reported to the server, for SHOW SLAVE HOSTS.
+	Host string
+	Port uint16
+
+	// NonBlocking makes Next return io.EOF at the end of the binary log,
+	// instead of waiting for new events.
+	NonBlocking bool
+}
+
+// Streamer reads the events of the binary log of a server as a replica.
+//
+// Position and GTIDSet return where to resume the stream after the last
+// committed transaction, so that saving them with the changes of the
+// transaction makes the capture exactly-once.
+type Streamer struct {
+	cfg    Config
+	conn   *mysql.ReplicationConn
+	parser *Parser
+
+	// next is the position of the next event, and pos the one after the
+	// last committed transaction
+	next Position
+	pos  Position
+
+	// gtids are the committed transactions when started with StartGTID,
+	// and gtid the one of the current transaction
+	gtids *GTIDSet
+	gtid  *GTIDEvent
+}
+
+// NewStreamer returns a Streamer, which connects when started.
+func NewStreamer(cfg Config) *Streamer {
+	return &Streamer{cfg: cfg}
+}
+
+// Start connects to the server and starts streaming from pos, which must be
+// the start of a transaction like the one of a saved Position, or the one of
+// SHOW MASTER STATUS.
+func (s *Streamer) Start(pos Position) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	if pos.Pos < 4 {
+		// skip the magic number of binlog files
+		pos.Pos = 4
+	}
+	s.next, s.pos = pos, pos
+	return s.conn.DumpBinlog(s.cfg.ServerID, pos.File, pos.Pos, s.cfg.NonBlocking)
+}
+
+// StartGTID connects to the server and starts streaming the transactions
+// which are not in set, eg. a saved GTIDSet or the gtid_executed of a
+// snapshot, or all of them if set is nil. It requires gtid_mode=ON.
+func (s *Streamer) StartGTID(set *GTIDSet) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	if set == nil {
+		set = &GTIDSet{}
+	}
+	s.gtids = set.Clone()
+	return s.conn.DumpBinlogGTID(s.cfg.ServerID, s.gtids.Encode(), s.cfg.NonBlocking)
+}
+
+func (s *Streamer) connect() error {
+	if s.conn != nil {
+		return errors.New("binlog: streamer already started")
+	}
+	if s.cfg.MySQL == nil {
+		return errors.New("binlog: missing MySQL config")
+	}
+
+	conn, err := mysql.NewReplicationConn(s.cfg.MySQL)
+	if err != nil {
+		return err
+	}
+
+	// servers with checksums refuse replicas which do not announce they
+	// support them
+	checksum := false
+	if alg, err := conn.SystemVar("global.binlog_checksum"); err == nil && !strings.EqualFold(alg, "NONE") {
+		if err = conn.Exec("SET @master_binlog_checksum = @@global.binlog_checksum"); err != nil {
+			conn.Close()
+			return err
+		}
+		checksum = true
+	}
+
+	if err = conn.Register(s.cfg.ServerID, s.cfg.Host, s.cfg.Port, s.cfg.MySQL.User, s.cfg.MySQL.Passwd); err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.conn = conn
+	s.parser = NewParser(s.cfg.MySQL.Loc)
+	s.parser.checksum = checksum
+	return nil
+}
+
+// Next returns the next event, waiting for it unless NonBlocking is set.
+// Close may be called concurrently to interrupt it.
+func (s *Streamer) Next() (*Event, error) {
+	if s.conn == nil {
+		return nil, errors.New("binlog: streamer not started")
+	}
+	data, err := s.conn.ReadEvent()
+	if err != nil {
+		return nil, err
+	}
+	ev, err := s.parser.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	s.track(ev)
+	return ev, nil
+}
+
+// track advances the positions past ev.
+func (s *Streamer) track(ev *Event) {
+	switch e := ev.Data.(type) {
+	case *RotateEvent:
+		// also sent at the start of the stream, which is at a transaction
+		// boundary like the start of a new file
+		s.next = Position{File: e.NextFile, Pos: uint32(e.Position)}
+		s.pos = s.next
+		return
+	case *GTIDEvent:
+		s.gtid = e
+	}
+
+	if ev.Header.LogPos > 0 {
+		s.next.Pos = ev.Header.LogPos
+	}
+
+	switch e := ev.Data.(type) {
+	case *XIDEvent:
+		s.commit()
+	case *QueryEvent:
+		// BEGIN starts a transaction, while other statements like DDL and
+		// the COMMIT of non transactional tables end one
+		if !strings.EqualFold(strings.TrimSpace(e.Query), "BEGIN") {
+			s.commit()
+		}
+	}
+}
+
+func (s *Streamer) commit() {
+	s.pos = s.next
+	if s.gtids != nil && s.gtid != nil {
+		s.gtids.Add(s.gtid.SID, s.gtid.GNO)
+	}
+	s.gtid = nil
+}
+
+// Position returns the position after the last committed transaction.
+func (s *Streamer) Position() Position {
+	return s.pos
+}
+
+// GTIDSet returns the committed transactions, including the ones of the set
+// the stream was started from, or nil if it was not started with StartGTID.
+func (s *Streamer) GTIDSet() *GTIDSet {
+	if s.gtids == nil {
+		return nil
+	}
+	return s.gtids.Clone()
+}
+
+// Close closes the connection.
+func (s *Streamer) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
@@ -364,6 +364,10 @@ func (mc *mysqlConn) query(query string, args []driver.Value) (*textRows, error)
 		errLog.Print(ErrInvalidConn)
 		return nil, driver.ErrBadConn
 	}
+	if mc.cfg.FetchSize > 0 {
+		// server-side cursors require prepared statements
+		return nil, driver.ErrSkip
+	}
 	if len(args) != 0 {
 		if !mc.cfg.InterpolateParams {
 			return nil, driver.ErrSkip
@@ -10,6 +10,7 @@ package mysql
 
 const (
 	defaultAuthPlugin       = "mysql_native_password"
+	defaultClientName       = "Go-MySQL-Driver"
 	defaultMaxAllowedPacket = 4 << 20 // 4 MiB
 	minProtocolVersion      = 10
 	maxPacketSize           = 1<<24 - 1
@@ -87,6 +88,20 @@ const (
 	comStmtReset
 	comSetOption
 	comStmtFetch
+	comDaemon
+	comBinlogDumpGTID
+)
+
+// https://dev.mysql.com/doc/internals/en/com-binlog-dump-gtid.html
+const (
+	binlogDumpNonBlock uint16 = 0x01
+	binlogThroughGTID  uint16 = 0x04
+)
+
+// https://dev.mysql.com/doc/internals/en/com-stmt-execute.html
+const (
+	cursorTypeNoCursor byte = 0x00
+	cursorTypeReadOnly byte = 0x01
 )
 
 // https://dev.mysql.com/doc/internals/en/com-query-response.html#packet-Protocol::ColumnType
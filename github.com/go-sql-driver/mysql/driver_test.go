@@ -2917,3 +2917,69 @@ func TestValuerWithValueReceiverGivenNilValue(t *testing.T) {
 		// This test will panic on the INSERT if ConvertValue() does not check for typed nil before calling Value()
 	})
 }
+
+func TestServerSideCursor(t *testing.T) {
+	runTests(t, dsn+"&fetchSize=3", func(dbt *DBTest) {
+		dbt.mustExec("CREATE TABLE test (value INT)")
+		for i := 0; i < 10; i++ {
+			dbt.mustExec("INSERT INTO test VALUES (?)", i)
+		}
+
+		// rows are fetched in batches, also for queries without arguments
+		rows := dbt.mustQuery("SELECT value FROM test ORDER BY value")
+		var n int
+		for ; rows.Next(); n++ {
+			var value int
+			if err := rows.Scan(&value); err != nil {
+				dbt.Fatal(err)
+			}
+			if value != n {
+				dbt.Errorf("expected %d, got %d", n, value)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			dbt.Fatal(err)
+		}
+		if n != 10 {
+			dbt.Errorf("expected 10 rows, got %d", n)
+		}
+
+		// closing the rows early closes the cursor of the statement
+		stmt, err := dbt.db.Prepare("SELECT value FROM test WHERE value >= ? ORDER BY value")
+		if err != nil {
+			dbt.Fatal(err)
+		}
+		defer stmt.Close()
+		for _, from := range []int{2, 5} {
+			var value int
+			if err := stmt.QueryRow(from).Scan(&value); err != nil {
+				dbt.Fatal(err)
+			}
+			if value != from {
+				dbt.Errorf("expected %d, got %d", from, value)
+			}
+		}
+	})
+}
+
+func TestConnectionAttributes(t *testing.T) {
+	runTests(t, dsn+"&connectionAttributes=program_name:go_test,team:drivers", func(dbt *DBTest) {
+		var enabled string
+		if err := dbt.db.QueryRow("SELECT @@performance_schema").Scan(&enabled); err != nil || enabled != "1" {
+			dbt.Skip("performance_schema is disabled")
+		}
+
+		rows := dbt.mustQuery("SELECT ATTR_NAME, ATTR_VALUE FROM performance_schema.session_account_connect_attrs WHERE PROCESSLIST_ID = CONNECTION_ID()")
+		attrs := make(map[string]string)
+		for rows.Next() {
+			var name, value string
+			if err := rows.Scan(&name, &value); err != nil {
+				dbt.Fatal(err)
+			}
+			attrs[name] = value
+		}
+		if attrs["program_name"] != "go_test" || attrs["team"] != "drivers" || attrs["_client_name"] != defaultClientName {
+			dbt.Errorf("unexpected connection attributes %v", attrs)
+		}
+	})
+}
@@ -33,22 +33,24 @@ var (
 // If a new Config is created instead of being parsed from a DSN string,
 // the NewConfig function should be used, which sets default values.
 type Config struct {
-	User             string            // Username
-	Passwd           string            // Password (requires User)
-	Net              string            // Network type
-	Addr             string            // Network address (requires Net)
-	DBName           string            // Database name
-	Params           map[string]string // Connection parameters
-	Collation        string            // Connection collation
-	Loc              *time.Location    // Location for time.Time values
-	MaxAllowedPacket int               // Max packet size allowed
-	ServerPubKey     string            // Server public key name
-	pubKey           *rsa.PublicKey    // Server public key
-	TLSConfig        string            // TLS configuration name
-	tls              *tls.Config       // TLS configuration
-	Timeout          time.Duration     // Dial timeout
-	ReadTimeout      time.Duration     // I/O read timeout
-	WriteTimeout     time.Duration     // I/O write timeout
+	User                 string            // Username
+	Passwd               string            // Password (requires User)
+	Net                  string            // Network type
+	Addr                 string            // Network address (requires Net)
+	DBName               string            // Database name
+	Params               map[string]string // Connection parameters
+	Collation            string            // Connection collation
+	Loc                  *time.Location    // Location for time.Time values
+	MaxAllowedPacket     int               // Max packet size allowed
+	ServerPubKey         string            // Server public key name
+	pubKey               *rsa.PublicKey    // Server public key
+	TLSConfig            string            // TLS configuration name
+	tls                  *tls.Config       // TLS configuration
+	Timeout              time.Duration     // Dial timeout
+	ReadTimeout          time.Duration     // I/O read timeout
+	WriteTimeout         time.Duration     // I/O write timeout
+	FetchSize            int               // Rows fetched at once by server-side cursors
+	ConnectionAttributes map[string]string // Connection attributes
 
 	AllowAllFiles           bool // Allow all files to be used with LOAD DATA LOCAL INFILE
 	AllowCleartextPasswords bool // Allows the cleartext client side plugin
@@ -201,6 +203,41 @@ func (cfg *Config) FormatDSN() string {
 		}
 	}
 
+	if len(cfg.ConnectionAttributes) > 0 {
+		if hasParam {
+			buf.WriteString("&connectionAttributes=")
+		} else {
+			hasParam = true
+			buf.WriteString("?connectionAttributes=")
+		}
+		var keys []string
+		for key := range cfg.ConnectionAttributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var attrs bytes.Buffer
+		for i, key := range keys {
+			if i > 0 {
+				attrs.WriteByte(',')
+			}
+			// escape the separators in names and values
+			attrs.WriteString(url.QueryEscape(key))
+			attrs.WriteByte(':')
+			attrs.WriteString(url.QueryEscape(cfg.ConnectionAttributes[key]))
+		}
+		buf.WriteString(url.QueryEscape(attrs.String()))
+	}
+
+	if cfg.FetchSize > 0 {
+		if hasParam {
+			buf.WriteString("&fetchSize=")
+		} else {
+			hasParam = true
+			buf.WriteString("?fetchSize=")
+		}
+		buf.WriteString(strconv.Itoa(cfg.FetchSize))
+	}
+
 	if cfg.InterpolateParams {
 		if hasParam {
 			buf.WriteString("&interpolateParams=true")
@@ -476,6 +513,37 @@ func parseDSNParams(cfg *Config, params string) (err error) {
 		case "compress":
 			return errors.New("compression not implemented yet")
 
+		// Connection attributes
+		case "connectionAttributes":
+			if value, err = url.QueryUnescape(value); err != nil {
+				return
+			}
+			cfg.ConnectionAttributes = make(map[string]string)
+			for _, attr := range strings.Split(value, ",") {
+				kv := strings.SplitN(attr, ":", 2)
+				if len(kv) != 2 || len(kv[0]) == 0 {
+					return errors.New("invalid connection attribute: " + attr)
+				}
+				var name, attrValue string
+				if name, err = url.QueryUnescape(kv[0]); err != nil {
+					return
+				}
+				if attrValue, err = url.QueryUnescape(kv[1]); err != nil {
+					return
+				}
+				cfg.ConnectionAttributes[name] = attrValue
+			}
+
+		// Server-side cursor fetch size
+		case "fetchSize":
+			cfg.FetchSize, err = strconv.Atoi(value)
+			if err != nil {
+				return
+			}
+			if cfg.FetchSize < 0 {
+				return errors.New("invalid fetch size: " + value)
+			}
+
 		// Enable client side placeholder substitution
 		case "interpolateParams":
 			var isBool bool
@@ -44,6 +44,9 @@ var testDSNs = []struct {
 }, {
 	"user:password@/dbname?allowNativePasswords=false&maxAllowedPacket=0",
 	&Config{User: "user", Passwd: "password", Net: "tcp", Addr: "127.0.0.1:3306", DBName: "dbname", Collation: "utf8_general_ci", Loc: time.UTC, MaxAllowedPacket: 0, AllowNativePasswords: false},
+}, {
+	"user:password@/dbname?connectionAttributes=program_name:exporter,team:billing&fetchSize=1000",
+	&Config{User: "user", Passwd: "password", Net: "tcp", Addr: "127.0.0.1:3306", DBName: "dbname", Collation: "utf8_general_ci", Loc: time.UTC, MaxAllowedPacket: defaultMaxAllowedPacket, AllowNativePasswords: true, ConnectionAttributes: map[string]string{"program_name": "exporter", "team": "billing"}, FetchSize: 1000},
 }, {
 	"user:p@ss(word)@tcp([de:ad:be:ef::ca:fe]:80)/dbname?loc=Local",
 	&Config{User: "user", Passwd: "p@ss(word)", Net: "tcp", Addr: "[de:ad:be:ef::ca:fe]:80", DBName: "dbname", Collation: "utf8_general_ci", Loc: time.Local, MaxAllowedPacket: defaultMaxAllowedPacket, AllowNativePasswords: true},
@@ -99,6 +102,8 @@ func TestDSNParserInvalid(t *testing.T) {
 		"net(addr)//",                 // unescaped
 		"User:pass@tcp(1.2.3.4:3306)", // no trailing slash
 		"net()/",                      // unknown default addr
+		"/dbname?connectionAttributes=program_name", // attribute without value
+		"/dbname?fetchSize=-1",                      // negative fetch size
 		//"/dbname?arg=/some/unescaped/path",
 	}
 
@@ -179,6 +184,28 @@ func TestDSNServerPubKeyQueryEscape(t *testing.T) {
 	}
 }
 
+func TestDSNConnectionAttributesQueryEscape(t *testing.T) {
+	attrs := map[string]string{"tags": "a,b:c", "a:b": "50% done"}
+	cfg := NewConfig()
+	cfg.ConnectionAttributes = attrs
+
+	parsed, err := ParseDSN(cfg.FormatDSN())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(parsed.ConnectionAttributes, attrs) {
+		t.Errorf("connection attributes do not round-trip: got %v, want %v", parsed.ConnectionAttributes, attrs)
+	}
+
+	parsed, err = ParseDSN("/dbname?connectionAttributes=tags:a%252Cb")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if parsed.ConnectionAttributes["tags"] != "a,b" {
+		t.Errorf("unexpected connection attribute value: %q", parsed.ConnectionAttributes["tags"])
+	}
+}
+
 func TestDSNWithCustomTLS(t *testing.T) {
 	baseDSN := "User:password@tcp(localhost:5555)/dbname?tls="
 	tlsCfg := tls.Config{}
@@ -17,6 +17,10 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -205,10 +209,15 @@ func (mc *mysqlConn) readHandshakePacket() (data []byte, plugin string, err erro
 	if len(data) > pos {
 		// character set [1 byte]
 		// status flags [2 bytes]
+		pos += 1 + 2
+
 		// capability flags (upper 2 bytes) [2 bytes]
+		mc.flags |= clientFlag(binary.LittleEndian.Uint16(data[pos:pos+2])) << 16
+		pos += 2
+
 		// length of auth-plugin-data [1 byte]
 		// reserved (all [00]) [10 bytes]
-		pos += 1 + 2 + 2 + 1 + 10
+		pos += 1 + 10
 
 		// second part of the password cipher [mininum 13 bytes],
 		// where len=MAX(13, length of auth-plugin-data - 8)
@@ -289,8 +298,16 @@ func (mc *mysqlConn) writeHandshakeResponsePacket(authResp []byte, plugin string
 		pktLen += n + 1
 	}
 
+	// To send connection attributes
+	var connAttrs []byte
+	if mc.flags&clientConnectAttrs != 0 {
+		clientFlags |= clientConnectAttrs
+		connAttrs = appendConnectionAttributes(nil, mc.cfg.ConnectionAttributes)
+		pktLen += len(connAttrs)
+	}
+
 	// Calculate packet length and get buffer with that size
-	data, err := mc.buf.takeSmallBuffer(pktLen + 4)
+	data, err := mc.buf.takeBuffer(pktLen + 4)
 	if err != nil {
 		// cannot take the buffer. Something must be wrong with the connection
 		errLog.Print(err)
@@ -364,10 +381,46 @@ func (mc *mysqlConn) writeHandshakeResponsePacket(authResp []byte, plugin string
 	data[pos] = 0x00
 	pos++
 
+	// Connection attributes [length encoded key-value pairs]
+	pos += copy(data[pos:], connAttrs)
+
 	// Send Auth packet
 	return mc.writePacket(data[:pos])
 }
 
+// appendConnectionAttributes appends the attributes of the driver and the
+// given ones, which take precedence, in the format of the handshake response:
+// the length of the pairs [length encoded integer], and each key and value
+// [length encoded string].
+func appendConnectionAttributes(b []byte, attrs map[string]string) []byte {
+	all := map[string]string{
+		"_client_name": defaultClientName,
+		"_os":          runtime.GOOS,
+		"_platform":    runtime.GOARCH,
+		"_pid":         strconv.Itoa(os.Getpid()),
+	}
+	for key, value := range attrs {
+		all[key] = value
+	}
+
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []byte
+	for _, key := range keys {
+		pairs = appendLengthEncodedInteger(pairs, uint64(len(key)))
+		pairs = append(pairs, key...)
+		pairs = appendLengthEncodedInteger(pairs, uint64(len(all[key])))
+		pairs = append(pairs, all[key]...)
+	}
+
+	b = appendLengthEncodedInteger(b, uint64(len(pairs)))
+	return append(b, pairs...)
+}
+
 // http://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::AuthSwitchResponse
 func (mc *mysqlConn) writeAuthSwitchPacket(authData []byte) error {
 	pktLen := 4 + len(authData)
@@ -613,6 +666,10 @@ func (mc *mysqlConn) readColumns(count int) ([]mysqlField, error) {
 
 		// EOF Packet
 		if data[0] == iEOF && (len(data) == 5 || len(data) == 1) {
+			if len(data) == 5 {
+				// tells if the rows are fetched from a server-side cursor
+				mc.status = readStatus(data[3:])
+			}
 			if i == count {
 				return columns, nil
 			}
@@ -875,7 +932,7 @@ func (stmt *mysqlStmt) writeCommandLongData(paramID int, arg []byte) error {
 
 // Execute Prepared Statement
 // http://dev.mysql.com/doc/internals/en/com-stmt-execute.html
-func (stmt *mysqlStmt) writeExecutePacket(args []driver.Value) error {
+func (stmt *mysqlStmt) writeExecutePacket(args []driver.Value, cursorType byte) error {
 	if len(args) != stmt.paramCount {
 		return fmt.Errorf(
 			"argument count mismatch (got: %d; has: %d)",
@@ -920,8 +977,8 @@ func (stmt *mysqlStmt) writeExecutePacket(args []driver.Value) error {
 	data[7] = byte(stmt.id >> 16)
 	data[8] = byte(stmt.id >> 24)
 
-	// flags (0: CURSOR_TYPE_NO_CURSOR) [1 byte]
-	data[9] = 0x00
+	// flags (cursor type) [1 byte]
+	data[9] = cursorType
 
 	// iteration_count (uint32(1)) [4 bytes]
 	data[10] = 0x01
@@ -1096,6 +1153,39 @@ func (stmt *mysqlStmt) writeExecutePacket(args []driver.Value) error {
 	return mc.writePacket(data)
 }
 
+// http://dev.mysql.com/doc/internals/en/com-stmt-fetch.html
+func (stmt *mysqlStmt) writeFetchPacket(numRows uint32) error {
+	mc := stmt.mc
+
+	// Reset Packet Sequence
+	mc.sequence = 0
+
+	data, err := mc.buf.takeSmallBuffer(4 + 1 + 4 + 4)
+	if err != nil {
+		// cannot take the buffer. Something must be wrong with the connection
+		errLog.Print(err)
+		return errBadConnNoWrite
+	}
+
+	// command [1 byte]
+	data[4] = comStmtFetch
+
+	// statement_id [4 bytes]
+	data[5] = byte(stmt.id)
+	data[6] = byte(stmt.id >> 8)
+	data[7] = byte(stmt.id >> 16)
+	data[8] = byte(stmt.id >> 24)
+
+	// num_rows [4 bytes]
+	data[9] = byte(numRows)
+	data[10] = byte(numRows >> 8)
+	data[11] = byte(numRows >> 16)
+	data[12] = byte(numRows >> 24)
+
+	// Send CMD packet
+	return mc.writePacket(data)
+}
+
 func (mc *mysqlConn) discardResults() error {
 	for mc.status&statusMoreResultsExists != 0 {
 		resLen, err := mc.readResultSetHeaderPacket()
@@ -1118,6 +1208,14 @@ func (mc *mysqlConn) discardResults() error {
 
 // http://dev.mysql.com/doc/internals/en/binary-protocol-resultset-row.html
 func (rows *binaryRows) readRow(dest []driver.Value) error {
+	// Fetch the next rows of a server-side cursor
+	if rows.cursor != nil && !rows.fetching {
+		if err := rows.cursor.writeFetchPacket(uint32(rows.mc.cfg.FetchSize)); err != nil {
+			return err
+		}
+		rows.fetching = true
+	}
+
 	data, err := rows.mc.readPacket()
 	if err != nil {
 		return err
@@ -1128,6 +1226,14 @@ func (rows *binaryRows) readRow(dest []driver.Value) error {
 		// EOF Packet
 		if data[0] == iEOF && len(data) == 5 {
 			rows.mc.status = readStatus(data[3:])
+			if rows.cursor != nil {
+				rows.fetching = false
+				if rows.mc.status&statusLastRowSent == 0 {
+					return rows.readRow(dest)
+				}
+				// the server closed the cursor
+				rows.cursor = nil
+			}
 			rows.rs.done = true
 			if !rows.HasNextResultSet() {
 				rows.mc = nil
@@ -1294,3 +1400,141 @@ func (rows *binaryRows) readRow(dest []driver.Value) error {
 
 	return nil
 }
+
+/******************************************************************************
+*                           Replication Packets                               *
+******************************************************************************/
+
+// https://dev.mysql.com/doc/internals/en/com-register-slave.html
+func (mc *mysqlConn) writeRegisterSlavePacket(serverID uint32, host, user, password string, port uint16) error {
+	// Reset Packet Sequence
+	mc.sequence = 0
+
+	if len(host) > 255 || len(user) > 255 || len(password) > 255 {
+		return errors.New("replica host, user and password must be at most 255 bytes long")
+	}
+
+	pktLen := 1 + 4 + 1 + len(host) + 1 + len(user) + 1 + len(password) + 2 + 4 + 4
+	data, err := mc.buf.takeBuffer(pktLen + 4)
+	if err != nil {
+		// cannot take the buffer. Something must be wrong with the connection
+		errLog.Print(err)
+		return errBadConnNoWrite
+	}
+
+	// command [1 byte]
+	data[4] = comRegisterSlave
+
+	// server id [32 bit]
+	binary.LittleEndian.PutUint32(data[5:], serverID)
+	pos := 9
+
+	// host, user, password [length prefixed strings]
+	for _, s := range []string{host, user, password} {
+		data[pos] = byte(len(s))
+		pos += 1 + copy(data[pos+1:], s)
+	}
+
+	// port [16 bit]
+	binary.LittleEndian.PutUint16(data[pos:], port)
+
+	// replication rank and master id [32 bit each], both unused
+	binary.LittleEndian.PutUint32(data[pos+2:], 0)
+	binary.LittleEndian.PutUint32(data[pos+6:], 0)
+
+	// Send CMD packet
+	return mc.writePacket(data)
+}
+
+// https://dev.mysql.com/doc/internals/en/com-binlog-dump.html
+func (mc *mysqlConn) writeBinlogDumpPacket(serverID uint32, file string, pos uint32, flags uint16) error {
+	// Reset Packet Sequence
+	mc.sequence = 0
+
+	pktLen := 1 + 4 + 2 + 4 + len(file)
+	data, err := mc.buf.takeBuffer(pktLen + 4)
+	if err != nil {
+		// cannot take the buffer. Something must be wrong with the connection
+		errLog.Print(err)
+		return errBadConnNoWrite
+	}
+
+	// command [1 byte]
+	data[4] = comBinlogDump
+
+	// binlog position [32 bit]
+	binary.LittleEndian.PutUint32(data[5:], pos)
+
+	// flags [16 bit]
+	binary.LittleEndian.PutUint16(data[9:], flags)
+
+	// server id [32 bit]
+	binary.LittleEndian.PutUint32(data[11:], serverID)
+
+	// binlog file name [EOF]
+	copy(data[15:], file)
+
+	// Send CMD packet
+	return mc.writePacket(data)
+}
+
+// https://dev.mysql.com/doc/internals/en/com-binlog-dump-gtid.html
+func (mc *mysqlConn) writeBinlogDumpGTIDPacket(serverID uint32, file string, pos uint64, gtidSet []byte, flags uint16) error {
+	// Reset Packet Sequence
+	mc.sequence = 0
+
+	pktLen := 1 + 2 + 4 + 4 + len(file) + 8 + 4 + len(gtidSet)
+	data, err := mc.buf.takeBuffer(pktLen + 4)
+	if err != nil {
+		// cannot take the buffer. Something must be wrong with the connection
+		errLog.Print(err)
+		return errBadConnNoWrite
+	}
+
+	// command [1 byte]
+	data[4] = comBinlogDumpGTID
+
+	// flags [16 bit], the GTID set is always sent
+	binary.LittleEndian.PutUint16(data[5:], flags|binlogThroughGTID)
+
+	// server id [32 bit]
+	binary.LittleEndian.PutUint32(data[7:], serverID)
+
+	// binlog file name [length prefixed string]
+	binary.LittleEndian.PutUint32(data[11:], uint32(len(file)))
+	n := 15 + copy(data[15:], file)
+
+	// binlog position [64 bit]
+	binary.LittleEndian.PutUint64(data[n:], pos)
+
+	// GTID set [length prefixed string]
+	binary.LittleEndian.PutUint32(data[n+8:], uint32(len(gtidSet)))
+	copy(data[n+12:], gtidSet)
+
+	// Send CMD packet
+	return mc.writePacket(data)
+}
+
+// Binlog Network Stream
+// https://dev.mysql.com/doc/internals/en/binlog-network-stream.html
+func (mc *mysqlConn) readBinlogEventPacket() ([]byte, error) {
+	data, err := mc.readPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data[0] {
+	case iOK:
+		// the packet is only valid until the next read
+		event := make([]byte, len(data)-1)
+		copy(event, data[1:])
+		return event, nil
+
+	case iEOF:
+		// end of the binlog in non-blocking mode
+		if len(data) < 9 {
+			return nil, io.EOF
+		}
+	}
+	return nil, mc.handleErrorPacket(data)
+}
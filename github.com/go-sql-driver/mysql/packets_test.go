@@ -10,7 +10,9 @@ package mysql
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"errors"
+	"io"
 	"net"
 	"testing"
 	"time"
@@ -334,3 +336,136 @@ func TestRegression801(t *testing.T) {
 		t.Errorf("expected authData '%v', got '%v'", expectedAuthData, authData)
 	}
 }
+
+func TestHandshakeConnectionAttributes(t *testing.T) {
+	conn, mc := newRWMockConn(1)
+	mc.cfg.User = "root"
+	mc.cfg.ConnectionAttributes = map[string]string{"program_name": "exporter"}
+	mc.flags = clientProtocol41 | clientConnectAttrs
+
+	if err := mc.writeHandshakeResponsePacket(nil, defaultAuthPlugin); err != nil {
+		t.Fatal(err)
+	}
+	if flags := clientFlag(conn.written[4]) | clientFlag(conn.written[6])<<16; flags&clientConnectAttrs == 0 {
+		t.Errorf("expected the connection attributes flag to be set")
+	}
+
+	attrs := appendConnectionAttributes(nil, mc.cfg.ConnectionAttributes)
+	if !bytes.HasSuffix(conn.written, attrs) {
+		t.Fatalf("expected the connection attributes at the end of %v", conn.written)
+	}
+	for _, pair := range []string{"\x0c_client_name\x0fGo-MySQL-Driver", "\x0cprogram_name\x08exporter"} {
+		if !bytes.Contains(attrs, []byte(pair)) {
+			t.Errorf("expected %q in the connection attributes %q", pair, attrs)
+		}
+	}
+	if n, _, _ := readLengthEncodedInteger(attrs); int(n) != len(attrs)-1 {
+		t.Errorf("expected the length %d, got %d", len(attrs)-1, n)
+	}
+
+	// without server support
+	conn.written = nil
+	mc.flags = clientProtocol41
+	if err := mc.writeHandshakeResponsePacket(nil, defaultAuthPlugin); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(conn.written, []byte("program_name")) {
+		t.Errorf("unexpected connection attributes in %v", conn.written)
+	}
+}
+
+func TestReadHandshakeUpperFlags(t *testing.T) {
+	conn, mc := newRWMockConn(0)
+	conn.data = []byte{72, 0, 0, 0, 10, 53, 46, 53, 46, 56, 0, 165, 0, 0, 0,
+		60, 70, 63, 58, 68, 104, 34, 97, 0, 223, 247, 33, 2, 0, 15, 128, 21, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 98, 120, 114, 47, 85, 75, 109, 99, 51, 77,
+		50, 64, 0, 109, 121, 115, 113, 108, 95, 110, 97, 116, 105, 118, 101, 95,
+		112, 97, 115, 115, 119, 111, 114, 100}
+	conn.maxReads = 1
+
+	if _, _, err := mc.readHandshakePacket(); err != nil {
+		t.Fatal(err)
+	}
+	if mc.flags&clientProtocol41 == 0 || mc.flags&clientPluginAuth == 0 {
+		t.Errorf("expected the lower and upper capability flags, got %x", mc.flags)
+	}
+	if mc.flags&clientConnectAttrs != 0 {
+		t.Errorf("unexpected connection attributes flag in %x", mc.flags)
+	}
+}
+
+// cursorRowsMock returns binary rows of a server-side cursor over a BIGINT
+// column with a fetch size of 2.
+func cursorRowsMock() (*mockConn, *binaryRows) {
+	conn, mc := newRWMockConn(0)
+	mc.cfg.FetchSize = 2
+
+	rows := &binaryRows{cursor: &mysqlStmt{mc: mc, id: 1}}
+	rows.mc = mc
+	rows.rs.columns = []mysqlField{{fieldType: fieldTypeLongLong}}
+	return conn, rows
+}
+
+func TestBinaryRowsCursorFetch(t *testing.T) {
+	conn, rows := cursorRowsMock()
+	conn.queuedReplies = [][]byte{{
+		10, 0, 0, 1, 0x00, 0x00, 1, 0, 0, 0, 0, 0, 0, 0,
+		10, 0, 0, 2, 0x00, 0x00, 2, 0, 0, 0, 0, 0, 0, 0,
+		5, 0, 0, 3, 0xfe, 0x00, 0x00, 0x42, 0x00, // cursor exists
+	}, {
+		10, 0, 0, 1, 0x00, 0x00, 3, 0, 0, 0, 0, 0, 0, 0,
+		5, 0, 0, 2, 0xfe, 0x00, 0x00, 0xc2, 0x00, // last row sent
+	}}
+
+	dest := make([]driver.Value, 1)
+	for i := int64(1); i <= 3; i++ {
+		if err := rows.Next(dest); err != nil {
+			t.Fatalf("row %d: %v", i, err)
+		}
+		if dest[0] != i {
+			t.Errorf("expected %d, got %v", i, dest[0])
+		}
+	}
+	if err := rows.Next(dest); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	fetch := []byte{9, 0, 0, 0, 0x1c, 1, 0, 0, 0, 2, 0, 0, 0}
+	if expected := append(fetch, fetch...); !bytes.Equal(conn.written, expected) {
+		t.Errorf("unexpected packets:\n%v\nexpected\n%v", conn.written, expected)
+	}
+	if rows.cursor != nil || rows.mc != nil {
+		t.Errorf("expected the cursor to be closed")
+	}
+}
+
+func TestBinaryRowsCursorClose(t *testing.T) {
+	conn, rows := cursorRowsMock()
+	conn.queuedReplies = [][]byte{{
+		10, 0, 0, 1, 0x00, 0x00, 1, 0, 0, 0, 0, 0, 0, 0,
+		10, 0, 0, 2, 0x00, 0x00, 2, 0, 0, 0, 0, 0, 0, 0,
+		5, 0, 0, 3, 0xfe, 0x00, 0x00, 0x42, 0x00,
+	}, {
+		7, 0, 0, 1, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+	}}
+
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		t.Fatal(err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the pending rows are discarded, and the cursor closed
+	expected := []byte{
+		9, 0, 0, 0, 0x1c, 1, 0, 0, 0, 2, 0, 0, 0, // COM_STMT_FETCH
+		5, 0, 0, 0, 0x1a, 1, 0, 0, 0, // COM_STMT_RESET
+	}
+	if !bytes.Equal(conn.written, expected) {
+		t.Errorf("unexpected packets:\n%v\nexpected\n%v", conn.written, expected)
+	}
+	if len(conn.data) != 0 {
+		t.Errorf("expected all packets to be read, %d bytes left", len(conn.data))
+	}
+}
@@ -0,0 +1,82 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mysql
+
+// ReplicationConn is a connection registered as a replica of the server, which
+// streams its binary log. It is not usable with database/sql; see the binlog
+// package for a client decoding the events.
+type ReplicationConn struct {
+	mc *mysqlConn
+}
+
+// NewReplicationConn opens a replication connection to the server of cfg.
+// Its user needs the REPLICATION SLAVE privilege.
+// As the server only sends events when the binary log changes, cfg.ReadTimeout
+// should be left unset unless heartbeats are enabled.
+func NewReplicationConn(cfg *Config) (*ReplicationConn, error) {
+	conn, err := MySQLDriver{}.Open(cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	return &ReplicationConn{mc: conn.(*mysqlConn)}, nil
+}
+
+// Exec executes a query without result set, like setting the session
+// variables of the replica before the dump is started.
+func (rc *ReplicationConn) Exec(query string) error {
+	return rc.mc.exec(query)
+}
+
+// SystemVar returns the value of the given system variable.
+func (rc *ReplicationConn) SystemVar(name string) (string, error) {
+	value, err := rc.mc.getSystemVar(name)
+	return string(value), err
+}
+
+// Register registers the connection as the replica serverID with
+// COM_REGISTER_SLAVE. serverID must be unique among the server and its
+// replicas. host, port, user and password are listed by SHOW SLAVE HOSTS.
+func (rc *ReplicationConn) Register(serverID uint32, host string, port uint16, user, password string) error {
+	if err := rc.mc.writeRegisterSlavePacket(serverID, host, user, password, port); err != nil {
+		return err
+	}
+	return rc.mc.readResultOK()
+}
+
+// DumpBinlog starts streaming the binary log from the position pos of file
+// with COM_BINLOG_DUMP. If nonBlock is set, ReadEvent returns io.EOF at the
+// end of the binary log instead of waiting for new events.
+func (rc *ReplicationConn) DumpBinlog(serverID uint32, file string, pos uint32, nonBlock bool) error {
+	var flags uint16
+	if nonBlock {
+		flags |= binlogDumpNonBlock
+	}
+	return rc.mc.writeBinlogDumpPacket(serverID, file, pos, flags)
+}
+
+// DumpBinlogGTID starts streaming the binary log with COM_BINLOG_DUMP_GTID,
+// skipping the transactions of gtidSet, in the encoding of the server.
+// nonBlock is the same as for DumpBinlog.
+func (rc *ReplicationConn) DumpBinlogGTID(serverID uint32, gtidSet []byte, nonBlock bool) error {
+	var flags uint16
+	if nonBlock {
+		flags |= binlogDumpNonBlock
+	}
+	return rc.mc.writeBinlogDumpGTIDPacket(serverID, "", 4, gtidSet, flags)
+}
+
+// ReadEvent reads the next event of the binary log, with its header.
+func (rc *ReplicationConn) ReadEvent() ([]byte, error) {
+	return rc.mc.readBinlogEventPacket()
+}
+
+// Close closes the connection.
+func (rc *ReplicationConn) Close() error {
+	return rc.mc.Close()
+}
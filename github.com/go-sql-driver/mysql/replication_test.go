@@ -0,0 +1,107 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2018 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mysql
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReplicationRegister(t *testing.T) {
+	conn, mc := newRWMockConn(0)
+	conn.queuedReplies = [][]byte{{0x07, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00}}
+	conn.maxReads = 1
+	rc := &ReplicationConn{mc: mc}
+
+	if err := rc.Register(1001, "replica", 3306, "repl", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		0x1f, 0x00, 0x00, 0x00, // header
+		0x15,                   // COM_REGISTER_SLAVE
+		0xe9, 0x03, 0x00, 0x00, // server id
+		0x07, 'r', 'e', 'p', 'l', 'i', 'c', 'a',
+		0x04, 'r', 'e', 'p', 'l',
+		0x02, 'p', 'w',
+		0xea, 0x0c, // port
+		0x00, 0x00, 0x00, 0x00, // replication rank
+		0x00, 0x00, 0x00, 0x00, // master id
+	}
+	if !bytes.Equal(conn.written, expected) {
+		t.Fatalf("unexpected packet:\n%v\nexpected\n%v", conn.written, expected)
+	}
+}
+
+func TestReplicationDumpBinlog(t *testing.T) {
+	conn, mc := newRWMockConn(0)
+	rc := &ReplicationConn{mc: mc}
+
+	if err := rc.DumpBinlog(2, "bin.000003", 154, true); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		0x15, 0x00, 0x00, 0x00, // header
+		0x12,                   // COM_BINLOG_DUMP
+		0x9a, 0x00, 0x00, 0x00, // position
+		0x01, 0x00, // flags
+		0x02, 0x00, 0x00, 0x00, // server id
+		'b', 'i', 'n', '.', '0', '0', '0', '0', '0', '3',
+	}
+	if !bytes.Equal(conn.written, expected) {
+		t.Fatalf("unexpected packet:\n%v\nexpected\n%v", conn.written, expected)
+	}
+
+	conn.written = nil
+	if err := rc.DumpBinlogGTID(2, []byte{0x01, 0x02}, false); err != nil {
+		t.Fatal(err)
+	}
+	expected = []byte{
+		0x19, 0x00, 0x00, 0x00, // header
+		0x1e,       // COM_BINLOG_DUMP_GTID
+		0x04, 0x00, // flags
+		0x02, 0x00, 0x00, 0x00, // server id
+		0x00, 0x00, 0x00, 0x00, // file name length
+		0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // position
+		0x02, 0x00, 0x00, 0x00, // GTID set length
+		0x01, 0x02,
+	}
+	if !bytes.Equal(conn.written, expected) {
+		t.Fatalf("unexpected packet:\n%v\nexpected\n%v", conn.written, expected)
+	}
+}
+
+func TestReplicationReadEvent(t *testing.T) {
+	conn, mc := newRWMockConn(0)
+	rc := &ReplicationConn{mc: mc}
+
+	conn.data = []byte{
+		0x04, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, // event
+		0x05, 0x00, 0x00, 0x01, 0xfe, 0x00, 0x00, 0x02, 0x00, // EOF
+	}
+	event, err := rc.ReadEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(event, []byte{0x01, 0x02, 0x03}) {
+		t.Fatalf("unexpected event %v", event)
+	}
+	if _, err = rc.ReadEvent(); err != io.EOF {
+		t.Fatalf("expected io.EOF at the end of the binlog, got %v", err)
+	}
+
+	mc.sequence = 0
+	conn.data = []byte{
+		0x0c, 0x00, 0x00, 0x00, 0xff, 0x29, 0x05, 'n', 'o', ' ', 'b', 'i', 'n', 'l', 'o', 'g', // ERR
+	}
+	_, err = rc.ReadEvent()
+	if merr, ok := err.(*MySQLError); !ok || merr.Number != 1321 {
+		t.Fatalf("expected MySQLError 1321, got %v", err)
+	}
+}
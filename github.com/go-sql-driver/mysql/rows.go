@@ -29,6 +29,8 @@ type mysqlRows struct {
 
 type binaryRows struct {
 	mysqlRows
+	cursor   *mysqlStmt // statement of an open server-side cursor
+	fetching bool       // rows of a COM_STMT_FETCH are pending
 }
 
 type textRows struct {
@@ -125,6 +127,28 @@ func (rows *mysqlRows) Close() (err error) {
 	return err
 }
 
+func (rows *binaryRows) Close() error {
+	stmt, mc := rows.cursor, rows.mc
+	if stmt == nil || mc == nil {
+		return rows.mysqlRows.Close()
+	}
+	rows.cursor = nil
+
+	// Only the rows of a pending fetch have to be removed from the stream
+	if !rows.fetching {
+		rows.rs.done = true
+	}
+	if err := rows.mysqlRows.Close(); err != nil {
+		return err
+	}
+
+	// Close the cursor, so that the statement can be executed again
+	if err := mc.writeCommandPacketUint32(comStmtReset, stmt.id); err != nil {
+		return err
+	}
+	return mc.readResultOK()
+}
+
 func (rows *mysqlRows) HasNextResultSet() (b bool) {
 	if rows.mc == nil {
 		return false
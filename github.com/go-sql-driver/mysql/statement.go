@@ -50,7 +50,7 @@ func (stmt *mysqlStmt) Exec(args []driver.Value) (driver.Result, error) {
 		return nil, driver.ErrBadConn
 	}
 	// Send command
-	err := stmt.writeExecutePacket(args)
+	err := stmt.writeExecutePacket(args, cursorTypeNoCursor)
 	if err != nil {
 		return nil, stmt.mc.markBadConn(err)
 	}
@@ -97,8 +97,14 @@ func (stmt *mysqlStmt) query(args []driver.Value) (*binaryRows, error) {
 		errLog.Print(ErrInvalidConn)
 		return nil, driver.ErrBadConn
 	}
+	// Stream the rows from a server-side cursor if a fetch size is set
+	cursorType := cursorTypeNoCursor
+	if stmt.mc.cfg.FetchSize > 0 {
+		cursorType = cursorTypeReadOnly
+	}
+
 	// Send command
-	err := stmt.writeExecutePacket(args)
+	err := stmt.writeExecutePacket(args, cursorType)
 	if err != nil {
 		return nil, stmt.mc.markBadConn(err)
 	}
@@ -116,6 +122,12 @@ func (stmt *mysqlStmt) query(args []driver.Value) (*binaryRows, error) {
 	if resLen > 0 {
 		rows.mc = mc
 		rows.rs.columns, err = mc.readColumns(resLen)
+
+		// The server only opens a cursor for statements returning rows, which
+		// are then fetched by Next
+		if err == nil && mc.status&statusCursorExists != 0 {
+			rows.cursor = stmt
+		}
 	} else {
 		rows.rs.done = true
 
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"net/url"
 	"regexp"
@@ -49,6 +50,9 @@ type conn struct {
 	writeTimeout time.Duration
 	bw           *bufio.Writer
 
+	// Called with out of band RESP3 push messages.
+	pushHandler func(push []interface{})
+
 	// Scratch space for formatting argument length.
 	// '*' or '$', length, "\r\n"
 	lenScratch [32]byte
@@ -84,6 +88,8 @@ type dialOptions struct {
 	useTLS       bool
 	skipVerify   bool
 	tlsConfig    *tls.Config
+	protocol     int
+	pushHandler  func(push []interface{})
 }
 
 // DialReadTimeout specifies the timeout for reading a single command reply.
@@ -174,6 +180,33 @@ func DialUseTLS(useTLS bool) DialOption {
 	}}
 }
 
+// DialProtocol specifies the version of the Redis protocol used by the
+// connection. Version 3 negotiates RESP3 with the HELLO command, which
+// requires Redis 6 or later. The default is version 2.
+//
+// RESP3 adds reply types and out of band push messages, see the package
+// documentation for how they are represented. Push messages other than
+// Pub/Sub messages are passed to the function specified with
+// DialPushHandler.
+func DialProtocol(version int) DialOption {
+	return DialOption{func(do *dialOptions) {
+		do.protocol = version
+	}}
+}
+
+// DialPushHandler specifies a function called with RESP3 push messages that
+// are not Pub/Sub messages, for example the invalidation messages sent by
+// the server for keys tracked with CLIENT TRACKING. The message kind is the
+// first element of push. The function is called from the goroutine reading
+// replies and must not use the connection.
+//
+// Push messages are discarded when no handler is specified.
+func DialPushHandler(f func(push []interface{})) DialOption {
+	return DialOption{func(do *dialOptions) {
+		do.pushHandler = f
+	}}
+}
+
 // Dial connects to the Redis server at the given network and
 // address using the specified options.
 func Dial(network, address string, options ...DialOption) (Conn, error) {
@@ -188,6 +221,11 @@ func Dial(network, address string, options ...DialOption) (Conn, error) {
 	if do.dial == nil {
 		do.dial = do.dialer.Dial
 	}
+	switch do.protocol {
+	case 0, 2, 3:
+	default:
+		return nil, fmt.Errorf("redigo: unsupported protocol version %d", do.protocol)
+	}
 
 	netConn, err := do.dial(network, address)
 	if err != nil {
@@ -224,9 +262,19 @@ func Dial(network, address string, options ...DialOption) (Conn, error) {
 		br:           bufio.NewReader(netConn),
 		readTimeout:  do.readTimeout,
 		writeTimeout: do.writeTimeout,
+		pushHandler:  do.pushHandler,
 	}
 
-	if do.password != "" {
+	if do.protocol == 3 {
+		args := []interface{}{3}
+		if do.password != "" {
+			args = append(args, "AUTH", "default", do.password)
+		}
+		if _, err := c.Do("HELLO", args...); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	} else if do.password != "" {
 		if _, err := c.Do("AUTH", do.password); err != nil {
 			netConn.Close()
 			return nil, err
@@ -527,6 +575,71 @@ var (
 	pongReply interface{} = "PONG"
 )
 
+// pushReply is a RESP3 push message.
+type pushReply []interface{}
+
+// pubSubKinds are the kinds of push messages that are replies to Pub/Sub
+// commands.
+var pubSubKinds = map[string]bool{
+	"message":      true,
+	"pmessage":     true,
+	"subscribe":    true,
+	"unsubscribe":  true,
+	"psubscribe":   true,
+	"punsubscribe": true,
+}
+
+// readTopReply reads a reply to a command. Pub/Sub push messages are returned
+// as arrays, other push messages are passed to the push handler.
+func (c *conn) readTopReply() (interface{}, error) {
+	for {
+		reply, err := c.readReply()
+		if err != nil {
+			return nil, err
+		}
+		push, ok := reply.(pushReply)
+		if !ok {
+			return reply, nil
+		}
+		if len(push) > 0 {
+			if kind, _ := String(push[0], nil); pubSubKinds[kind] {
+				return []interface{}(push), nil
+			}
+		}
+		if c.pushHandler != nil {
+			c.pushHandler(push)
+		}
+	}
+}
+
+// readBlob reads a blob of n bytes followed by CRLF.
+func (c *conn) readBlob(n int) ([]byte, error) {
+	p := make([]byte, n)
+	_, err := io.ReadFull(c.br, p)
+	if err != nil {
+		return nil, err
+	}
+	if line, err := c.readLine(); err != nil {
+		return nil, err
+	} else if len(line) != 0 {
+		return nil, protocolError("bad bulk string format")
+	}
+	return p, nil
+}
+
+// readValues reads n replies.
+func (c *conn) readValues(n int) ([]interface{}, error) {
+	r := make([]interface{}, n)
+	for i := range r {
+		var err error
+		r[i], err = c.readReply()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
 func (c *conn) readReply() (interface{}, error) {
 	line, err := c.readLine()
 	if err != nil {
@@ -556,30 +669,81 @@ func (c *conn) readReply() (interface{}, error) {
 		if n < 0 || err != nil {
 			return nil, err
 		}
-		p := make([]byte, n)
-		_, err = io.ReadFull(c.br, p)
-		if err != nil {
+		return c.readBlob(n)
+	case '*', '~':
+		// Sets are returned as arrays.
+		n, err := parseLen(line[1:])
+		if n < 0 || err != nil {
 			return nil, err
 		}
-		if line, err := c.readLine(); err != nil {
+		return c.readValues(n)
+	case '%':
+		// Maps are returned as arrays of alternating keys and values, the
+		// format of RESP2 replies to commands like HGETALL.
+		n, err := parseLen(line[1:])
+		if n < 0 || err != nil {
 			return nil, err
-		} else if len(line) != 0 {
-			return nil, protocolError("bad bulk string format")
 		}
-		return p, nil
-	case '*':
+		return c.readValues(2 * n)
+	case '>':
 		n, err := parseLen(line[1:])
 		if n < 0 || err != nil {
 			return nil, err
 		}
-		r := make([]interface{}, n)
-		for i := range r {
-			r[i], err = c.readReply()
-			if err != nil {
-				return nil, err
-			}
+		r, err := c.readValues(n)
+		return pushReply(r), err
+	case '|':
+		// Attributes are metadata preceding a reply. They are skipped.
+		n, err := parseLen(line[1:])
+		if n < 0 || err != nil {
+			return nil, err
+		}
+		if _, err := c.readValues(2 * n); err != nil {
+			return nil, err
+		}
+		return c.readReply()
+	case '_':
+		if len(line) != 1 {
+			return nil, protocolError("bad null format")
+		}
+		return nil, nil
+	case '#':
+		switch {
+		case len(line) == 2 && line[1] == 't':
+			return true, nil
+		case len(line) == 2 && line[1] == 'f':
+			return false, nil
+		}
+		return nil, protocolError("bad boolean format")
+	case ',':
+		f, err := strconv.ParseFloat(string(line[1:]), 64)
+		if err != nil {
+			return nil, protocolError("bad double format")
+		}
+		return f, nil
+	case '(':
+		n, ok := new(big.Int).SetString(string(line[1:]), 10)
+		if !ok {
+			return nil, protocolError("bad big number format")
+		}
+		return n, nil
+	case '!', '=':
+		n, err := parseLen(line[1:])
+		if n < 0 || err != nil {
+			return nil, protocolError("malformed length")
+		}
+		p, err := c.readBlob(n)
+		if err != nil {
+			return nil, err
+		}
+		if line[0] == '!' {
+			return Error(p), nil
+		}
+		// Verbatim strings start with a three character format and a colon.
+		if len(p) < 4 || p[3] != ':' {
+			return nil, protocolError("bad verbatim string format")
 		}
-		return r, nil
+		return p[4:], nil
 	}
 	return nil, protocolError("unexpected response line")
 }
@@ -618,7 +782,7 @@ func (c *conn) ReceiveWithTimeout(timeout time.Duration) (reply interface{}, err
 	}
 	c.conn.SetReadDeadline(deadline)
 
-	if reply, err = c.readReply(); err != nil {
+	if reply, err = c.readTopReply(); err != nil {
 		return nil, c.fatal(err)
 	}
 	// When using pub/sub, the number of receives can be greater than the
@@ -676,7 +840,7 @@ func (c *conn) DoWithTimeout(readTimeout time.Duration, cmd string, args ...inte
 	if cmd == "" {
 		reply := make([]interface{}, pending)
 		for i := range reply {
-			r, e := c.readReply()
+			r, e := c.readTopReply()
 			if e != nil {
 				return nil, c.fatal(e)
 			}
@@ -689,7 +853,7 @@ func (c *conn) DoWithTimeout(readTimeout time.Duration, cmd string, args ...inte
 	var reply interface{}
 	for i := 0; i <= pending; i++ {
 		var e error
-		if reply, e = c.readReply(); e != nil {
+		if reply, e = c.readTopReply(); e != nil {
 			return nil, c.fatal(e)
 		}
 		if e, ok := reply.(Error); ok && err == nil {
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"net"
 	"os"
 	"reflect"
@@ -209,6 +210,67 @@ var readTests = []struct {
 		"*3\r\n$3\r\nfoo\r\n$-1\r\n$3\r\nbar\r\n",
 		[]interface{}{[]byte("foo"), nil, []byte("bar")},
 	},
+	{
+		"_\r\n",
+		nil,
+	},
+	{
+		",1.5\r\n",
+		1.5,
+	},
+	{
+		",-inf\r\n",
+		math.Inf(-1),
+	},
+	{
+		"#t\r\n",
+		true,
+	},
+	{
+		"#f\r\n",
+		false,
+	},
+	{
+		"(3492890328409238509324850943850943825024385\r\n",
+		bigInt("3492890328409238509324850943850943825024385"),
+	},
+	{
+		"!10\r\nSYNTAX err\r\n",
+		errorSentinel,
+	},
+	{
+		"=15\r\ntxt:Some string\r\n",
+		[]byte("Some string"),
+	},
+	{
+		"%2\r\n+first\r\n:1\r\n+second\r\n:2\r\n",
+		[]interface{}{"first", int64(1), "second", int64(2)},
+	},
+	{
+		"~2\r\n+a\r\n+b\r\n",
+		[]interface{}{"a", "b"},
+	},
+	{
+		"|1\r\n+key-popularity\r\n%1\r\n$1\r\na\r\n,0.19\r\n*1\r\n:2039123\r\n",
+		[]interface{}{int64(2039123)},
+	},
+	{
+		">3\r\n$7\r\nmessage\r\n$2\r\nch\r\n$4\r\ndata\r\n",
+		[]interface{}{[]byte("message"), []byte("ch"), []byte("data")},
+	},
+	{
+		// push messages other than Pub/Sub messages are not returned
+		">2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nfoo\r\n:1\r\n",
+		int64(1),
+	},
+	{
+		"#x\r\n",
+		errorSentinel,
+	},
+	{
+		",x\r\n",
+		errorSentinel,
+	},
 
 	{
 		// "" is not a valid length
@@ -272,6 +334,40 @@ func TestRead(t *testing.T) {
 	}
 }
 
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+func TestPushHandler(t *testing.T) {
+	var pushes [][]interface{}
+	var buf bytes.Buffer
+	c, err := redis.Dial("", "",
+		dialTestConn("%1\r\n$5\r\nproto\r\n:3\r\n"+
+			">2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nfoo\r\n"+
+			"$3\r\nbar\r\n", &buf),
+		redis.DialProtocol(3),
+		redis.DialPushHandler(func(push []interface{}) { pushes = append(pushes, push) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "*2\r\n$5\r\nHELLO\r\n$1\r\n3\r\n") {
+		t.Errorf("dial sent %q, want HELLO 3", buf.String())
+	}
+	v, err := redis.String(c.Do("GET", "foo"))
+	if err != nil || v != "bar" {
+		t.Fatalf("GET = %q, %v, want %q", v, err, "bar")
+	}
+	want := [][]interface{}{{[]byte("invalidate"), []interface{}{[]byte("foo")}}}
+	if !reflect.DeepEqual(pushes, want) {
+		t.Errorf("pushes = %q, want %q", pushes, want)
+	}
+
+	if _, err := redis.Dial("", "", dialTestConn("", nil), redis.DialProtocol(4)); err == nil {
+		t.Error("Dial with protocol 4 did not return an error")
+	}
+}
+
 func TestReadString(t *testing.T) {
 	// n is value of bufio.defaultBufSize
 	const n = 4096
@@ -59,6 +59,22 @@
 //  bulk string             []byte or nil if value not present.
 //  array                   []interface{} or nil if value not present.
 //
+// Connections dialed with the DialProtocol(3) option use RESP3, which adds
+// the following reply types:
+//
+//  Redis type              Go type
+//  null                    nil
+//  double                  float64
+//  boolean                 bool
+//  big number              *big.Int
+//  blob error              redis.Error
+//  verbatim string         []byte without the format prefix
+//  map                     []interface{} with alternating keys and values
+//  set                     []interface{}
+//
+// Attributes sent before a reply are discarded. The Map helper converts a map
+// reply to a map[string]interface{}.
+//
 // Use type assertions or the reply helper functions to convert from
 // interface{} to the specific Go type for the command result.
 //
@@ -140,6 +156,31 @@
 //      }
 //  }
 //
+// Client Side Caching
+//
+// With RESP3, the server sends out of band push messages on the connection.
+// Pub/Sub messages are received as described above, all other push messages
+// are passed to the function specified with the DialPushHandler option. Use
+// this to invalidate a local cache of keys tracked with CLIENT TRACKING:
+//
+//  c, err := redis.Dial("tcp", ":6379",
+//      redis.DialProtocol(3),
+//      redis.DialPushHandler(func(push []interface{}) {
+//          if kind, _ := redis.String(push[0], nil); kind == "invalidate" && len(push) == 2 {
+//              keys, _ := redis.Strings(push[1], nil) // nil when the server flushed all keys
+//              cache.invalidate(keys)
+//          }
+//      }))
+//  if err != nil {
+//      // handle error
+//  }
+//  if _, err := c.Do("CLIENT", "TRACKING", "ON"); err != nil {
+//      // handle error
+//  }
+//
+// Push messages are read together with command replies, so the handler is
+// only called while the application reads from the connection.
+//
 // Reply Helpers
 //
 // The Bool, Int, Bytes, String, Strings and Values functions convert a reply
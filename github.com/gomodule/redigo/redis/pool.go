@@ -161,6 +161,10 @@ type Pool struct {
 	// the pool does not close connections based on age.
 	MaxConnLifetime time.Duration
 
+	// Trace is an optional set of hooks for instrumenting the pool and its
+	// connections.
+	Trace *PoolTrace
+
 	chInitialized uint32 // set to 1 when field ch is initialized
 
 	mu           sync.Mutex    // mu protects the following fields
@@ -185,11 +189,11 @@ func NewPool(newFn func() (Conn, error), maxIdle int) *Pool {
 // getting an underlying connection, then the connection Err, Do, Send, Flush
 // and Receive methods return that error.
 func (p *Pool) Get() Conn {
-	pc, err := p.get(nil)
+	ac, err := p.getActive(nil)
 	if err != nil {
 		return errorConn{err}
 	}
-	return &activeConn{p: p, pc: pc}
+	return ac
 }
 
 // GetContext gets a connection using the provided context.
@@ -201,11 +205,42 @@ func (p *Pool) Get() Conn {
 // If the function completes without error, then the application must close the
 // returned connection.
 func (p *Pool) GetContext(ctx context.Context) (Conn, error) {
-	pc, err := p.get(ctx)
+	ac, err := p.getActive(ctx)
 	if err != nil {
 		return errorConn{err}, err
 	}
-	return &activeConn{p: p, pc: pc}, nil
+	return ac, nil
+}
+
+func (p *Pool) getActive(ctx context.Context) (*activeConn, error) {
+	trace := p.Trace
+	if trace == nil {
+		pc, err := p.get(ctx, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &activeConn{p: p, pc: pc}, nil
+	}
+
+	tctx := ctx
+	if tctx == nil {
+		tctx = context.Background()
+	}
+	if trace.GetStart != nil {
+		tctx = trace.GetStart(tctx)
+	}
+	var info GetDoneInfo
+	start := time.Now()
+	pc, err := p.get(ctx, tctx, &info)
+	if trace.GetDone != nil {
+		info.Duration = time.Since(start)
+		info.Err = err
+		trace.GetDone(tctx, info)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &activeConn{p: p, pc: pc, trace: trace, ctx: tctx, borrowed: start}, nil
 }
 
 // PoolStats contains pool statistics.
@@ -256,6 +291,22 @@ func (p *Pool) IdleCount() int {
 	return idle
 }
 
+// CloseIdle closes the idle connections in the pool. Connections in use are
+// not affected. Use CloseIdle to drop connections to a server that is no
+// longer valid, for example after a failover.
+func (p *Pool) CloseIdle() error {
+	p.mu.Lock()
+	p.active -= p.idle.count
+	pc := p.idle.front
+	p.idle.count = 0
+	p.idle.front, p.idle.back = nil, nil
+	p.mu.Unlock()
+	for ; pc != nil; pc = pc.next {
+		pc.c.Close()
+	}
+	return nil
+}
+
 // Close releases the resources used by the pool.
 func (p *Pool) Close() error {
 	p.mu.Lock()
@@ -300,8 +351,10 @@ func (p *Pool) lazyInit() {
 }
 
 // get prunes stale connections and returns a connection from the idle list or
-// creates a new connection.
-func (p *Pool) get(ctx context.Context) (*poolConn, error) {
+// creates a new connection. If the pool is traced, tctx is the context passed
+// to the dial hooks and the dial function, and info records the wait and reuse
+// of a connection.
+func (p *Pool) get(ctx, tctx context.Context, info *GetDoneInfo) (*poolConn, error) {
 
 	// Handle limit for p.Wait == true.
 	var waited time.Duration
@@ -327,6 +380,9 @@ func (p *Pool) get(ctx context.Context) (*poolConn, error) {
 		if wait {
 			waited = time.Since(start)
 		}
+		if info != nil {
+			info.Wait = waited
+		}
 	}
 
 	p.mu.Lock()
@@ -356,6 +412,9 @@ func (p *Pool) get(ctx context.Context) (*poolConn, error) {
 		p.mu.Unlock()
 		if (p.TestOnBorrow == nil || p.TestOnBorrow(pc.c, pc.t) == nil) &&
 			(p.MaxConnLifetime == 0 || nowFunc().Sub(pc.created) < p.MaxConnLifetime) {
+			if info != nil {
+				info.Reused = true
+			}
 			return pc, nil
 		}
 		pc.c.Close()
@@ -377,7 +436,13 @@ func (p *Pool) get(ctx context.Context) (*poolConn, error) {
 
 	p.active++
 	p.mu.Unlock()
-	c, err := p.dial(ctx)
+	var c Conn
+	var err error
+	if info != nil {
+		c, err = p.traceDial(tctx)
+	} else {
+		c, err = p.dial(ctx)
+	}
 	if err != nil {
 		c = nil
 		p.mu.Lock()
@@ -431,6 +496,11 @@ type activeConn struct {
 	p     *Pool
 	pc    *poolConn
 	state int
+
+	// Set when the pool is traced.
+	trace    *PoolTrace
+	ctx      context.Context
+	borrowed time.Time
 }
 
 var (
@@ -484,7 +554,11 @@ func (ac *activeConn) Close() error {
 		}
 	}
 	pc.c.Do("")
-	ac.p.put(pc, ac.state != 0 || pc.c.Err() != nil)
+	forceClose := ac.state != 0 || pc.c.Err() != nil
+	if ac.trace != nil && ac.trace.PutConn != nil {
+		ac.trace.PutConn(ac.ctx, PutConnInfo{Used: time.Since(ac.borrowed), Closed: forceClose})
+	}
+	ac.p.put(pc, forceClose)
 	return nil
 }
 
@@ -503,6 +577,11 @@ func (ac *activeConn) Do(commandName string, args ...interface{}) (reply interfa
 	}
 	ci := lookupCommandInfo(commandName)
 	ac.state = (ac.state | ci.Set) &^ ci.Clear
+	if ac.trace != nil {
+		return ac.traceCommand("Do", commandName, args, func() (interface{}, error) {
+			return pc.c.Do(commandName, args...)
+		})
+	}
 	return pc.c.Do(commandName, args...)
 }
 
@@ -517,6 +596,11 @@ func (ac *activeConn) DoWithTimeout(timeout time.Duration, commandName string, a
 	}
 	ci := lookupCommandInfo(commandName)
 	ac.state = (ac.state | ci.Set) &^ ci.Clear
+	if ac.trace != nil {
+		return ac.traceCommand("Do", commandName, args, func() (interface{}, error) {
+			return cwt.DoWithTimeout(timeout, commandName, args...)
+		})
+	}
 	return cwt.DoWithTimeout(timeout, commandName, args...)
 }
 
@@ -527,6 +611,12 @@ func (ac *activeConn) Send(commandName string, args ...interface{}) error {
 	}
 	ci := lookupCommandInfo(commandName)
 	ac.state = (ac.state | ci.Set) &^ ci.Clear
+	if ac.trace != nil {
+		_, err := ac.traceCommand("Send", commandName, args, func() (interface{}, error) {
+			return nil, pc.c.Send(commandName, args...)
+		})
+		return err
+	}
 	return pc.c.Send(commandName, args...)
 }
 
@@ -535,6 +625,12 @@ func (ac *activeConn) Flush() error {
 	if pc == nil {
 		return errConnClosed
 	}
+	if ac.trace != nil {
+		_, err := ac.traceCommand("Flush", "", nil, func() (interface{}, error) {
+			return nil, pc.c.Flush()
+		})
+		return err
+	}
 	return pc.c.Flush()
 }
 
@@ -543,6 +639,9 @@ func (ac *activeConn) Receive() (reply interface{}, err error) {
 	if pc == nil {
 		return nil, errConnClosed
 	}
+	if ac.trace != nil {
+		return ac.traceCommand("Receive", "", nil, pc.c.Receive)
+	}
 	return pc.c.Receive()
 }
 
@@ -555,6 +654,11 @@ func (ac *activeConn) ReceiveWithTimeout(timeout time.Duration) (reply interface
 	if !ok {
 		return nil, errTimeoutNotSupported
 	}
+	if ac.trace != nil {
+		return ac.traceCommand("Receive", "", nil, func() (interface{}, error) {
+			return cwt.ReceiveWithTimeout(timeout)
+		})
+	}
 	return cwt.ReceiveWithTimeout(timeout)
 }
 
@@ -240,6 +240,33 @@ func TestPoolClose(t *testing.T) {
 	}
 }
 
+func TestPoolCloseIdle(t *testing.T) {
+	d := poolDialer{t: t}
+	p := &redis.Pool{
+		MaxIdle: 2,
+		Dial:    d.dial,
+	}
+	defer p.Close()
+
+	c1 := p.Get()
+	c1.Do("PING")
+	c2 := p.Get()
+	c2.Do("PING")
+	c1.Close()
+	d.check("before close idle", p, 2, 2, 1)
+
+	p.CloseIdle()
+	d.check("after close idle", p, 2, 1, 1)
+
+	c2.Close()
+	d.check("after close", p, 2, 1, 0)
+
+	c3 := p.Get()
+	c3.Do("PING")
+	c3.Close()
+	d.check("after reuse", p, 2, 1, 0)
+}
+
 func TestPoolClosedConn(t *testing.T) {
 	d := poolDialer{t: t}
 	p := &redis.Pool{
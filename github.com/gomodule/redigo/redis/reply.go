@@ -118,6 +118,7 @@ func Uint64(reply interface{}, err error) (uint64, error) {
 // the reply to an int as follows:
 //
 //  Reply type    Result
+//  double        reply, nil
 //  bulk string   parsed reply, nil
 //  nil           0, ErrNil
 //  other         0, error
@@ -126,6 +127,8 @@ func Float64(reply interface{}, err error) (float64, error) {
 		return 0, err
 	}
 	switch reply := reply.(type) {
+	case float64:
+		return reply, nil
 	case []byte:
 		n, err := strconv.ParseFloat(string(reply), 64)
 		return n, err
@@ -194,6 +197,7 @@ func Bytes(reply interface{}, err error) ([]byte, error) {
 // reply to boolean as follows:
 //
 //  Reply type      Result
+//  boolean         reply, nil
 //  integer         value != 0, nil
 //  bulk string     strconv.ParseBool(reply)
 //  nil             false, ErrNil
@@ -203,6 +207,8 @@ func Bool(reply interface{}, err error) (bool, error) {
 		return false, err
 	}
 	switch reply := reply.(type) {
+	case bool:
+		return reply, nil
 	case int64:
 		return reply != 0, nil
 	case []byte:
@@ -270,10 +276,14 @@ func sliceHelper(reply interface{}, err error, name string, makeSlice func(int),
 // Float64s is a helper that converts an array command reply to a []float64. If
 // err is not equal to nil, then Float64s returns nil, err. Nil array items are
 // converted to 0 in the output slice. Floats64 returns an error if an array
-// item is not a bulk string or nil.
+// item is not a bulk string, double or nil.
 func Float64s(reply interface{}, err error) ([]float64, error) {
 	var result []float64
 	err = sliceHelper(reply, err, "Float64s", func(n int) { result = make([]float64, n) }, func(i int, v interface{}) error {
+		if f, ok := v.(float64); ok {
+			result[i] = f
+			return nil
+		}
 		p, ok := v.([]byte)
 		if !ok {
 			return fmt.Errorf("redigo: unexpected element type for Floats64, got type %T", v)
@@ -446,6 +456,34 @@ func Int64Map(result interface{}, err error) (map[string]int64, error) {
 	return m, nil
 }
 
+// Map is a helper that converts a map reply, or an array of alternating keys
+// and values, into a map[string]interface{}. RESP3 map replies are returned
+// in this format, as are the RESP2 replies to commands like HGETALL and
+// CONFIG GET. Keys must be simple or bulk strings.
+func Map(result interface{}, err error) (map[string]interface{}, error) {
+	values, err := Values(result, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(values)%2 != 0 {
+		return nil, errors.New("redigo: Map expects even number of values result")
+	}
+	m := make(map[string]interface{}, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		var key string
+		switch k := values[i].(type) {
+		case []byte:
+			key = string(k)
+		case string:
+			key = k
+		default:
+			return nil, fmt.Errorf("redigo: unexpected key type for Map, got type %T", values[i])
+		}
+		m[key] = values[i+1]
+	}
+	return m, nil
+}
+
 // Positions is a helper that converts an array of positions (lat, long)
 // into a [][2]float64. The GEOPOS command returns replies in this format.
 func Positions(result interface{}, err error) ([]*[2]float64, error) {
@@ -477,3 +515,97 @@ func Positions(result interface{}, err error) ([]*[2]float64, error) {
 	}
 	return positions, nil
 }
+
+// StreamEntry is an entry of a Redis stream.
+type StreamEntry struct {
+	// ID is the ID of the entry.
+	ID string
+
+	// Fields holds the alternating names and values of the entry fields in
+	// the order they were added. Use ScanStruct to copy them to a struct:
+	//
+	//  var v struct {
+	//      Title string `redis:"title"`
+	//      Views int    `redis:"views"`
+	//  }
+	//  err := redis.ScanStruct(entry.Fields, &v)
+	Fields []interface{}
+}
+
+// FieldMap returns the fields of the entry as a map.
+func (e StreamEntry) FieldMap() (map[string]string, error) {
+	return StringMap(e.Fields, nil)
+}
+
+// StreamEntries is a helper that converts an array of stream entries into a
+// []StreamEntry. The XRANGE, XREVRANGE and XCLAIM commands return replies in
+// this format. Nil array items, returned for deleted entries, are skipped.
+func StreamEntries(reply interface{}, err error) ([]StreamEntry, error) {
+	values, err := Values(reply, err)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]StreamEntry, 0, len(values))
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		p, ok := v.([]interface{})
+		if !ok || len(p) != 2 {
+			return nil, fmt.Errorf("redigo: unexpected stream entry %v", v)
+		}
+		id, err := String(p[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		var fields []interface{}
+		if p[1] != nil {
+			if fields, ok = p[1].([]interface{}); !ok {
+				return nil, fmt.Errorf("redigo: unexpected element type for stream entry fields, got type %T", p[1])
+			}
+		}
+		entries = append(entries, StreamEntry{ID: id, Fields: fields})
+	}
+	return entries, nil
+}
+
+// Streams is a helper that converts the reply of XREAD or XREADGROUP into a
+// map from stream names to entries. Streams returns ErrNil when a blocking
+// read times out.
+func Streams(reply interface{}, err error) (map[string][]StreamEntry, error) {
+	values, err := Values(reply, err)
+	if err != nil {
+		return nil, err
+	}
+	// RESP2 replies are arrays of [name, entries] pairs, RESP3 replies are
+	// maps which are returned as alternating names and entries.
+	if len(values) > 0 {
+		if _, ok := values[0].([]interface{}); ok {
+			flat := make([]interface{}, 0, 2*len(values))
+			for _, v := range values {
+				p, ok := v.([]interface{})
+				if !ok || len(p) != 2 {
+					return nil, fmt.Errorf("redigo: unexpected stream %v", v)
+				}
+				flat = append(flat, p...)
+			}
+			values = flat
+		}
+	}
+	if len(values)%2 != 0 {
+		return nil, errors.New("redigo: Streams expects even number of values result")
+	}
+	streams := make(map[string][]StreamEntry, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		name, err := String(values[i], nil)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := StreamEntries(values[i+1], nil)
+		if err != nil {
+			return nil, err
+		}
+		streams[name] = entries
+	}
+	return streams, nil
+}
@@ -106,6 +106,51 @@ var replyTests = []struct {
 		ve(redis.Float64(nil, nil)),
 		ve(float64(0.0), redis.ErrNil),
 	},
+	{
+		"float64(double)",
+		ve(redis.Float64(float64(1.5), nil)),
+		ve(float64(1.5), nil),
+	},
+	{
+		"float64s([double, []byte])",
+		ve(redis.Float64s([]interface{}{float64(1.5), []byte("2.5")}, nil)),
+		ve([]float64{1.5, 2.5}, nil),
+	},
+	{
+		"bool(boolean)",
+		ve(redis.Bool(true, nil)),
+		ve(true, nil),
+	},
+	{
+		"map([k1, v1, k2, 2])",
+		ve(redis.Map([]interface{}{[]byte("k1"), []byte("v1"), "k2", int64(2)}, nil)),
+		ve(map[string]interface{}{"k1": []byte("v1"), "k2": int64(2)}, nil),
+	},
+	{
+		"map(nil)",
+		ve(redis.Map(nil, nil)),
+		ve(map[string]interface{}(nil), redis.ErrNil),
+	},
+	{
+		"streamentries([[1-0, [f, v]], nil])",
+		ve(redis.StreamEntries([]interface{}{[]interface{}{[]byte("1-0"), []interface{}{[]byte("f"), []byte("v")}}, nil}, nil)),
+		ve([]redis.StreamEntry{{ID: "1-0", Fields: []interface{}{[]byte("f"), []byte("v")}}}, nil),
+	},
+	{
+		"streams([[s, [[1-0, [f, v]]]]])",
+		ve(redis.Streams([]interface{}{[]interface{}{[]byte("s"), []interface{}{[]interface{}{[]byte("1-0"), []interface{}{[]byte("f"), []byte("v")}}}}}, nil)),
+		ve(map[string][]redis.StreamEntry{"s": {{ID: "1-0", Fields: []interface{}{[]byte("f"), []byte("v")}}}}, nil),
+	},
+	{
+		"streams(resp3 map)",
+		ve(redis.Streams([]interface{}{[]byte("s"), []interface{}{[]interface{}{[]byte("1-0"), []interface{}{[]byte("f"), []byte("v")}}}}, nil)),
+		ve(map[string][]redis.StreamEntry{"s": {{ID: "1-0", Fields: []interface{}{[]byte("f"), []byte("v")}}}}, nil),
+	},
+	{
+		"streams(nil)",
+		ve(redis.Streams(nil, nil)),
+		ve(map[string][]redis.StreamEntry(nil), redis.ErrNil),
+	},
 	{
 		"uint64(1)",
 		ve(redis.Uint64(int64(1), nil)),
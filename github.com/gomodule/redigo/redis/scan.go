@@ -44,6 +44,10 @@ func cannotConvert(d reflect.Value, s interface{}) error {
 		sname = "Redis bulk string"
 	case []interface{}:
 		sname = "Redis array"
+	case float64:
+		sname = "Redis double"
+	case bool:
+		sname = "Redis boolean"
 	case nil:
 		sname = "Redis nil"
 	default:
@@ -148,6 +152,30 @@ func convertAssignInt(d reflect.Value, s int64) (err error) {
 	return
 }
 
+func convertAssignFloat(d reflect.Value, s float64) (err error) {
+	switch d.Type().Kind() {
+	case reflect.Float32, reflect.Float64:
+		d.SetFloat(s)
+	case reflect.Interface:
+		d.Set(reflect.ValueOf(s))
+	default:
+		err = cannotConvert(d, s)
+	}
+	return
+}
+
+func convertAssignBool(d reflect.Value, s bool) (err error) {
+	switch d.Type().Kind() {
+	case reflect.Bool:
+		d.SetBool(s)
+	case reflect.Interface:
+		d.Set(reflect.ValueOf(s))
+	default:
+		err = cannotConvert(d, s)
+	}
+	return
+}
+
 func convertAssignValue(d reflect.Value, s interface{}) (err error) {
 	if d.Kind() != reflect.Ptr {
 		if d.CanAddr() {
@@ -177,6 +205,10 @@ func convertAssignValue(d reflect.Value, s interface{}) (err error) {
 		err = convertAssignInt(d, s)
 	case string:
 		err = convertAssignString(d, s)
+	case float64:
+		err = convertAssignFloat(d, s)
+	case bool:
+		err = convertAssignBool(d, s)
 	case Error:
 		err = convertAssignError(d, s)
 	default:
@@ -277,6 +309,36 @@ func convertAssign(d interface{}, s interface{}) (err error) {
 				err = convertAssignArray(d.Elem(), s)
 			}
 		}
+	case float64:
+		switch d := d.(type) {
+		case *float64:
+			*d = s
+		case *interface{}:
+			*d = s
+		case nil:
+			// skip value
+		default:
+			if d := reflect.ValueOf(d); d.Type().Kind() != reflect.Ptr {
+				err = cannotConvert(d, s)
+			} else {
+				err = convertAssignFloat(d.Elem(), s)
+			}
+		}
+	case bool:
+		switch d := d.(type) {
+		case *bool:
+			*d = s
+		case *interface{}:
+			*d = s
+		case nil:
+			// skip value
+		default:
+			if d := reflect.ValueOf(d); d.Type().Kind() != reflect.Ptr {
+				err = cannotConvert(d, s)
+			} else {
+				err = convertAssignBool(d.Elem(), s)
+			}
+		}
 	case Error:
 		err = s
 	default:
@@ -0,0 +1,162 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+// PoolTrace is a set of hooks called by a pool and the connections it
+// returns. Any hook may be nil. Hooks may be called concurrently from
+// multiple goroutines.
+//
+// The context passed to the hooks of a connection is the context given to
+// Pool.GetContext, or the background context for Pool.Get, as returned by
+// GetStart. Start hooks return the context passed to the matching done hook,
+// which lets tracing libraries start a span in a start hook and end it in the
+// done hook:
+//
+//  pool.Trace = &redis.PoolTrace{
+//      CommandStart: func(ctx context.Context, info redis.CommandStartInfo) context.Context {
+//          ctx, _ = tracer.Start(ctx, "redis "+info.CommandName)
+//          return ctx
+//      },
+//      CommandDone: func(ctx context.Context, info redis.CommandDoneInfo) {
+//          span := trace.SpanFromContext(ctx)
+//          if info.Err != nil {
+//              span.RecordError(info.Err)
+//          }
+//          span.End()
+//          commandLatency.WithLabelValues(info.CommandName).Observe(info.Duration.Seconds())
+//      },
+//  }
+type PoolTrace struct {
+	// GetStart is called when the application requests a connection.
+	GetStart func(ctx context.Context) context.Context
+
+	// GetDone is called when a connection is returned to the application or
+	// getting a connection failed.
+	GetDone func(ctx context.Context, info GetDoneInfo)
+
+	// DialStart is called before the pool dials a new connection.
+	DialStart func(ctx context.Context) context.Context
+
+	// DialDone is called when dialing a new connection completes.
+	DialDone func(ctx context.Context, info DialDoneInfo)
+
+	// CommandStart is called before a connection method sends a command or
+	// receives a reply.
+	CommandStart func(ctx context.Context, info CommandStartInfo) context.Context
+
+	// CommandDone is called when a connection method returns.
+	CommandDone func(ctx context.Context, info CommandDoneInfo)
+
+	// PutConn is called when the application closes a connection.
+	PutConn func(ctx context.Context, info PutConnInfo)
+}
+
+// GetDoneInfo is the argument of PoolTrace.GetDone.
+type GetDoneInfo struct {
+	// Wait is the time spent waiting for a connection because the pool was
+	// at the MaxActive limit.
+	Wait time.Duration
+
+	// Duration is the total time taken to get the connection, including
+	// the wait and dialing a new connection.
+	Duration time.Duration
+
+	// Reused is true if the connection was taken from the idle connections.
+	Reused bool
+
+	// Err is the error getting the connection. Err is ErrPoolExhausted when
+	// the pool is at the MaxActive limit and Wait is false.
+	Err error
+}
+
+// DialDoneInfo is the argument of PoolTrace.DialDone.
+type DialDoneInfo struct {
+	Duration time.Duration
+	Err      error
+}
+
+// CommandStartInfo is the argument of PoolTrace.CommandStart.
+type CommandStartInfo struct {
+	// Method is the connection method called: "Do", "Send", "Flush" or
+	// "Receive".
+	Method string
+
+	// CommandName and Args are the command and its arguments. They are empty
+	// for Flush and Receive.
+	CommandName string
+	Args        []interface{}
+}
+
+// CommandDoneInfo is the argument of PoolTrace.CommandDone.
+type CommandDoneInfo struct {
+	Method      string
+	CommandName string
+	Duration    time.Duration
+
+	// Reply is the reply of Do and Receive.
+	Reply interface{}
+	Err   error
+}
+
+// PutConnInfo is the argument of PoolTrace.PutConn.
+type PutConnInfo struct {
+	// Used is the time the application held the connection.
+	Used time.Duration
+
+	// Closed is true if the connection is closed instead of being returned
+	// to the idle connections because it has an error or is left in a
+	// special state.
+	Closed bool
+}
+
+func (ac *activeConn) traceCommand(method, commandName string, args []interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	trace := ac.trace
+	ctx := ac.ctx
+	if trace.CommandStart != nil {
+		ctx = trace.CommandStart(ctx, CommandStartInfo{Method: method, CommandName: commandName, Args: args})
+	}
+	start := time.Now()
+	reply, err := fn()
+	if trace.CommandDone != nil {
+		trace.CommandDone(ctx, CommandDoneInfo{
+			Method:      method,
+			CommandName: commandName,
+			Duration:    time.Since(start),
+			Reply:       reply,
+			Err:         err,
+		})
+	}
+	return reply, err
+}
+
+// traceDial dials a new connection with the context returned by DialStart,
+// so that the dial function sees the values added by the hooks.
+func (p *Pool) traceDial(tctx context.Context) (Conn, error) {
+	trace := p.Trace
+	if trace.DialStart != nil {
+		tctx = trace.DialStart(tctx)
+	}
+	start := time.Now()
+	c, err := p.dial(tctx)
+	if trace.DialDone != nil {
+		trace.DialDone(tctx, DialDoneInfo{Duration: time.Since(start), Err: err})
+	}
+	return c, err
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// echoConn replies to every command with the command name.
+type echoConn struct {
+	pending []interface{}
+}
+
+func (c *echoConn) Close() error { return nil }
+func (c *echoConn) Err() error   { return nil }
+func (c *echoConn) Flush() error { return nil }
+
+func (c *echoConn) Send(cmd string, args ...interface{}) error {
+	c.pending = append(c.pending, cmd)
+	return nil
+}
+
+func (c *echoConn) Receive() (interface{}, error) {
+	r := c.pending[0]
+	c.pending = c.pending[1:]
+	return r, nil
+}
+
+func (c *echoConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if cmd == "" {
+		c.pending = nil
+		return nil, nil
+	}
+	return cmd, nil
+}
+
+type traceKey struct{}
+
+type traceRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *traceRecorder) add(ctx context.Context, format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf("%v:", ctx.Value(traceKey{}))+fmt.Sprintf(format, args...))
+}
+
+func (r *traceRecorder) trace() *redis.PoolTrace {
+	return &redis.PoolTrace{
+		GetStart: func(ctx context.Context) context.Context {
+			return context.WithValue(ctx, traceKey{}, "get")
+		},
+		GetDone: func(ctx context.Context, info redis.GetDoneInfo) {
+			r.add(ctx, "GetDone reused=%v err=%v", info.Reused, info.Err)
+		},
+		DialStart: func(ctx context.Context) context.Context {
+			return context.WithValue(ctx, traceKey{}, "dial")
+		},
+		DialDone: func(ctx context.Context, info redis.DialDoneInfo) {
+			r.add(ctx, "DialDone err=%v", info.Err)
+		},
+		CommandStart: func(ctx context.Context, info redis.CommandStartInfo) context.Context {
+			return context.WithValue(ctx, traceKey{}, "cmd")
+		},
+		CommandDone: func(ctx context.Context, info redis.CommandDoneInfo) {
+			r.add(ctx, "%s %s %v", info.Method, info.CommandName, info.Reply)
+		},
+		PutConn: func(ctx context.Context, info redis.PutConnInfo) {
+			r.add(ctx, "PutConn closed=%v", info.Closed)
+		},
+	}
+}
+
+func TestPoolTrace(t *testing.T) {
+	var r traceRecorder
+	p := &redis.Pool{
+		MaxIdle: 1,
+		Dial:    func() (redis.Conn, error) { return &echoConn{}, nil },
+		Trace:   r.trace(),
+	}
+	defer p.Close()
+
+	for i := 0; i < 2; i++ {
+		c := p.Get()
+		c.Do("PING")
+		c.Send("GET", "foo")
+		c.Flush()
+		c.Receive()
+		c.Close()
+	}
+	want := []string{
+		"dial:DialDone err=<nil>",
+		"get:GetDone reused=false err=<nil>",
+		"cmd:Do PING PING",
+		"cmd:Send GET <nil>",
+		"cmd:Flush  <nil>",
+		"cmd:Receive  GET",
+		"get:PutConn closed=false",
+		"get:GetDone reused=true err=<nil>",
+		"cmd:Do PING PING",
+		"cmd:Send GET <nil>",
+		"cmd:Flush  <nil>",
+		"cmd:Receive  GET",
+		"get:PutConn closed=false",
+	}
+	if !reflect.DeepEqual(r.events, want) {
+		t.Fatalf("events =\n%q\nwant\n%q", r.events, want)
+	}
+}
+
+func TestPoolTraceErrors(t *testing.T) {
+	var r traceRecorder
+	dialErr := errors.New("dial error")
+	p := &redis.Pool{
+		MaxActive: 1,
+		Dial:      func() (redis.Conn, error) { return &echoConn{}, nil },
+		Trace:     r.trace(),
+	}
+	defer p.Close()
+
+	c := p.Get()
+	defer c.Close()
+	if _, err := p.Get().Do("PING"); err != redis.ErrPoolExhausted {
+		t.Fatalf("Do on exhausted pool returned %v, want ErrPoolExhausted", err)
+	}
+
+	p2 := &redis.Pool{
+		Dial:  func() (redis.Conn, error) { return nil, dialErr },
+		Trace: r.trace(),
+	}
+	defer p2.Close()
+	ctx := context.WithValue(context.Background(), traceKey{}, "request")
+	p2.Trace.GetStart = nil
+	if _, err := p2.GetContext(ctx); err != dialErr {
+		t.Fatalf("GetContext returned %v, want dial error", err)
+	}
+
+	want := []string{
+		"dial:DialDone err=<nil>",
+		"get:GetDone reused=false err=<nil>",
+		"get:GetDone reused=false err=" + redis.ErrPoolExhausted.Error(),
+		"dial:DialDone err=dial error",
+		"request:GetDone reused=false err=dial error",
+	}
+	if !reflect.DeepEqual(r.events, want) {
+		t.Fatalf("events =\n%q\nwant\n%q", r.events, want)
+	}
+}
+
+func TestPoolTraceDialContext(t *testing.T) {
+	var r traceRecorder
+	var dialValue interface{}
+	p := &redis.Pool{
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			dialValue = ctx.Value(traceKey{})
+			return &echoConn{}, nil
+		},
+		Trace: r.trace(),
+	}
+	defer p.Close()
+
+	c, err := p.GetContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if dialValue != "dial" {
+		t.Fatalf("DialContext got context value %v, want the value added by DialStart", dialValue)
+	}
+}
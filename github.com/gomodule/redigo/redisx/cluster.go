@@ -0,0 +1,711 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// HashSlots is the number of hash slots in a Redis Cluster.
+const HashSlots = 16384
+
+const defaultMaxRedirects = 5
+
+var errClusterClosed = errors.New("redisx: cluster closed")
+
+// Cluster is a client for Redis Cluster. It discovers the slot layout of the
+// cluster with CLUSTER SLOTS, keeps a connection pool per master node and
+// routes every command to the node serving the hash slot of its first key.
+// MOVED and ASK redirections are followed transparently.
+//
+// The key of a command is found with a table of well known commands. Key
+// positions of other commands are fetched from the cluster with COMMAND INFO
+// and cached. Commands without keys are sent to an arbitrary node.
+//
+// Commands that associate server side state with the connection, such as
+// WATCH, MULTI and SUBSCRIBE, are not supported on connections returned by
+// Get. Use ConnForKey to get a connection to the node serving a key for
+// transactions.
+//
+//  cluster := &redisx.Cluster{
+//      StartupNodes: []string{"10.0.0.1:7000", "10.0.0.2:7000"},
+//      DialOptions:  []redis.DialOption{redis.DialConnectTimeout(5 * time.Second)},
+//  }
+//  defer cluster.Close()
+//
+//  c := cluster.Get()
+//  defer c.Close()
+//  v, err := redis.String(c.Do("GET", "greeting"))
+type Cluster struct {
+	// StartupNodes is the list of node addresses used to discover the
+	// cluster layout.
+	StartupNodes []string
+
+	// DialOptions are the options used to dial nodes when CreatePool is nil.
+	DialOptions []redis.DialOption
+
+	// CreatePool creates the connection pool of the node at address addr. If
+	// CreatePool is nil, a pool dialing addr with DialOptions is used.
+	CreatePool func(addr string, options ...redis.DialOption) (*redis.Pool, error)
+
+	// MaxRedirects is the maximum number of MOVED and ASK redirections
+	// followed by a single command. If zero, five redirections are followed.
+	MaxRedirects int
+
+	mu         sync.RWMutex
+	closed     bool
+	refreshing bool
+	pools      map[string]*redis.Pool
+	masters    [HashSlots]string
+	mapped     bool
+	commands   map[string]int // first key positions fetched from the cluster
+}
+
+// Slot returns the hash slot of key. When the key contains a non-empty hash
+// tag, only the tag is hashed so that related keys can be stored in the same
+// slot, e.g. "{user1000}.following" and "{user1000}.followers".
+func Slot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % HashSlots)
+}
+
+// Refresh updates the slot layout of the cluster. Refresh is called on the
+// first command and when a MOVED redirection is received. Applications
+// should call Refresh only to fail fast on a bad configuration.
+func (c *Cluster) Refresh() error {
+	addrs := c.knownAddrs()
+	if len(addrs) == 0 {
+		return errors.New("redisx: no cluster nodes")
+	}
+	var err error
+	for _, addr := range addrs {
+		var masters [HashSlots]string
+		if err = c.fetchSlots(addr, &masters); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		c.masters = masters
+		c.mapped = true
+		c.mu.Unlock()
+		return nil
+	}
+	return err
+}
+
+func (c *Cluster) fetchSlots(addr string, masters *[HashSlots]string) error {
+	conn, err := c.getConn(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ranges, err := redis.Values(conn.Do("CLUSTER", "SLOTS"))
+	if err != nil {
+		return err
+	}
+	for _, r := range ranges {
+		values, err := redis.Values(r, nil)
+		if err != nil {
+			return err
+		}
+		if len(values) < 3 {
+			return fmt.Errorf("redisx: unexpected CLUSTER SLOTS entry %v", values)
+		}
+		start, err := redis.Int(values[0], nil)
+		if err != nil {
+			return err
+		}
+		end, err := redis.Int(values[1], nil)
+		if err != nil {
+			return err
+		}
+		node, err := redis.Values(values[2], nil)
+		if err != nil {
+			return err
+		}
+		if len(node) < 2 || start < 0 || end >= HashSlots || start > end {
+			return fmt.Errorf("redisx: unexpected CLUSTER SLOTS entry %v", values)
+		}
+		host, err := redis.String(node[0], nil)
+		if err != nil {
+			return err
+		}
+		port, err := redis.Int(node[1], nil)
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			// The node does not know its own address, use the one we dialed.
+			host, _, _ = net.SplitHostPort(addr)
+		}
+		master := net.JoinHostPort(host, strconv.Itoa(port))
+		for slot := start; slot <= end; slot++ {
+			masters[slot] = master
+		}
+	}
+	return nil
+}
+
+// knownAddrs returns the addresses of the known masters followed by the
+// startup nodes.
+func (c *Cluster) knownAddrs() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	seen := make(map[string]bool)
+	var addrs []string
+	for _, addr := range c.masters {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, addr := range c.StartupNodes {
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// refreshAsync refreshes the slot layout in the background unless a refresh
+// is already running.
+func (c *Cluster) refreshAsync() {
+	c.mu.Lock()
+	if c.refreshing || c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing = true
+	c.mu.Unlock()
+	go func() {
+		c.Refresh()
+		c.mu.Lock()
+		c.refreshing = false
+		c.mu.Unlock()
+	}()
+}
+
+func (c *Cluster) pool(addr string) (*redis.Pool, error) {
+	c.mu.RLock()
+	p, ok := c.pools[addr]
+	closed := c.closed
+	c.mu.RUnlock()
+	if ok {
+		return p, nil
+	}
+	if closed {
+		return nil, errClusterClosed
+	}
+
+	var err error
+	if c.CreatePool != nil {
+		p, err = c.CreatePool(addr, c.DialOptions...)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p = &redis.Pool{
+			MaxIdle:     3,
+			IdleTimeout: 4 * time.Minute,
+			Dial:        func() (redis.Conn, error) { return redis.Dial("tcp", addr, c.DialOptions...) },
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		p.Close()
+		return nil, errClusterClosed
+	}
+	if existing, ok := c.pools[addr]; ok {
+		// Lost the race with another goroutine.
+		p.Close()
+		return existing, nil
+	}
+	if c.pools == nil {
+		c.pools = make(map[string]*redis.Pool)
+	}
+	c.pools[addr] = p
+	return p, nil
+}
+
+func (c *Cluster) getConn(addr string) (redis.Conn, error) {
+	p, err := c.pool(addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := p.Get()
+	if err := conn.Err(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// addrForSlot returns the address of the master serving slot. If slot is
+// negative, the address of an arbitrary node is returned.
+func (c *Cluster) addrForSlot(slot int) (string, error) {
+	c.mu.RLock()
+	mapped := c.mapped
+	c.mu.RUnlock()
+	if !mapped {
+		if err := c.Refresh(); err != nil {
+			return "", err
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if slot >= 0 {
+		if addr := c.masters[slot]; addr != "" {
+			return addr, nil
+		}
+		return "", fmt.Errorf("redisx: hash slot %d is not served by any node", slot)
+	}
+	for addr := range c.pools {
+		return addr, nil
+	}
+	for _, addr := range c.masters {
+		if addr != "" {
+			return addr, nil
+		}
+	}
+	return "", errors.New("redisx: no cluster nodes")
+}
+
+// slotForCommand returns the hash slot of the first key of the command or -1
+// if the command has no key.
+func (c *Cluster) slotForCommand(cmd string, args []interface{}) (int, error) {
+	pos, err := c.firstKey(cmd)
+	if err != nil {
+		return -1, err
+	}
+	i := -1
+	switch strings.ToUpper(cmd) {
+	case "EVAL", "EVALSHA":
+		if len(args) > 2 {
+			if n, err := strconv.Atoi(keyString(args[1])); err == nil && n > 0 {
+				i = 2
+			}
+		}
+	case "XREAD", "XREADGROUP":
+		for j, arg := range args {
+			if strings.EqualFold(keyString(arg), "STREAMS") {
+				i = j + 1
+				break
+			}
+		}
+	default:
+		if pos > 0 {
+			i = pos - 1
+		}
+	}
+	if i < 0 || i >= len(args) {
+		return -1, nil
+	}
+	return Slot(keyString(args[i])), nil
+}
+
+// firstKey returns the position of the first key of cmd.
+func (c *Cluster) firstKey(cmd string) (int, error) {
+	if ci, ok := lookupCommandInfoOK(cmd); ok {
+		return ci.firstKey, nil
+	}
+	name := strings.ToUpper(cmd)
+	c.mu.RLock()
+	pos, ok := c.commands[name]
+	c.mu.RUnlock()
+	if ok {
+		return pos, nil
+	}
+
+	addr, err := c.addrForSlot(-1)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := c.getConn(addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	infos, err := redis.Values(conn.Do("COMMAND", "INFO", name))
+	if err != nil {
+		return 0, err
+	}
+	// The reply is [name, arity, flags, first key, last key, step] or nil
+	// for unknown commands.
+	if len(infos) == 1 {
+		if info, _ := redis.Values(infos[0], nil); len(info) >= 4 {
+			pos, _ = redis.Int(info[3], nil)
+		}
+	}
+	c.mu.Lock()
+	if c.commands == nil {
+		c.commands = make(map[string]int)
+	}
+	c.commands[name] = pos
+	c.mu.Unlock()
+	return pos, nil
+}
+
+// do executes the command on the node at addr, preceded by ASKING if
+// asking is set, and follows redirections.
+func (c *Cluster) do(addr string, asking bool, cmd string, args []interface{}) (interface{}, error) {
+	maxRedirects := c.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	for i := 0; ; i++ {
+		conn, err := c.getConn(addr)
+		if err != nil {
+			return nil, err
+		}
+		if asking {
+			conn.Send("ASKING")
+		}
+		reply, err := conn.Do(cmd, args...)
+		conn.Close()
+
+		r := parseRedirect(err)
+		if r == nil || i >= maxRedirects {
+			return reply, err
+		}
+		c.redirected(r)
+		addr, asking = r.addr, r.ask
+	}
+}
+
+// redirected updates the slot table for a MOVED redirection. ASK
+// redirections only apply to the redirected command.
+func (c *Cluster) redirected(r *redirect) {
+	if r.ask {
+		return
+	}
+	c.mu.Lock()
+	c.masters[r.slot] = r.addr
+	c.mu.Unlock()
+	c.refreshAsync()
+}
+
+// ConnForKey returns a connection to the master serving the hash slot of
+// key. The connection is not redirected, use it for transactions or other
+// commands that must run on a single connection. The application must close
+// the returned connection.
+func (c *Cluster) ConnForKey(key string) (redis.Conn, error) {
+	addr, err := c.addrForSlot(Slot(key))
+	if err != nil {
+		return nil, err
+	}
+	return c.getConn(addr)
+}
+
+// Get returns a connection to the cluster. Commands are routed by key and
+// pipelined commands are split by node; replies are received in the order
+// the commands were sent. The application must close the returned
+// connection.
+func (c *Cluster) Get() redis.Conn {
+	return &clusterConn{c: c}
+}
+
+// Close releases the resources used by the cluster.
+func (c *Cluster) Close() error {
+	c.mu.Lock()
+	pools := c.pools
+	c.pools = nil
+	c.closed = true
+	c.mu.Unlock()
+
+	var err error
+	for _, p := range pools {
+		if e := p.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+type clusterCommand struct {
+	cmd  string
+	args []interface{}
+}
+
+type clusterReply struct {
+	reply interface{}
+	err   error
+}
+
+type clusterConn struct {
+	c       *Cluster
+	err     error
+	pending []clusterCommand
+	replies []clusterReply
+}
+
+func (cc *clusterConn) Close() error {
+	if cc.err == nil {
+		cc.err = errors.New("redisx: closed")
+	}
+	cc.pending = nil
+	cc.replies = nil
+	return nil
+}
+
+func (cc *clusterConn) Err() error {
+	return cc.err
+}
+
+func (cc *clusterConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if cc.err != nil {
+		return nil, cc.err
+	}
+	if lookupCommandInfo(cmd).notMuxable {
+		return nil, fmt.Errorf("redisx: %s not supported by cluster connection", cmd)
+	}
+
+	if cmd == "" {
+		if err := cc.Flush(); err != nil {
+			return nil, err
+		}
+		if len(cc.replies) == 0 {
+			return nil, nil
+		}
+		reply := make([]interface{}, len(cc.replies))
+		for i, r := range cc.replies {
+			reply[i] = r.reply
+			if r.err != nil {
+				reply[i] = r.err
+			}
+		}
+		cc.replies = nil
+		return reply, nil
+	}
+
+	// Like redis.Conn, Do receives pending replies and returns the first
+	// error.
+	if err := cc.Flush(); err != nil {
+		return nil, err
+	}
+	var err error
+	for _, r := range cc.replies {
+		if r.err != nil {
+			err = r.err
+			break
+		}
+	}
+	cc.replies = nil
+
+	slot, e := cc.c.slotForCommand(cmd, args)
+	if e != nil {
+		return nil, e
+	}
+	addr, e := cc.c.addrForSlot(slot)
+	if e != nil {
+		return nil, e
+	}
+	reply, e := cc.c.do(addr, false, cmd, args)
+	if err == nil {
+		err = e
+	}
+	return reply, err
+}
+
+func (cc *clusterConn) Send(cmd string, args ...interface{}) error {
+	if cc.err != nil {
+		return cc.err
+	}
+	if lookupCommandInfo(cmd).notMuxable {
+		return fmt.Errorf("redisx: %s not supported by cluster connection", cmd)
+	}
+	cc.pending = append(cc.pending, clusterCommand{cmd: cmd, args: args})
+	return nil
+}
+
+// Flush sends the pending commands. The commands are grouped by node and
+// each group is written to its node as a single pipeline.
+func (cc *clusterConn) Flush() error {
+	if cc.err != nil {
+		return cc.err
+	}
+	if len(cc.pending) == 0 {
+		return nil
+	}
+	pending := cc.pending
+	cc.pending = nil
+
+	replies := make([]clusterReply, len(pending))
+	type batch struct {
+		conn    redis.Conn
+		indexes []int
+	}
+	var order []string
+	batches := make(map[string]*batch)
+	for i, pc := range pending {
+		slot, err := cc.c.slotForCommand(pc.cmd, pc.args)
+		if err != nil {
+			replies[i].err = err
+			continue
+		}
+		addr, err := cc.c.addrForSlot(slot)
+		if err != nil {
+			replies[i].err = err
+			continue
+		}
+		b, ok := batches[addr]
+		if !ok {
+			b = &batch{}
+			batches[addr] = b
+			order = append(order, addr)
+		}
+		b.indexes = append(b.indexes, i)
+	}
+
+	// Write to all nodes before reading any reply.
+	for _, addr := range order {
+		b := batches[addr]
+		conn, err := cc.c.getConn(addr)
+		if err == nil {
+			for _, i := range b.indexes {
+				if err = conn.Send(pending[i].cmd, pending[i].args...); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = conn.Flush()
+			}
+			if err != nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			for _, i := range b.indexes {
+				replies[i].err = err
+			}
+			continue
+		}
+		b.conn = conn
+	}
+
+	for _, addr := range order {
+		b := batches[addr]
+		if b.conn == nil {
+			continue
+		}
+		for _, i := range b.indexes {
+			replies[i].reply, replies[i].err = b.conn.Receive()
+		}
+		b.conn.Close()
+	}
+
+	for i, r := range replies {
+		if redirect := parseRedirect(r.err); redirect != nil {
+			cc.c.redirected(redirect)
+			replies[i].reply, replies[i].err = cc.c.do(redirect.addr, redirect.ask, pending[i].cmd, pending[i].args)
+		}
+	}
+	cc.replies = append(cc.replies, replies...)
+	return nil
+}
+
+func (cc *clusterConn) Receive() (interface{}, error) {
+	if cc.err != nil {
+		return nil, cc.err
+	}
+	if len(cc.replies) == 0 && len(cc.pending) > 0 {
+		if err := cc.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	if len(cc.replies) == 0 {
+		return nil, errors.New("redisx: no pending replies")
+	}
+	r := cc.replies[0]
+	cc.replies = cc.replies[1:]
+	return r.reply, r.err
+}
+
+type redirect struct {
+	ask  bool
+	slot int
+	addr string
+}
+
+// parseRedirect parses MOVED and ASK errors of the form "MOVED 3999
+// 127.0.0.1:6381". It returns nil if err is not a redirection.
+func parseRedirect(err error) *redirect {
+	e, ok := err.(redis.Error)
+	if !ok {
+		return nil
+	}
+	fields := strings.Fields(string(e))
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return nil
+	}
+	slot, err := strconv.Atoi(fields[1])
+	if err != nil || slot < 0 || slot >= HashSlots {
+		return nil
+	}
+	return &redirect{ask: fields[0] == "ASK", slot: slot, addr: fields[2]}
+}
+
+func keyString(arg interface{}) string {
+	switch arg := arg.(type) {
+	case string:
+		return arg
+	case []byte:
+		return string(arg)
+	case redis.Argument:
+		return keyString(arg.RedisArg())
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(arg)
+	}
+}
+
+// crc16 implements the CRC16-CCITT (XModem) checksum used by Redis Cluster.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^s[i]]
+	}
+	return crc
+}
+
+var crc16Table = func() (t [256]uint16) {
+	for i := range t {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}()
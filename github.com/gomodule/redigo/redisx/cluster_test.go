@@ -0,0 +1,421 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/gomodule/redigo/redisx"
+)
+
+var slotTests = []struct {
+	key  string
+	slot int
+}{
+	{"123456789", 0x31C3},
+	{"foo", 12182},
+	{"bar", 5061},
+	{"{user1000}.following", redisx.Slot("user1000")},
+	{"foo{}{bar}", 8363},
+	{"foo{{bar}}zap", redisx.Slot("{bar")},
+	{"foo{bar}{zap}", redisx.Slot("bar")},
+}
+
+func TestSlot(t *testing.T) {
+	for _, tt := range slotTests {
+		if slot := redisx.Slot(tt.key); slot != tt.slot {
+			t.Errorf("Slot(%q) = %d, want %d", tt.key, slot, tt.slot)
+		}
+	}
+}
+
+// fakeCluster simulates a two node cluster. Node "a:1" serves the slots
+// below 8192 and node "b:1" serves the others unless overridden by moved.
+type fakeCluster struct {
+	mu        sync.Mutex
+	data      map[string]map[string]string
+	moved     map[int]string // slot -> addr
+	migrating map[int]string // slot -> target addr
+	commands  []string       // "addr CMD" for every command executed
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{
+		data:      map[string]map[string]string{"a:1": {}, "b:1": {}},
+		moved:     make(map[int]string),
+		migrating: make(map[int]string),
+	}
+}
+
+func (fc *fakeCluster) owner(slot int) string {
+	if addr, ok := fc.moved[slot]; ok {
+		return addr
+	}
+	if slot < 8192 {
+		return "a:1"
+	}
+	return "b:1"
+}
+
+func (fc *fakeCluster) createPool(addr string, options ...redis.DialOption) (*redis.Pool, error) {
+	if _, ok := fc.data[addr]; !ok {
+		return nil, fmt.Errorf("unknown node %s", addr)
+	}
+	return &redis.Pool{
+		MaxIdle: 1,
+		Dial:    func() (redis.Conn, error) { return &fakeNodeConn{fc: fc, addr: addr}, nil },
+	}, nil
+}
+
+func (fc *fakeCluster) log() []string {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	log := fc.commands
+	fc.commands = nil
+	return log
+}
+
+func (fc *fakeCluster) exec(addr string, asking bool, cmd string, args []interface{}) interface{} {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	cmd = strings.ToUpper(cmd)
+	fc.commands = append(fc.commands, addr+" "+cmd)
+	switch cmd {
+	case "CLUSTER":
+		var slots []interface{}
+		start := 0
+		for slot := 1; slot <= redisx.HashSlots; slot++ {
+			if slot == redisx.HashSlots || fc.owner(slot) != fc.owner(start) {
+				host := strings.Split(fc.owner(start), ":")[0]
+				slots = append(slots, []interface{}{int64(start), int64(slot - 1), []interface{}{[]byte(host), int64(1)}})
+				start = slot
+			}
+		}
+		return slots
+	case "COMMAND":
+		if fmt.Sprint(args[1]) == "GETDEL" {
+			return []interface{}{[]interface{}{[]byte("getdel"), int64(2), nil, int64(1), int64(1), int64(1)}}
+		}
+		return []interface{}{[]interface{}{[]byte(strings.ToLower(fmt.Sprint(args[1]))), int64(-1), nil, int64(0), int64(0), int64(0)}}
+	case "PING":
+		return "PONG"
+	}
+
+	key := fmt.Sprint(args[0])
+	slot := redisx.Slot(key)
+	if owner := fc.owner(slot); owner != addr && !(asking && fc.migrating[slot] == addr) {
+		return redis.Error(fmt.Sprintf("MOVED %d %s", slot, owner))
+	}
+	data := fc.data[addr]
+	if target, ok := fc.migrating[slot]; ok && target != addr {
+		if _, ok := data[key]; !ok {
+			return redis.Error(fmt.Sprintf("ASK %d %s", slot, target))
+		}
+	}
+	switch cmd {
+	case "GET":
+		if v, ok := data[key]; ok {
+			return []byte(v)
+		}
+		return nil
+	case "SET":
+		data[key] = fmt.Sprint(args[1])
+		return "OK"
+	case "GETDEL":
+		v, ok := data[key]
+		if !ok {
+			return nil
+		}
+		delete(data, key)
+		return []byte(v)
+	}
+	return redis.Error("ERR unknown command " + cmd)
+}
+
+type fakeNodeConn struct {
+	fc      *fakeCluster
+	addr    string
+	asking  bool
+	replies []interface{}
+}
+
+func (c *fakeNodeConn) Close() error { return nil }
+func (c *fakeNodeConn) Err() error   { return nil }
+func (c *fakeNodeConn) Flush() error { return nil }
+
+func (c *fakeNodeConn) Send(cmd string, args ...interface{}) error {
+	if strings.ToUpper(cmd) == "ASKING" {
+		c.asking = true
+		c.replies = append(c.replies, "OK")
+		return nil
+	}
+	c.replies = append(c.replies, c.fc.exec(c.addr, c.asking, cmd, args))
+	c.asking = false
+	return nil
+}
+
+func (c *fakeNodeConn) Receive() (interface{}, error) {
+	if len(c.replies) == 0 {
+		return nil, errors.New("no reply")
+	}
+	r := c.replies[0]
+	c.replies = c.replies[1:]
+	if err, ok := r.(redis.Error); ok {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (c *fakeNodeConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if cmd != "" {
+		c.Send(cmd, args...)
+	}
+	var reply interface{}
+	var err error
+	for len(c.replies) > 0 {
+		var e error
+		reply, e = c.Receive()
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+	return reply, err
+}
+
+func newTestCluster(fc *fakeCluster) *redisx.Cluster {
+	return &redisx.Cluster{StartupNodes: []string{"a:1"}, CreatePool: fc.createPool}
+}
+
+func TestClusterRouting(t *testing.T) {
+	fc := newFakeCluster()
+	cluster := newTestCluster(fc)
+	defer cluster.Close()
+
+	c := cluster.Get()
+	defer c.Close()
+	for _, key := range []string{"bar", "foo"} {
+		if _, err := c.Do("SET", key, key+"-value"); err != nil {
+			t.Fatalf("SET %s returned %v", key, err)
+		}
+	}
+	if fc.data["a:1"]["bar"] != "bar-value" || fc.data["b:1"]["foo"] != "foo-value" {
+		t.Fatalf("keys stored on wrong nodes: %v", fc.data)
+	}
+	v, err := redis.String(c.Do("GET", "foo"))
+	if err != nil || v != "foo-value" {
+		t.Fatalf("GET foo = %q, %v, want %q", v, err, "foo-value")
+	}
+
+	// Key position fetched with COMMAND INFO.
+	fc.log()
+	v, err = redis.String(c.Do("GETDEL", "foo"))
+	if err != nil || v != "foo-value" {
+		t.Fatalf("GETDEL foo = %q, %v, want %q", v, err, "foo-value")
+	}
+	if log := fc.log(); len(log) != 2 || !strings.HasSuffix(log[0], " COMMAND") || log[1] != "b:1 GETDEL" {
+		t.Fatalf("commands = %v, want COMMAND INFO and GETDEL on b:1", log)
+	}
+	if _, err := c.Do("GETDEL", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if log := fc.log(); len(log) != 1 {
+		t.Fatalf("commands = %v, want command info to be cached", log)
+	}
+
+	if _, err := c.Do("MULTI"); err == nil {
+		t.Fatal("MULTI did not return an error")
+	}
+}
+
+func TestClusterMoved(t *testing.T) {
+	fc := newFakeCluster()
+	cluster := newTestCluster(fc)
+	defer cluster.Close()
+	if err := cluster.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	fc.mu.Lock()
+	fc.moved[redisx.Slot("foo")] = "a:1"
+	fc.data["a:1"]["foo"] = "moved"
+	fc.mu.Unlock()
+
+	c := cluster.Get()
+	defer c.Close()
+	fc.log()
+	v, err := redis.String(c.Do("GET", "foo"))
+	if err != nil || v != "moved" {
+		t.Fatalf("GET foo = %q, %v, want %q", v, err, "moved")
+	}
+	log := fc.log()
+	if len(log) < 2 || log[0] != "b:1 GET" || log[1] != "a:1 GET" {
+		t.Fatalf("commands = %v, want GET redirected from b:1 to a:1", log)
+	}
+
+	// The slot table is updated by the redirection.
+	if _, err := c.Do("GET", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range fc.log() {
+		if cmd == "b:1 GET" {
+			t.Fatal("GET sent to old owner after MOVED")
+		}
+	}
+}
+
+func TestClusterAsk(t *testing.T) {
+	fc := newFakeCluster()
+	cluster := newTestCluster(fc)
+	defer cluster.Close()
+
+	c := cluster.Get()
+	defer c.Close()
+	if _, err := c.Do("SET", "foo", "old"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Slot of foo is migrating from b:1 to a:1 and key baz{foo} has already moved.
+	fc.mu.Lock()
+	fc.migrating[redisx.Slot("foo")] = "a:1"
+	fc.data["a:1"]["baz{foo}"] = "migrated"
+	fc.mu.Unlock()
+	fc.log()
+
+	v, err := redis.String(c.Do("GET", "baz{foo}"))
+	if err != nil || v != "migrated" {
+		t.Fatalf("GET baz{foo} = %q, %v, want %q", v, err, "migrated")
+	}
+	v, err = redis.String(c.Do("GET", "foo"))
+	if err != nil || v != "old" {
+		t.Fatalf("GET foo = %q, %v, want %q", v, err, "old")
+	}
+	want := []string{"b:1 GET", "a:1 GET", "b:1 GET"}
+	if log := fc.log(); strings.Join(log, ",") != strings.Join(want, ",") {
+		t.Fatalf("commands = %v, want %v", log, want)
+	}
+}
+
+func TestClusterPipeline(t *testing.T) {
+	fc := newFakeCluster()
+	cluster := newTestCluster(fc)
+	defer cluster.Close()
+	if err := cluster.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	fc.log()
+
+	c := cluster.Get()
+	defer c.Close()
+	keys := []string{"foo", "bar", "{foo}2", "{bar}2"}
+	for _, key := range keys {
+		c.Send("SET", key, key)
+	}
+	for _, key := range keys {
+		c.Send("GET", key)
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for range keys {
+		if _, err := c.Receive(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range keys {
+		v, err := redis.String(c.Receive())
+		if err != nil || v != key {
+			t.Fatalf("Receive() = %q, %v, want %q", v, err, key)
+		}
+	}
+
+	// Each node received its commands in a single batch.
+	log := fc.log()
+	if len(log) != 8 {
+		t.Fatalf("commands = %v, want 8 commands", log)
+	}
+	for i, cmd := range log {
+		want := "b:1"
+		if i >= 4 {
+			want = "a:1"
+		}
+		if !strings.HasPrefix(cmd, want) {
+			t.Fatalf("commands = %v, want b:1 batch then a:1 batch", log)
+		}
+	}
+
+	c.Send("GET", "foo")
+	c.Send("GET", "bar")
+	values, err := redis.Strings(c.Do(""))
+	if err != nil || len(values) != 2 || values[0] != "foo" || values[1] != "bar" {
+		t.Fatalf("Do(\"\") = %v, %v, want [foo bar]", values, err)
+	}
+}
+
+func TestClusterPipelineRedirect(t *testing.T) {
+	fc := newFakeCluster()
+	cluster := newTestCluster(fc)
+	defer cluster.Close()
+	if err := cluster.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Slot of foo moved from b:1 to a:1, and slot of bar is migrating from
+	// a:1 to b:1 where key baz{bar} has already moved.
+	fc.mu.Lock()
+	fc.moved[redisx.Slot("foo")] = "a:1"
+	fc.data["a:1"]["foo"] = "moved"
+	fc.migrating[redisx.Slot("bar")] = "b:1"
+	fc.data["b:1"]["baz{bar}"] = "migrated"
+	fc.mu.Unlock()
+	fc.log()
+
+	gets := func() []string {
+		var gets []string
+		for _, cmd := range fc.log() {
+			if strings.HasSuffix(cmd, " GET") {
+				gets = append(gets, cmd)
+			}
+		}
+		return gets
+	}
+
+	c := cluster.Get()
+	defer c.Close()
+	c.Send("GET", "foo")
+	c.Send("GET", "baz{bar}")
+	values, err := redis.Strings(c.Do(""))
+	if err != nil || len(values) != 2 || values[0] != "moved" || values[1] != "migrated" {
+		t.Fatalf("Do(\"\") = %v, %v, want [moved migrated]", values, err)
+	}
+	// The ASK redirection is followed with ASKING, without bouncing back.
+	want := []string{"b:1 GET", "a:1 GET", "a:1 GET", "b:1 GET"}
+	if log := gets(); strings.Join(log, ",") != strings.Join(want, ",") {
+		t.Fatalf("commands = %v, want %v", log, want)
+	}
+
+	// The slot table is updated by the MOVED redirection.
+	c.Send("GET", "foo")
+	if _, err := c.Do(""); err != nil {
+		t.Fatal(err)
+	}
+	if log := gets(); len(log) != 1 || log[0] != "a:1 GET" {
+		t.Fatalf("commands = %v, want GET sent to the new owner", log)
+	}
+}
@@ -27,6 +27,11 @@ const (
 
 type commandInfo struct {
 	notMuxable bool
+
+	// firstKey is the position of the first key in the command, counting the
+	// command name as position zero. Zero means that the command has no key
+	// or that the key position depends on the arguments.
+	firstKey int
 }
 
 var commandInfos = map[string]commandInfo{
@@ -40,15 +45,48 @@ var commandInfos = map[string]commandInfo{
 	"MONITOR":    {notMuxable: true},
 }
 
+// keyCommands lists the commands routed by key, indexed by the position of
+// the first key.
+var keyCommands = [...]string{
+	1: "APPEND BITCOUNT BITFIELD BITPOS BLPOP BRPOP BRPOPLPUSH BZPOPMAX BZPOPMIN " +
+		"DECR DECRBY DEL DUMP EXISTS EXPIRE EXPIREAT GEOADD GEODIST GEOHASH GEOPOS " +
+		"GEORADIUS GEORADIUSBYMEMBER GET GETBIT GETRANGE GETSET HDEL HEXISTS HGET " +
+		"HGETALL HINCRBY HINCRBYFLOAT HKEYS HLEN HMGET HMSET HSCAN HSET HSETNX HSTRLEN " +
+		"HVALS INCR INCRBY INCRBYFLOAT LINDEX LINSERT LLEN LPOP LPUSH LPUSHX LRANGE " +
+		"LREM LSET LTRIM MGET MSET MSETNX PERSIST PEXPIRE PEXPIREAT PFADD PFCOUNT " +
+		"PFMERGE PSETEX PTTL RENAME RENAMENX RESTORE RPOP RPOPLPUSH RPUSH RPUSHX SADD " +
+		"SCARD SDIFF SDIFFSTORE SET SETBIT SETEX SETNX SETRANGE SINTER SINTERSTORE " +
+		"SISMEMBER SMEMBERS SMOVE SORT SPOP SRANDMEMBER SREM SSCAN STRLEN SUNION " +
+		"SUNIONSTORE TOUCH TTL TYPE UNLINK WATCH XACK XADD XCLAIM XDEL XLEN XPENDING " +
+		"XRANGE XREVRANGE XTRIM ZADD ZCARD ZCOUNT ZINCRBY ZINTERSTORE ZLEXCOUNT " +
+		"ZPOPMAX ZPOPMIN ZRANGE ZRANGEBYLEX ZRANGEBYSCORE ZRANK ZREM ZREMRANGEBYLEX " +
+		"ZREMRANGEBYRANK ZREMRANGEBYSCORE ZREVRANGE ZREVRANGEBYLEX ZREVRANGEBYSCORE " +
+		"ZREVRANK ZSCAN ZSCORE ZUNIONSTORE",
+	2: "OBJECT XGROUP XINFO",
+}
+
 func init() {
+	for i, names := range keyCommands {
+		for _, n := range strings.Fields(names) {
+			ci := commandInfos[n]
+			ci.firstKey = i
+			commandInfos[n] = ci
+		}
+	}
 	for n, ci := range commandInfos {
 		commandInfos[strings.ToLower(n)] = ci
 	}
 }
 
 func lookupCommandInfo(commandName string) commandInfo {
+	ci, _ := lookupCommandInfoOK(commandName)
+	return ci
+}
+
+func lookupCommandInfoOK(commandName string) (commandInfo, bool) {
 	if ci, ok := commandInfos[commandName]; ok {
-		return ci
+		return ci, true
 	}
-	return commandInfos[strings.ToUpper(commandName)]
+	ci, ok := commandInfos[strings.ToUpper(commandName)]
+	return ci, ok
 }
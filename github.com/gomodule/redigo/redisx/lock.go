@@ -0,0 +1,251 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+var (
+	// ErrNotObtained is returned when a lock is held by someone else.
+	ErrNotObtained = errors.New("redisx: lock not obtained")
+
+	// ErrLockNotHeld is returned when releasing or extending a lock that
+	// expired or was obtained by someone else.
+	ErrLockNotHeld = errors.New("redisx: lock not held")
+
+	errShortTTL = errors.New("redisx: lock ttl shorter than one millisecond")
+)
+
+var (
+	releaseScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	extendScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// Locker obtains locks on keys. With a single pool, a lock is a key set with
+// SET NX PX to a random token. With more than one pool, the Redlock algorithm
+// is used: a lock is obtained when it is set on a majority of the
+// independent servers the pools connect to.
+//
+//  locker := &redisx.Locker{Pools: []*redis.Pool{pool}}
+//  lock, err := locker.Acquire(ctx, "report:42", 10*time.Second)
+//  if err != nil {
+//      return err
+//  }
+//  defer lock.Release()
+type Locker struct {
+	// Pools are the pools of the servers holding the locks.
+	Pools []*redis.Pool
+
+	// RetryDelay is the initial delay between attempts of Acquire. The delay
+	// doubles after each attempt up to MaxRetryDelay. If zero, the initial
+	// delay is 50 milliseconds.
+	RetryDelay time.Duration
+
+	// MaxRetryDelay is the maximum delay between attempts of Acquire. If
+	// zero, the maximum delay is one second.
+	MaxRetryDelay time.Duration
+
+	// DriftFactor is the fraction of the lock time to live subtracted from
+	// the validity of a Redlock to account for clock drift between servers.
+	// If zero, 0.01 is used.
+	DriftFactor float64
+}
+
+// Lock is a lock obtained by a Locker.
+type Lock struct {
+	locker *Locker
+	key    string
+	token  string
+	until  time.Time
+}
+
+// Key returns the locked key.
+func (lk *Lock) Key() string { return lk.key }
+
+// Token returns the random value identifying the owner of the lock.
+func (lk *Lock) Token() string { return lk.token }
+
+// Until returns the time until which the lock is valid. Work protected by
+// the lock should be completed or the lock extended before this time.
+func (lk *Lock) Until() time.Time { return lk.until }
+
+// Obtain tries once to lock key for ttl, which must be at least one
+// millisecond. It returns ErrNotObtained if the lock is held by someone else.
+func (l *Locker) Obtain(key string, ttl time.Duration) (*Lock, error) {
+	if len(l.Pools) == 0 {
+		return nil, errors.New("redisx: Locker has no pools")
+	}
+	if ttl < time.Millisecond {
+		return nil, errShortTTL
+	}
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	lk := &Lock{locker: l, key: key, token: token}
+	ms := int64(ttl / time.Millisecond)
+
+	start := time.Now()
+	n, err := l.each(func(c redis.Conn) (bool, error) {
+		_, err := redis.String(c.Do("SET", key, token, "NX", "PX", ms))
+		if err == redis.ErrNil {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	validity := ttl - time.Since(start) - l.drift(ttl)
+	if n >= l.quorum() && validity > 0 {
+		lk.until = start.Add(validity)
+		return lk, nil
+	}
+
+	// Undo partial locks.
+	lk.release()
+	if err != nil && len(l.Pools) == 1 {
+		return nil, err
+	}
+	return nil, ErrNotObtained
+}
+
+// Acquire locks key for ttl, retrying with exponential backoff while the
+// lock is held by someone else. Acquire returns the context error if ctx is
+// done before the lock is obtained.
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	delay := l.RetryDelay
+	if delay <= 0 {
+		delay = 50 * time.Millisecond
+	}
+	maxDelay := l.MaxRetryDelay
+	if maxDelay <= 0 {
+		maxDelay = time.Second
+	}
+	for {
+		lk, err := l.Obtain(key, ttl)
+		if err != ErrNotObtained {
+			return lk, err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// Release releases the lock. It returns ErrLockNotHeld if the lock expired.
+func (lk *Lock) Release() error {
+	n, err := lk.release()
+	if err != nil && len(lk.locker.Pools) == 1 {
+		return err
+	}
+	if n < lk.locker.quorum() {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (lk *Lock) release() (int, error) {
+	return lk.locker.each(func(c redis.Conn) (bool, error) {
+		n, err := redis.Int(releaseScript.Do(c, lk.key, lk.token))
+		return n == 1, err
+	})
+}
+
+// Extend resets the time to live of the lock to ttl, which must be at least
+// one millisecond. It returns ErrLockNotHeld if the lock expired.
+func (lk *Lock) Extend(ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return errShortTTL
+	}
+	l := lk.locker
+	start := time.Now()
+	n, err := l.each(func(c redis.Conn) (bool, error) {
+		n, err := redis.Int(extendScript.Do(c, lk.key, lk.token, int64(ttl/time.Millisecond)))
+		return n == 1, err
+	})
+	if err != nil && len(l.Pools) == 1 {
+		return err
+	}
+	validity := ttl - time.Since(start) - l.drift(ttl)
+	if n < l.quorum() || validity <= 0 {
+		return ErrLockNotHeld
+	}
+	lk.until = start.Add(validity)
+	return nil
+}
+
+// each runs fn on a connection of every pool and returns the number of pools
+// for which fn returned true and the last error.
+func (l *Locker) each(fn func(c redis.Conn) (bool, error)) (int, error) {
+	var n int
+	var err error
+	for _, p := range l.Pools {
+		c := p.Get()
+		ok, e := fn(c)
+		c.Close()
+		if ok {
+			n++
+		}
+		if e != nil {
+			err = e
+		}
+	}
+	return n, err
+}
+
+func (l *Locker) quorum() int {
+	return len(l.Pools)/2 + 1
+}
+
+func (l *Locker) drift(ttl time.Duration) time.Duration {
+	if len(l.Pools) == 1 {
+		return 0
+	}
+	factor := l.DriftFactor
+	if factor <= 0 {
+		factor = 0.01
+	}
+	// Add two milliseconds for the precision of the expiration on the server.
+	return time.Duration(float64(ttl)*factor) + 2*time.Millisecond
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/gomodule/redigo/redisx"
+)
+
+func newTestPool(t *testing.T) *redis.Pool {
+	c, err := redisx.DialTest()
+	if err != nil {
+		t.Fatalf("error connection to database, %v", err)
+	}
+	return &redis.Pool{
+		MaxIdle:   1,
+		MaxActive: 1,
+		Wait:      true,
+		Dial:      func() (redis.Conn, error) { return c, nil },
+	}
+}
+
+func TestLock(t *testing.T) {
+	p := newTestPool(t)
+	defer p.Close()
+	locker := &redisx.Locker{Pools: []*redis.Pool{p}, RetryDelay: 10 * time.Millisecond}
+
+	lock, err := locker.Obtain("lock", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locker.Obtain("lock", time.Second); err != redisx.ErrNotObtained {
+		t.Fatalf("second Obtain returned %v, want ErrNotObtained", err)
+	}
+
+	if err := lock.Extend(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	c := p.Get()
+	ttl, err := redis.Int(c.Do("PTTL", "lock"))
+	c.Close()
+	if err != nil || ttl <= int(time.Second/time.Millisecond) {
+		t.Fatalf("PTTL after Extend = %d, %v, want more than one second", ttl, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := locker.Acquire(ctx, "lock", time.Second); err != context.DeadlineExceeded {
+		t.Fatalf("Acquire of held lock returned %v, want context.DeadlineExceeded", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Release(); err != redisx.ErrLockNotHeld {
+		t.Fatalf("second Release returned %v, want ErrLockNotHeld", err)
+	}
+	if err := lock.Extend(time.Second); err != redisx.ErrLockNotHeld {
+		t.Fatalf("Extend of released lock returned %v, want ErrLockNotHeld", err)
+	}
+
+	lock, err = locker.Acquire(context.Background(), "lock", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lock expires and is acquired by someone else.
+	time.Sleep(100 * time.Millisecond)
+	other, err := locker.Acquire(context.Background(), "lock", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Release(); err != redisx.ErrLockNotHeld {
+		t.Fatalf("Release of expired lock returned %v, want ErrLockNotHeld", err)
+	}
+	if err := other.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockShortTTL(t *testing.T) {
+	dialed := false
+	p := &redis.Pool{Dial: func() (redis.Conn, error) {
+		dialed = true
+		return nil, errors.New("unexpected dial")
+	}}
+	locker := &redisx.Locker{Pools: []*redis.Pool{p}}
+
+	if _, err := locker.Obtain("lock", time.Microsecond); err == nil || err == redisx.ErrNotObtained {
+		t.Fatalf("Obtain with a ttl under one millisecond returned %v, want an error", err)
+	}
+	if _, err := locker.Acquire(context.Background(), "lock", 0); err == nil || err == redisx.ErrNotObtained {
+		t.Fatalf("Acquire with a zero ttl returned %v, want an error", err)
+	}
+	if dialed {
+		t.Fatal("short ttl sent to the server")
+	}
+}
+
+func TestRedlock(t *testing.T) {
+	p := newTestPool(t)
+	defer p.Close()
+
+	// All pools share one server, only the first SET succeeds so a majority
+	// is never reached.
+	locker := &redisx.Locker{Pools: []*redis.Pool{p, p, p}}
+	if _, err := locker.Obtain("lock", time.Second); err != redisx.ErrNotObtained {
+		t.Fatalf("Obtain returned %v, want ErrNotObtained", err)
+	}
+	c := p.Get()
+	n, err := redis.Int(c.Do("EXISTS", "lock"))
+	c.Close()
+	if err != nil || n != 0 {
+		t.Fatalf("EXISTS lock = %d, %v, want partial lock to be released", n, err)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// The scripts read the clock of the server so that the limits do not depend
+// on the clocks of the clients. Times are in microseconds. Calling
+// redis.replicate_commands allows writes after the non deterministic TIME
+// command on servers before Redis 5.
+
+var slidingWindowScript = redis.NewScript(1, `
+if redis.replicate_commands then redis.replicate_commands() end
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local member = ARGV[4]
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+local count = redis.call("ZCARD", key)
+
+local allowed = 0
+local retry = -1
+if count + n <= limit then
+	for i = 1, n do
+		redis.call("ZADD", key, now, member .. ":" .. i)
+	end
+	count = count + n
+	allowed = n
+elseif n <= limit then
+	-- Wait until enough of the oldest requests leave the window.
+	local i = count + n - limit - 1
+	local e = redis.call("ZRANGE", key, i, i, "WITHSCORES")
+	retry = tonumber(e[2]) + window - now
+end
+
+local reset = 0
+local newest = redis.call("ZRANGE", key, -1, -1, "WITHSCORES")
+if #newest > 0 then
+	reset = tonumber(newest[2]) + window - now
+	redis.call("PEXPIRE", key, math.ceil(reset / 1000))
+end
+return {allowed, limit - count, retry, reset}`)
+
+var gcraScript = redis.NewScript(1, `
+if redis.replicate_commands then redis.replicate_commands() end
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+local interval = period / rate
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+-- tat is the theoretical arrival time of the next request.
+local tat = tonumber(redis.call("GET", key))
+if not tat or tat < now then
+	tat = now
+end
+local newTat = tat + n * interval
+local diff = now - (newTat - burst * interval)
+local remaining = math.floor(diff / interval)
+
+if remaining < 0 then
+	local retry = -1
+	if n <= burst then
+		retry = math.ceil(-diff)
+	end
+	return {0, math.floor((now - (tat - burst * interval)) / interval), retry, math.ceil(tat - now)}
+end
+
+local reset = newTat - now
+if reset > 0 then
+	redis.call("SET", key, string.format("%.0f", newTat), "PX", math.ceil(reset / 1000))
+end
+return {n, remaining, -1, math.ceil(reset)}`)
+
+// RateLimitResult is the result of a rate limiter check.
+type RateLimitResult struct {
+	// Allowed is the number of requests allowed, either zero or the number
+	// of requests checked.
+	Allowed int
+
+	// Remaining is the number of requests that would be allowed now.
+	Remaining int
+
+	// RetryAfter is the time after which the requests would be allowed. It
+	// is -1 when the requests are allowed or can never be allowed because
+	// they exceed the limit.
+	RetryAfter time.Duration
+
+	// ResetAfter is the time after which the limiter returns to its initial
+	// state.
+	ResetAfter time.Duration
+}
+
+func parseRateLimitResult(reply interface{}, err error) (*RateLimitResult, error) {
+	values, err := redis.Int64s(reply, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 4 {
+		return nil, errors.New("redisx: unexpected reply from rate limit script")
+	}
+	r := &RateLimitResult{
+		Allowed:    int(values[0]),
+		Remaining:  int(values[1]),
+		RetryAfter: -1,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}
+	if values[2] >= 0 {
+		r.RetryAfter = time.Duration(values[2]) * time.Microsecond
+	}
+	return r, nil
+}
+
+// SlidingWindowLimiter allows at most Limit requests per key in any period
+// of length Window. Every allowed request is recorded in a sorted set stored
+// at the key.
+//
+//  limiter := &redisx.SlidingWindowLimiter{Pool: pool, Limit: 100, Window: time.Minute}
+//  r, err := limiter.Allow("api:" + userID)
+//  if err != nil {
+//      return err
+//  }
+//  if r.Allowed == 0 {
+//      // Too many requests, try again after r.RetryAfter.
+//  }
+type SlidingWindowLimiter struct {
+	// Pool is the pool used to get connections.
+	Pool *redis.Pool
+
+	// Limit is the number of requests allowed in a window.
+	Limit int
+
+	// Window is the length of the window.
+	Window time.Duration
+}
+
+// Allow is shorthand for AllowN(key, 1).
+func (l *SlidingWindowLimiter) Allow(key string) (*RateLimitResult, error) {
+	return l.AllowN(key, 1)
+}
+
+// AllowN reports whether n requests may happen now and records them if so.
+func (l *SlidingWindowLimiter) AllowN(key string, n int) (*RateLimitResult, error) {
+	if l.Limit <= 0 || l.Window < time.Microsecond {
+		return nil, errors.New("redisx: invalid SlidingWindowLimiter limit or window")
+	}
+	member, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	c := l.Pool.Get()
+	defer c.Close()
+	return parseRateLimitResult(slidingWindowScript.Do(c, key, l.Limit, int64(l.Window/time.Microsecond), n, member))
+}
+
+// GCRALimiter allows Rate requests per Period per key with bursts of up to
+// Burst requests using the generic cell rate algorithm. Unlike
+// SlidingWindowLimiter, the limiter stores a single value per key.
+//
+//  // Ten requests per second with bursts of up to twenty requests.
+//  limiter := &redisx.GCRALimiter{Pool: pool, Rate: 10, Period: time.Second, Burst: 20}
+type GCRALimiter struct {
+	// Pool is the pool used to get connections.
+	Pool *redis.Pool
+
+	// Rate is the number of requests allowed per Period.
+	Rate int
+
+	// Period is the period of Rate. If zero, one second is used.
+	Period time.Duration
+
+	// Burst is the number of requests allowed at once. If zero, Rate is used.
+	Burst int
+}
+
+// Allow is shorthand for AllowN(key, 1).
+func (l *GCRALimiter) Allow(key string) (*RateLimitResult, error) {
+	return l.AllowN(key, 1)
+}
+
+// AllowN reports whether n requests may happen now and records them if so.
+func (l *GCRALimiter) AllowN(key string, n int) (*RateLimitResult, error) {
+	period := l.Period
+	if period <= 0 {
+		period = time.Second
+	}
+	burst := l.Burst
+	if burst <= 0 {
+		burst = l.Rate
+	}
+	if l.Rate <= 0 {
+		return nil, errors.New("redisx: invalid GCRALimiter rate")
+	}
+	c := l.Pool.Get()
+	defer c.Close()
+	return parseRateLimitResult(gcraScript.Do(c, key, burst, l.Rate, int64(period/time.Microsecond), n))
+}
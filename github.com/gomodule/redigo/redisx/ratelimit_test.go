@@ -0,0 +1,84 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redisx"
+)
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	p := newTestPool(t)
+	defer p.Close()
+	limiter := &redisx.SlidingWindowLimiter{Pool: p, Limit: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		r, err := limiter.Allow("limit")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Allowed != 1 || r.Remaining != 2-i {
+			t.Fatalf("request %d: Allow() = %+v, want allowed with %d remaining", i, r, 2-i)
+		}
+	}
+	r, err := limiter.Allow("limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Allowed != 0 || r.Remaining != 0 || r.RetryAfter <= 0 || r.RetryAfter > time.Minute {
+		t.Fatalf("Allow() over limit = %+v, want denied with retry within a minute", r)
+	}
+	if r, err = limiter.AllowN("limit", 4); err != nil || r.RetryAfter != -1 {
+		t.Fatalf("AllowN(4) = %+v, %v, want RetryAfter -1", r, err)
+	}
+
+	// Keys are limited independently.
+	if r, err = limiter.AllowN("other", 3); err != nil || r.Allowed != 3 {
+		t.Fatalf("AllowN on other key = %+v, %v, want 3 allowed", r, err)
+	}
+}
+
+func TestGCRALimiter(t *testing.T) {
+	p := newTestPool(t)
+	defer p.Close()
+	limiter := &redisx.GCRALimiter{Pool: p, Rate: 10, Period: time.Second, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		r, err := limiter.Allow("limit")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Allowed != 1 || r.Remaining != 1-i {
+			t.Fatalf("request %d: Allow() = %+v, want allowed with %d remaining", i, r, 1-i)
+		}
+	}
+	r, err := limiter.Allow("limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Allowed != 0 || r.RetryAfter <= 0 || r.RetryAfter > 100*time.Millisecond {
+		t.Fatalf("Allow() over burst = %+v, want denied with retry within 100ms", r)
+	}
+
+	time.Sleep(r.RetryAfter)
+	if r, err = limiter.Allow("limit"); err != nil || r.Allowed != 1 {
+		t.Fatalf("Allow() after RetryAfter = %+v, %v, want allowed", r, err)
+	}
+	if r, err = limiter.AllowN("limit", 3); err != nil || r.Allowed != 0 || r.RetryAfter != -1 {
+		t.Fatalf("AllowN(3) = %+v, %v, want denied with RetryAfter -1", r, err)
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ErrNoSentinel is returned when none of the Sentinels could be reached.
+var ErrNoSentinel = errors.New("redisx: no Sentinel available")
+
+// Sentinel discovers the master and replicas of a Redis deployment monitored
+// by Redis Sentinel and keeps pools created with MasterPool and ReplicaPool
+// pointed at the right servers.
+//
+// The Sentinel subscribes to the +switch-master event. On failover, idle
+// connections of the pools are closed and the role of master connections
+// that were in use during the failover is checked before they are reused.
+//
+//  sntnl := &redisx.Sentinel{
+//      Addrs:      []string{":26379", ":26380", ":26381"},
+//      MasterName: "mymaster",
+//  }
+//  defer sntnl.Close()
+//
+//  pool := sntnl.MasterPool(&redis.Pool{
+//      MaxIdle:     3,
+//      IdleTimeout: 240 * time.Second,
+//  })
+type Sentinel struct {
+	// Addrs is the list of Sentinel addresses.
+	Addrs []string
+
+	// MasterName is the name of the master as configured in Sentinel.
+	MasterName string
+
+	// DialSentinel dials the Sentinel at addr. If nil, redis.Dial is used
+	// with a connect, read and write timeout of one second.
+	DialSentinel func(addr string) (redis.Conn, error)
+
+	// DialServer dials the Redis server at addr. If nil, redis.Dial is used.
+	DialServer func(addr string) (redis.Conn, error)
+
+	mu           sync.Mutex
+	addrs        []string // Addrs with the last Sentinel that answered first
+	pools        []*redis.Pool
+	lastFailover time.Time
+	watching     bool
+	watchConn    redis.Conn
+	closed       bool
+}
+
+func (s *Sentinel) dialSentinel(addr string) (redis.Conn, error) {
+	if s.DialSentinel != nil {
+		return s.DialSentinel(addr)
+	}
+	return redis.Dial("tcp", addr,
+		redis.DialConnectTimeout(time.Second),
+		redis.DialReadTimeout(time.Second),
+		redis.DialWriteTimeout(time.Second))
+}
+
+func (s *Sentinel) dialServer(addr string) (redis.Conn, error) {
+	if s.DialServer != nil {
+		return s.DialServer(addr)
+	}
+	return redis.Dial("tcp", addr)
+}
+
+// sentinelAddrs returns the Sentinel addresses in the order to try them.
+func (s *Sentinel) sentinelAddrs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.addrs == nil {
+		s.addrs = append([]string(nil), s.Addrs...)
+	}
+	return append([]string(nil), s.addrs...)
+}
+
+// promote moves addr to the front of the Sentinel list.
+func (s *Sentinel) promote(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, a := range s.addrs {
+		if a == addr {
+			copy(s.addrs[1:i+1], s.addrs[:i])
+			s.addrs[0] = addr
+			return
+		}
+	}
+}
+
+// query runs fn on the Sentinels in turn until one succeeds.
+func (s *Sentinel) query(fn func(c redis.Conn) error) error {
+	err := ErrNoSentinel
+	for _, addr := range s.sentinelAddrs() {
+		var c redis.Conn
+		c, err = s.dialSentinel(addr)
+		if err != nil {
+			continue
+		}
+		err = fn(c)
+		c.Close()
+		if err == nil {
+			s.promote(addr)
+			return nil
+		}
+	}
+	return err
+}
+
+// MasterAddr returns the address of the current master.
+func (s *Sentinel) MasterAddr() (string, error) {
+	var addr string
+	err := s.query(func(c redis.Conn) error {
+		hostPort, err := redis.Strings(c.Do("SENTINEL", "get-master-addr-by-name", s.MasterName))
+		if err == redis.ErrNil {
+			return fmt.Errorf("redisx: unknown master %q", s.MasterName)
+		}
+		if err != nil {
+			return err
+		}
+		if len(hostPort) != 2 {
+			return fmt.Errorf("redisx: unexpected reply %q to SENTINEL get-master-addr-by-name", hostPort)
+		}
+		addr = net.JoinHostPort(hostPort[0], hostPort[1])
+		return nil
+	})
+	return addr, err
+}
+
+// ReplicaAddrs returns the addresses of the replicas of the master that are
+// neither down nor disconnected.
+func (s *Sentinel) ReplicaAddrs() ([]string, error) {
+	var addrs []string
+	err := s.query(func(c redis.Conn) error {
+		replicas, err := redis.Values(c.Do("SENTINEL", "slaves", s.MasterName))
+		if err != nil {
+			return err
+		}
+		addrs = addrs[:0]
+		for _, replica := range replicas {
+			info, err := redis.StringMap(replica, nil)
+			if err != nil {
+				return err
+			}
+			if replicaDown(info["flags"]) {
+				continue
+			}
+			addrs = append(addrs, net.JoinHostPort(info["ip"], info["port"]))
+		}
+		return nil
+	})
+	return addrs, err
+}
+
+func replicaDown(flags string) bool {
+	for _, flag := range strings.Split(flags, ",") {
+		switch flag {
+		case "s_down", "o_down", "disconnected":
+			return true
+		}
+	}
+	return false
+}
+
+// MasterPool configures p to connect to the current master and returns p.
+// The Dial, DialContext and TestOnBorrow fields of p are set by MasterPool,
+// an existing TestOnBorrow function is called before the role check.
+func (s *Sentinel) MasterPool(p *redis.Pool) *redis.Pool {
+	p.Dial = func() (redis.Conn, error) {
+		addr, err := s.MasterAddr()
+		if err != nil {
+			return nil, err
+		}
+		c, err := s.dialServer(addr)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRole(c, "master"); err != nil {
+			c.Close()
+			return nil, err
+		}
+		return &sentinelConn{Conn: c, checked: time.Now()}, nil
+	}
+	return s.register(p, "master")
+}
+
+// ReplicaPool configures p to connect to a random replica of the master and
+// returns p. The master is used when no replica is available. Use a replica
+// pool for reads that tolerate replication lag.
+func (s *Sentinel) ReplicaPool(p *redis.Pool) *redis.Pool {
+	p.Dial = func() (redis.Conn, error) {
+		addrs, err := s.ReplicaAddrs()
+		if err != nil {
+			return nil, err
+		}
+		for len(addrs) > 0 {
+			i := rand.Intn(len(addrs))
+			c, err := s.dialServer(addrs[i])
+			if err == nil {
+				return c, nil
+			}
+			addrs = append(addrs[:i], addrs[i+1:]...)
+		}
+		addr, err := s.MasterAddr()
+		if err != nil {
+			return nil, err
+		}
+		return s.dialServer(addr)
+	}
+	return s.register(p, "")
+}
+
+// register sets the borrow test of p and adds p to the pools flushed on
+// failover. If role is not empty, connections whose role was last checked
+// before the last failover must have that role to be reused.
+func (s *Sentinel) register(p *redis.Pool, role string) *redis.Pool {
+	p.DialContext = nil
+	testOnBorrow := p.TestOnBorrow
+	p.TestOnBorrow = func(c redis.Conn, t time.Time) error {
+		if testOnBorrow != nil {
+			if err := testOnBorrow(c, t); err != nil {
+				return err
+			}
+		}
+		sc, ok := c.(*sentinelConn)
+		if !ok || role == "" {
+			return nil
+		}
+		s.mu.Lock()
+		stale := !sc.checked.After(s.lastFailover)
+		s.mu.Unlock()
+		if !stale {
+			return nil
+		}
+		if err := checkRole(sc.Conn, role); err != nil {
+			return err
+		}
+		sc.checked = time.Now()
+		return nil
+	}
+
+	s.mu.Lock()
+	s.pools = append(s.pools, p)
+	s.mu.Unlock()
+	s.watch()
+	return p
+}
+
+// sentinelConn is a connection to a server with a known role.
+type sentinelConn struct {
+	redis.Conn
+	checked time.Time // when the role was last checked
+}
+
+func (c *sentinelConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	return redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+}
+
+func (c *sentinelConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	return redis.ReceiveWithTimeout(c.Conn, timeout)
+}
+
+// checkRole returns an error if the server c is connected to does not have
+// the given role.
+func checkRole(c redis.Conn, role string) error {
+	reply, err := redis.Values(c.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(reply) == 0 {
+		return errors.New("redisx: unexpected reply to ROLE")
+	}
+	got, err := redis.String(reply[0], nil)
+	if err != nil {
+		return err
+	}
+	if got != role {
+		return fmt.Errorf("redisx: server role is %s, want %s", got, role)
+	}
+	return nil
+}
+
+// failover records a failover and closes the idle connections of the pools.
+func (s *Sentinel) failover() {
+	s.mu.Lock()
+	s.lastFailover = time.Now()
+	pools := append([]*redis.Pool(nil), s.pools...)
+	s.mu.Unlock()
+	for _, p := range pools {
+		p.CloseIdle()
+	}
+}
+
+// watch starts the goroutine listening for +switch-master events.
+func (s *Sentinel) watch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watching || s.closed {
+		return
+	}
+	s.watching = true
+	go s.watchLoop()
+}
+
+func (s *Sentinel) watchLoop() {
+	for {
+		err := s.query(func(c redis.Conn) error {
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				return nil
+			}
+			s.watchConn = c
+			s.mu.Unlock()
+
+			psc := redis.PubSubConn{Conn: c}
+			if err := psc.Subscribe("+switch-master"); err != nil {
+				return err
+			}
+			// Events may have been missed while not subscribed.
+			s.failover()
+			for {
+				switch v := psc.ReceiveWithTimeout(0).(type) {
+				case redis.Message:
+					// The message is "<master name> <old ip> <old port> <new ip> <new port>".
+					fields := strings.Fields(string(v.Data))
+					if len(fields) == 5 && fields[0] == s.MasterName {
+						s.failover()
+					}
+				case error:
+					return v
+				}
+			}
+		})
+
+		s.mu.Lock()
+		s.watchConn = nil
+		closed := s.closed
+		s.mu.Unlock()
+		if closed {
+			return
+		}
+		if err != nil {
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// Close stops listening for failover events. Pools returned by MasterPool
+// and ReplicaPool must be closed separately.
+func (s *Sentinel) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	c := s.watchConn
+	s.mu.Unlock()
+	if c != nil {
+		return c.Close()
+	}
+	return nil
+}
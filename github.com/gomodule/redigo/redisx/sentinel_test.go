@@ -0,0 +1,259 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx_test
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/gomodule/redigo/redisx"
+)
+
+// fakeSentinel simulates a Sentinel monitoring "mymaster" and the servers
+// of the deployment.
+type fakeSentinel struct {
+	mu       sync.Mutex
+	master   string
+	replicas map[string]string // addr -> flags
+	dialed   []string
+	events   chan string
+	watching chan struct{}
+}
+
+func newFakeSentinel() *fakeSentinel {
+	return &fakeSentinel{
+		master:   "10.0.0.1:6379",
+		replicas: map[string]string{"10.0.0.2:6379": "slave", "10.0.0.3:6379": "slave,s_down"},
+		events:   make(chan string),
+		watching: make(chan struct{}, 1),
+	}
+}
+
+func (fs *fakeSentinel) failover(addr string) {
+	fs.mu.Lock()
+	old := fs.master
+	fs.master = addr
+	delete(fs.replicas, addr)
+	fs.replicas[old] = "slave"
+	fs.mu.Unlock()
+	oldHost, oldPort, _ := net.SplitHostPort(old)
+	host, port, _ := net.SplitHostPort(addr)
+	fs.events <- "mymaster " + oldHost + " " + oldPort + " " + host + " " + port
+}
+
+func (fs *fakeSentinel) takeDialed() []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	dialed := fs.dialed
+	fs.dialed = nil
+	return dialed
+}
+
+func (fs *fakeSentinel) dialSentinel(addr string) (redis.Conn, error) {
+	if addr != "sentinel:26379" {
+		return nil, errors.New("connection refused")
+	}
+	return &fakeSentinelConn{fs: fs, closed: make(chan struct{})}, nil
+}
+
+func (fs *fakeSentinel) dialServer(addr string) (redis.Conn, error) {
+	fs.mu.Lock()
+	fs.dialed = append(fs.dialed, addr)
+	fs.mu.Unlock()
+	return &fakeServerConn{fs: fs, addr: addr}, nil
+}
+
+type fakeSentinelConn struct {
+	fs      *fakeSentinel
+	replies []interface{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func (c *fakeSentinelConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fakeSentinelConn) Err() error   { return nil }
+func (c *fakeSentinelConn) Flush() error { return nil }
+
+func (c *fakeSentinelConn) Send(cmd string, args ...interface{}) error {
+	if cmd == "SUBSCRIBE" {
+		c.replies = append(c.replies, []interface{}{[]byte("subscribe"), []byte(args[0].(string)), int64(1)})
+		return nil
+	}
+	c.fs.mu.Lock()
+	defer c.fs.mu.Unlock()
+	switch args[0] {
+	case "get-master-addr-by-name":
+		host, port, _ := net.SplitHostPort(c.fs.master)
+		c.replies = append(c.replies, []interface{}{[]byte(host), []byte(port)})
+	case "slaves":
+		var replicas []interface{}
+		for addr, flags := range c.fs.replicas {
+			host, port, _ := net.SplitHostPort(addr)
+			replicas = append(replicas, []interface{}{
+				[]byte("ip"), []byte(host), []byte("port"), []byte(port), []byte("flags"), []byte(flags),
+			})
+		}
+		c.replies = append(c.replies, replicas)
+	}
+	return nil
+}
+
+func (c *fakeSentinelConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	c.Send(cmd, args...)
+	return c.Receive()
+}
+
+func (c *fakeSentinelConn) Receive() (interface{}, error) {
+	return c.ReceiveWithTimeout(0)
+}
+
+func (c *fakeSentinelConn) ReceiveWithTimeout(time.Duration) (interface{}, error) {
+	if len(c.replies) > 0 {
+		r := c.replies[0]
+		c.replies = c.replies[1:]
+		return r, nil
+	}
+	select {
+	case c.fs.watching <- struct{}{}:
+	default:
+	}
+	select {
+	case event := <-c.fs.events:
+		return []interface{}{[]byte("message"), []byte("+switch-master"), []byte(event)}, nil
+	case <-c.closed:
+		return nil, errors.New("closed")
+	}
+}
+
+func (c *fakeSentinelConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	return c.Do(cmd, args...)
+}
+
+type fakeServerConn struct {
+	fs   *fakeSentinel
+	addr string
+}
+
+func (c *fakeServerConn) Close() error                      { return nil }
+func (c *fakeServerConn) Err() error                        { return nil }
+func (c *fakeServerConn) Flush() error                      { return nil }
+func (c *fakeServerConn) Send(string, ...interface{}) error { return nil }
+func (c *fakeServerConn) Receive() (interface{}, error)     { return nil, nil }
+func (c *fakeServerConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if cmd == "ROLE" {
+		c.fs.mu.Lock()
+		defer c.fs.mu.Unlock()
+		if c.addr == c.fs.master {
+			return []interface{}{[]byte("master"), int64(0), []interface{}{}}, nil
+		}
+		return []interface{}{[]byte("slave"), []byte("10.0.0.1"), int64(6379), []byte("connected"), int64(0)}, nil
+	}
+	return "OK", nil
+}
+
+func newTestSentinel(fs *fakeSentinel) *redisx.Sentinel {
+	return &redisx.Sentinel{
+		Addrs:        []string{"down:26379", "sentinel:26379"},
+		MasterName:   "mymaster",
+		DialSentinel: fs.dialSentinel,
+		DialServer:   fs.dialServer,
+	}
+}
+
+func TestSentinelAddrs(t *testing.T) {
+	fs := newFakeSentinel()
+	sntnl := newTestSentinel(fs)
+	defer sntnl.Close()
+
+	addr, err := sntnl.MasterAddr()
+	if err != nil || addr != "10.0.0.1:6379" {
+		t.Fatalf("MasterAddr() = %q, %v, want %q", addr, err, "10.0.0.1:6379")
+	}
+	addrs, err := sntnl.ReplicaAddrs()
+	if err != nil || !reflect.DeepEqual(addrs, []string{"10.0.0.2:6379"}) {
+		t.Fatalf("ReplicaAddrs() = %q, %v, want [10.0.0.2:6379]", addrs, err)
+	}
+}
+
+func TestSentinelFailover(t *testing.T) {
+	fs := newFakeSentinel()
+	sntnl := newTestSentinel(fs)
+	defer sntnl.Close()
+
+	p := sntnl.MasterPool(&redis.Pool{MaxIdle: 2})
+	defer p.Close()
+	<-fs.watching
+
+	c1 := p.Get()
+	c2 := p.Get()
+	c1.Do("PING")
+	c2.Do("PING")
+	c1.Close()
+	if dialed := fs.takeDialed(); !reflect.DeepEqual(dialed, []string{"10.0.0.1:6379", "10.0.0.1:6379"}) {
+		t.Fatalf("dialed %q, want two connections to the master", dialed)
+	}
+
+	fs.failover("10.0.0.2:6379")
+	<-fs.watching
+	if n := p.IdleCount(); n != 0 {
+		t.Fatalf("IdleCount() = %d after failover, want 0", n)
+	}
+
+	// c2 was in use during the failover, its role is checked on borrow.
+	c2.Close()
+	c := p.Get()
+	if _, err := c.Do("PING"); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if dialed := fs.takeDialed(); !reflect.DeepEqual(dialed, []string{"10.0.0.2:6379"}) {
+		t.Fatalf("dialed %q, want a connection to the new master", dialed)
+	}
+
+	// Connections to the new master are reused.
+	c = p.Get()
+	c.Do("PING")
+	c.Close()
+	if dialed := fs.takeDialed(); len(dialed) != 0 {
+		t.Fatalf("dialed %q, want idle connection to be reused", dialed)
+	}
+}
+
+func TestSentinelReplicaPool(t *testing.T) {
+	fs := newFakeSentinel()
+	sntnl := newTestSentinel(fs)
+	defer sntnl.Close()
+
+	p := sntnl.ReplicaPool(&redis.Pool{MaxIdle: 1})
+	defer p.Close()
+
+	c := p.Get()
+	if _, err := c.Do("GET", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if dialed := fs.takeDialed(); !reflect.DeepEqual(dialed, []string{"10.0.0.2:6379"}) {
+		t.Fatalf("dialed %q, want a connection to the replica", dialed)
+	}
+}
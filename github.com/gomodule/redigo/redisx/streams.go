@@ -0,0 +1,356 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// XAdd appends an entry with the given alternating field names and values to
+// stream and returns the ID of the entry. Use "*" as id to let the server
+// generate the ID. Structs can be added with redis.Args:
+//
+//  id, err := redisx.XAdd(c, "events", "*", redis.Args{}.AddFlat(&event)...)
+func XAdd(c redis.Conn, stream, id string, fields ...interface{}) (string, error) {
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return "", errors.New("redisx: XAdd expects a non-empty even number of field names and values")
+	}
+	args := make([]interface{}, 0, 2+len(fields))
+	args = append(args, stream, id)
+	args = append(args, fields...)
+	return redis.String(c.Do("XADD", args...))
+}
+
+// XReadGroupArgs are the arguments of XReadGroup.
+type XReadGroupArgs struct {
+	// Group and Consumer name the consumer group and the consumer.
+	Group    string
+	Consumer string
+
+	// Stream is the stream to read.
+	Stream string
+
+	// ID is the ID after which entries are read. The default ">" reads
+	// entries never delivered to other consumers, "0" reads the pending
+	// entries of the consumer.
+	ID string
+
+	// Count limits the number of entries returned. Zero means no limit.
+	Count int
+
+	// Block is how long to wait for entries when none are available. If
+	// zero, XReadGroup does not block.
+	Block time.Duration
+
+	// NoAck acknowledges the entries as they are read, they are not added to
+	// the pending entries list.
+	NoAck bool
+}
+
+// XReadGroup reads entries from a stream on behalf of a consumer group. An
+// empty slice is returned when no entries are available before the block
+// timeout expires.
+func XReadGroup(c redis.Conn, args XReadGroupArgs) ([]redis.StreamEntry, error) {
+	id := args.ID
+	if id == "" {
+		id = ">"
+	}
+	cmdArgs := []interface{}{"GROUP", args.Group, args.Consumer}
+	if args.Count > 0 {
+		cmdArgs = append(cmdArgs, "COUNT", args.Count)
+	}
+	if args.Block > 0 {
+		cmdArgs = append(cmdArgs, "BLOCK", int64(args.Block/time.Millisecond))
+	}
+	if args.NoAck {
+		cmdArgs = append(cmdArgs, "NOACK")
+	}
+	cmdArgs = append(cmdArgs, "STREAMS", args.Stream, id)
+
+	var reply interface{}
+	var err error
+	if _, ok := c.(redis.ConnWithTimeout); ok && args.Block > 0 {
+		// Leave the server time to reply after the block timeout.
+		reply, err = redis.DoWithTimeout(c, args.Block+time.Second, "XREADGROUP", cmdArgs...)
+	} else {
+		reply, err = c.Do("XREADGROUP", cmdArgs...)
+	}
+	streams, err := redis.Streams(reply, err)
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return streams[args.Stream], nil
+}
+
+// Consumer reads the entries of a stream as a member of a consumer group,
+// passes them to Handler and acknowledges the entries handled without error.
+//
+// Entries that are not acknowledged stay pending. When ClaimMinIdle is set,
+// the consumer periodically claims the entries pending for longer than
+// ClaimMinIdle, including the entries of consumers that died, and hands them
+// to Handler again.
+//
+//  consumer := &redisx.Consumer{
+//      Pool:   pool,
+//      Stream: "events",
+//      Group:  "mailer",
+//      Name:   hostname,
+//      Handler: func(entry redis.StreamEntry) error {
+//          var e Event
+//          if err := redis.ScanStruct(entry.Fields, &e); err != nil {
+//              return err
+//          }
+//          return send(e)
+//      },
+//      ClaimMinIdle: time.Minute,
+//  }
+//  err := consumer.Run(ctx)
+type Consumer struct {
+	// Pool is the pool used to get connections.
+	Pool *redis.Pool
+
+	// Stream is the stream to read.
+	Stream string
+
+	// Group is the consumer group. The group is created with MKSTREAM when
+	// it does not exist.
+	Group string
+
+	// Name is the name of the consumer in the group.
+	Name string
+
+	// Handler is called with each entry. The entry is acknowledged if
+	// Handler returns nil.
+	Handler func(entry redis.StreamEntry) error
+
+	// Count is the maximum number of entries read at once. If zero, ten
+	// entries are read at once.
+	Count int
+
+	// Block is how long a read waits for new entries. It bounds how long Run
+	// takes to return after the context is canceled. If zero, a read waits
+	// for five seconds.
+	Block time.Duration
+
+	// ClaimMinIdle is the time after which a pending entry is claimed by
+	// the consumer. If zero, pending entries of other consumers are not
+	// claimed.
+	ClaimMinIdle time.Duration
+
+	// ClaimInterval is the time between claims. If zero, ClaimMinIdle is
+	// used.
+	ClaimInterval time.Duration
+
+	// claimStart is the ID from which XAUTOCLAIM scans the pending entries.
+	claimStart string
+}
+
+// Run creates the consumer group if needed, handles the pending entries of
+// the consumer and then reads new entries until ctx is done. The entry being
+// handled when ctx is done is handled to completion. Run returns nil when ctx
+// is done and otherwise the first error returned by the server.
+func (cr *Consumer) Run(ctx context.Context) error {
+	if cr.Handler == nil {
+		return errors.New("redisx: Consumer.Handler is nil")
+	}
+	if err := cr.createGroup(); err != nil {
+		return err
+	}
+
+	claimInterval := cr.ClaimInterval
+	if claimInterval <= 0 {
+		claimInterval = cr.ClaimMinIdle
+	}
+	var lastClaim time.Time
+
+	// Start with the entries delivered to this consumer but not acknowledged,
+	// for example because the previous run crashed.
+	id := "0"
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if cr.ClaimMinIdle > 0 && time.Since(lastClaim) >= claimInterval {
+			lastClaim = time.Now()
+			// Scan the pending entries list to its end, one page at a time.
+			for done := false; !done && ctx.Err() == nil; {
+				var entries []redis.StreamEntry
+				var err error
+				entries, done, err = cr.claim()
+				if err != nil {
+					return err
+				}
+				if err := cr.handle(ctx, entries); err != nil {
+					return err
+				}
+			}
+		}
+
+		entries, err := cr.read(id)
+		if err != nil {
+			return err
+		}
+		if err := cr.handle(ctx, entries); err != nil {
+			return err
+		}
+		if id != ">" {
+			// Page through the pending entries. Entries handled with an
+			// error stay pending until they are claimed.
+			if len(entries) == 0 {
+				id = ">"
+			} else {
+				id = entries[len(entries)-1].ID
+			}
+		}
+	}
+}
+
+func (cr *Consumer) createGroup() error {
+	c := cr.Pool.Get()
+	defer c.Close()
+	_, err := c.Do("XGROUP", "CREATE", cr.Stream, cr.Group, "$", "MKSTREAM")
+	if err, ok := err.(redis.Error); ok && strings.HasPrefix(string(err), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+func (cr *Consumer) read(id string) ([]redis.StreamEntry, error) {
+	c := cr.Pool.Get()
+	defer c.Close()
+	count := cr.Count
+	if count <= 0 {
+		count = 10
+	}
+	block := cr.Block
+	if block <= 0 {
+		block = 5 * time.Second
+	}
+	args := XReadGroupArgs{
+		Group:    cr.Group,
+		Consumer: cr.Name,
+		Stream:   cr.Stream,
+		ID:       id,
+		Count:    count,
+	}
+	if id == ">" {
+		args.Block = block
+	}
+	return XReadGroup(c, args)
+}
+
+// claim claims a page of the entries pending for longer than ClaimMinIdle
+// with XAUTOCLAIM or, on servers before Redis 6.2, with XPENDING and XCLAIM.
+// claim reports done once the end of the pending entries list is reached.
+func (cr *Consumer) claim() (entries []redis.StreamEntry, done bool, err error) {
+	c := cr.Pool.Get()
+	defer c.Close()
+	count := cr.Count
+	if count <= 0 {
+		count = 10
+	}
+	minIdle := int64(cr.ClaimMinIdle / time.Millisecond)
+
+	start := cr.claimStart
+	if start == "" {
+		start = "0"
+	}
+	reply, err := redis.Values(c.Do("XAUTOCLAIM", cr.Stream, cr.Group, cr.Name, minIdle, start, "COUNT", count))
+	if err == nil {
+		if len(reply) < 2 {
+			return nil, false, errors.New("redisx: unexpected reply to XAUTOCLAIM")
+		}
+		next, err := redis.String(reply[0], nil)
+		if err != nil {
+			return nil, false, err
+		}
+		entries, err := redis.StreamEntries(reply[1], nil)
+		if err != nil {
+			return nil, false, err
+		}
+		// The server returns the cursor "0-0" once the scan is complete.
+		done = next == "0-0"
+		if done {
+			next = ""
+		}
+		cr.claimStart = next
+		return entries, done, nil
+	}
+	if err, ok := err.(redis.Error); !ok || !strings.Contains(strings.ToLower(string(err)), "unknown command") {
+		return nil, false, err
+	}
+
+	// Without XAUTOCLAIM, only the oldest pending entries are claimed.
+	pending, err := redis.Values(c.Do("XPENDING", cr.Stream, cr.Group, "-", "+", count))
+	if err != nil {
+		return nil, false, err
+	}
+	args := []interface{}{cr.Stream, cr.Group, cr.Name, minIdle}
+	for _, p := range pending {
+		// Each pending entry is [id, consumer, idle time, delivery count].
+		values, err := redis.Values(p, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(values) < 3 {
+			return nil, false, errors.New("redisx: unexpected reply to XPENDING")
+		}
+		idle, err := redis.Int64(values[2], nil)
+		if err != nil {
+			return nil, false, err
+		}
+		if idle >= minIdle {
+			args = append(args, values[0])
+		}
+	}
+	if len(args) == 4 {
+		return nil, true, nil
+	}
+	entries, err = redis.StreamEntries(c.Do("XCLAIM", args...))
+	return entries, true, err
+}
+
+// handle passes entries to the handler and acknowledges the entries handled
+// without error.
+func (cr *Consumer) handle(ctx context.Context, entries []redis.StreamEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var acks []interface{}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := cr.Handler(entry); err == nil {
+			acks = append(acks, entry.ID)
+		}
+	}
+	if len(acks) == 0 {
+		return nil
+	}
+	c := cr.Pool.Get()
+	defer c.Close()
+	_, err := c.Do("XACK", append([]interface{}{cr.Stream, cr.Group}, acks...)...)
+	return err
+}
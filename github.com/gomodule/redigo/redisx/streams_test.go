@@ -0,0 +1,154 @@
+// Copyright 2019 Gary Burd
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package redisx_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/gomodule/redigo/redisx"
+)
+
+type streamEvent struct {
+	Name  string `redis:"name"`
+	Count int    `redis:"count"`
+}
+
+func TestXReadGroup(t *testing.T) {
+	c, err := redisx.DialTest()
+	if err != nil {
+		t.Fatalf("error connection to database, %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Do("XGROUP", "CREATE", "events", "g", "$", "MKSTREAM"); err != nil {
+		t.Fatal(err)
+	}
+	id, err := redisx.XAdd(c, "events", "*", redis.Args{}.AddFlat(&streamEvent{Name: "a", Count: 1})...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := redisx.XReadGroup(c, redisx.XReadGroupArgs{Group: "g", Consumer: "c1", Stream: "events", Block: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != id {
+		t.Fatalf("XReadGroup returned %v, want entry %s", entries, id)
+	}
+	var ev streamEvent
+	if err := redis.ScanStruct(entries[0].Fields, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev != (streamEvent{Name: "a", Count: 1}) {
+		t.Fatalf("ScanStruct = %+v, want {a 1}", ev)
+	}
+
+	entries, err = redisx.XReadGroup(c, redisx.XReadGroupArgs{Group: "g", Consumer: "c1", Stream: "events", Block: 10 * time.Millisecond})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("XReadGroup on empty stream = %v, %v, want no entries", entries, err)
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	c, err := redisx.DialTest()
+	if err != nil {
+		t.Fatalf("error connection to database, %v", err)
+	}
+	p := &redis.Pool{
+		MaxIdle:   1,
+		MaxActive: 1,
+		Wait:      true,
+		Dial:      func() (redis.Conn, error) { return c, nil },
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var handled []string
+	consumer := &redisx.Consumer{
+		Pool:   p,
+		Stream: "jobs",
+		Group:  "workers",
+		Name:   "w1",
+		Block:  50 * time.Millisecond,
+		Handler: func(entry redis.StreamEntry) error {
+			var ev streamEvent
+			if err := redis.ScanStruct(entry.Fields, &ev); err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, ev.Name)
+			if len(handled) == 3 {
+				cancel()
+			}
+			if ev.Name == "bad" {
+				return errors.New("bad event")
+			}
+			return nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	// Wait for the group to be created.
+	for i := 0; ; i++ {
+		conn := p.Get()
+		n, err := redis.Int(conn.Do("EXISTS", "jobs"))
+		conn.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 1 {
+			break
+		}
+		if i > 100 {
+			t.Fatal("consumer group not created")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	conn := p.Get()
+	for _, name := range []string{"a", "bad", "b"} {
+		if _, err := redisx.XAdd(conn, "jobs", "*", "name", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer did not stop")
+	}
+
+	conn = p.Get()
+	defer conn.Close()
+	pending, err := redis.Values(conn.Do("XPENDING", "jobs", "workers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := redis.Int(pending[0], nil); n != 1 {
+		t.Fatalf("%d entries pending, want 1", n)
+	}
+}
@@ -0,0 +1,236 @@
+package gorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditEntry describes a change of an audited table
+type AuditEntry struct {
+	Table string
+	// PrimaryKey is nil for updates and deletes without primary key, which may change several records
+	PrimaryKey interface{}
+	Action     string
+	// Actor is the acting user, set on the context of the DB with `WithActor`
+	Actor   string
+	Changes []AuditChange
+	Time    time.Time
+}
+
+// AuditChange is the change of a column, Before is nil for created records and After is nil for deleted records
+type AuditChange struct {
+	Column string
+	Before interface{}
+	After  interface{}
+}
+
+// Auditor records the changes of audited tables
+type Auditor interface {
+	// Audit is called in the transaction of the change, the change is rolled back if it returns an error
+	Audit(tx *DB, entry *AuditEntry) error
+}
+
+// AuditorFunc is an adapter to use a function as an Auditor
+type AuditorFunc func(tx *DB, entry *AuditEntry) error
+
+// Audit call f(tx, entry)
+func (f AuditorFunc) Audit(tx *DB, entry *AuditEntry) error {
+	return f(tx, entry)
+}
+
+type audit struct {
+	auditor Auditor
+	tables  map[string]bool
+}
+
+// SetAuditor audit the changes of the tables of models with auditor, e.g. to record them in an audit table
+//     db.SetAuditor(gorm.TableAuditor("audit_logs"), &Order{}, &Payment{})
+//     db.WithContext(gorm.WithActor(ctx, "jinzhu")).Save(&order)
+// The values before an update or delete are loaded in its transaction, so that each change is recorded with its previous and new values
+func (s *DB) SetAuditor(auditor Auditor, models ...interface{}) *DB {
+	tables := map[string]bool{}
+	for _, model := range models {
+		tables[s.NewScope(model).TableName()] = true
+	}
+	s.parent.audit = &audit{auditor: auditor, tables: tables}
+	return s
+}
+
+type actorContextKey struct{}
+
+// WithActor return a copy of ctx with the acting user of changes, recorded in their AuditEntry
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext return the acting user set with `WithActor`
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(string)
+	return actor, ok
+}
+
+// AuditLog is the record of a change inserted by TableAuditor, Changes is the JSON encoded list of AuditChange
+type AuditLog struct {
+	ID         uint
+	Table      string `gorm:"column:table_name;size:255;index"`
+	PrimaryKey string `gorm:"size:255"`
+	Action     string `gorm:"size:16"`
+	Actor      string `gorm:"size:255"`
+	Changes    string `gorm:"type:text"`
+	CreatedAt  time.Time
+}
+
+// TableAuditor return an Auditor inserting an AuditLog for each change into the table name, created with
+//     db.Table("audit_logs").AutoMigrate(&gorm.AuditLog{})
+func TableAuditor(name string) Auditor {
+	return AuditorFunc(func(tx *DB, entry *AuditEntry) error {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+
+		log := AuditLog{
+			Table:     entry.Table,
+			Action:    entry.Action,
+			Actor:     entry.Actor,
+			Changes:   string(changes),
+			CreatedAt: entry.Time,
+		}
+		if entry.PrimaryKey != nil {
+			log.PrimaryKey = fmt.Sprint(entry.PrimaryKey)
+		}
+		return tx.Table(name).Create(&log).Error
+	})
+}
+
+// auditor return the Auditor of the table of the operation, nil if it is not audited
+func (scope *Scope) auditor() Auditor {
+	if audit := scope.db.parent.audit; audit != nil {
+		if table := scope.operationTableName(); table != "" && audit.tables[table] {
+			return audit.auditor
+		}
+	}
+	return nil
+}
+
+// auditLoadCallback load the record before it is updated or deleted
+func auditLoadCallback(scope *Scope) {
+	if scope.HasError() || scope.auditor() == nil || scope.PrimaryKeyZero() {
+		return
+	}
+	if before, ok := scope.auditLoad(); ok {
+		scope.InstanceSet("gorm:audit_before", before)
+	}
+}
+
+// auditCreateCallback record the values of created records
+func auditCreateCallback(scope *Scope) {
+	auditor := scope.auditor()
+	if auditor == nil || scope.HasError() || scope.IndirectValue().Kind() != reflect.Struct {
+		return
+	}
+
+	entry := scope.newAuditEntry(AuditCreate)
+	entry.PrimaryKey = scope.PrimaryKeyValue()
+	for _, field := range scope.Fields() {
+		if field.IsNormal && !field.IsIgnored {
+			entry.Changes = append(entry.Changes, AuditChange{Column: field.DBName, After: field.Field.Interface()})
+		}
+	}
+	scope.Err(auditor.Audit(scope.NewDB(), entry))
+}
+
+// auditUpdateCallback record the changed columns of updated records
+func auditUpdateCallback(scope *Scope) {
+	auditor := scope.auditor()
+	if auditor == nil || scope.HasError() || scope.db.RowsAffected == 0 {
+		return
+	}
+
+	entry := scope.newAuditEntry(AuditUpdate)
+	if before, ok := scope.InstanceGet("gorm:audit_before"); ok {
+		after, ok := scope.auditLoad()
+		if !ok {
+			return
+		}
+		entry.PrimaryKey = scope.PrimaryKeyValue()
+		entry.Changes = auditChanges(scope.New(before).Fields(), scope.New(after).Fields())
+		if len(entry.Changes) == 0 {
+			return
+		}
+	} else if updateAttrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+		// Updates without primary key only know the new values of the updated columns
+		updateMap := updateAttrs.(map[string]interface{})
+		for column, value := range updateMap {
+			entry.Changes = append(entry.Changes, AuditChange{Column: column, After: value})
+		}
+		sort.Slice(entry.Changes, func(i, j int) bool { return entry.Changes[i].Column < entry.Changes[j].Column })
+	}
+	scope.Err(auditor.Audit(scope.NewDB(), entry))
+}
+
+// auditDeleteCallback record the values of deleted records
+func auditDeleteCallback(scope *Scope) {
+	auditor := scope.auditor()
+	if auditor == nil || scope.HasError() || scope.db.RowsAffected == 0 {
+		return
+	}
+
+	entry := scope.newAuditEntry(AuditDelete)
+	if before, ok := scope.InstanceGet("gorm:audit_before"); ok {
+		entry.PrimaryKey = scope.PrimaryKeyValue()
+		for _, field := range scope.New(before).Fields() {
+			if field.IsNormal && !field.IsIgnored {
+				entry.Changes = append(entry.Changes, AuditChange{Column: field.DBName, Before: field.Field.Interface()})
+			}
+		}
+	}
+	scope.Err(auditor.Audit(scope.NewDB(), entry))
+}
+
+func (scope *Scope) newAuditEntry(action string) *AuditEntry {
+	entry := &AuditEntry{Table: scope.operationTableName(), Action: action, Time: NowFunc()}
+	entry.Actor, _ = ActorFromContext(scope.Context())
+	return entry
+}
+
+// auditLoad load the current values of the record of the scope, including soft deleted records
+func (scope *Scope) auditLoad() (interface{}, bool) {
+	value := reflect.New(scope.GetModelStruct().ModelType).Interface()
+	db := scope.NewDB().Unscoped().Table(scope.operationTableName())
+	for _, field := range scope.PrimaryFields() {
+		db = db.Where(fmt.Sprintf("%v = ?", scope.Quote(field.DBName)), field.Field.Interface())
+	}
+	if err := db.First(value).Error; err != nil {
+		if !IsRecordNotFoundError(err) {
+			scope.Err(err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+// auditChanges return the changes of the columns between before and after
+func auditChanges(before, after []*Field) []AuditChange {
+	var changes []AuditChange
+	for i, field := range before {
+		if !field.IsNormal || field.IsIgnored {
+			continue
+		}
+		if beforeValue, afterValue := field.Field.Interface(), after[i].Field.Interface(); !reflect.DeepEqual(beforeValue, afterValue) {
+			changes = append(changes, AuditChange{Column: field.DBName, Before: beforeValue, After: afterValue})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,72 @@
+package gorm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+type AuditedOrder struct {
+	Id     int64
+	Number string
+	Amount int64
+}
+
+func TestAuditor(t *testing.T) {
+	DB.DropTableIfExists(&AuditedOrder{}, "audited_order_logs")
+	DB.AutoMigrate(&AuditedOrder{})
+	DB.Table("audited_order_logs").AutoMigrate(&gorm.AuditLog{})
+
+	db, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("No error should happen when connecting to test database, but got %v", err)
+	}
+	defer db.Close()
+
+	var entries []*gorm.AuditEntry
+	auditor := gorm.TableAuditor("audited_order_logs")
+	db.SetAuditor(gorm.AuditorFunc(func(tx *gorm.DB, entry *gorm.AuditEntry) error {
+		entries = append(entries, entry)
+		return auditor.Audit(tx, entry)
+	}), &AuditedOrder{})
+
+	db = db.WithContext(gorm.WithActor(context.Background(), "auditor"))
+	order := AuditedOrder{Number: "A1", Amount: 10}
+	db.Create(&order)
+	db.Model(&order).Update("amount", 20)
+	db.Delete(&order)
+	db.Create(&User{Name: "not audited"})
+
+	if len(entries) != 3 {
+		t.Fatalf("Changes of audited tables should be recorded, but got %d entries", len(entries))
+	}
+
+	for i, action := range []string{gorm.AuditCreate, gorm.AuditUpdate, gorm.AuditDelete} {
+		if entries[i].Action != action || entries[i].Actor != "auditor" || entries[i].Table != "audited_orders" {
+			t.Errorf("Expected %v entry of audited_orders by auditor, but got %+v", action, entries[i])
+		}
+	}
+
+	changes := entries[1].Changes
+	if len(changes) != 1 || changes[0].Column != "amount" || changes[0].Before != int64(10) || changes[0].After != int64(20) {
+		t.Errorf("Update should record the changed columns with their values, but got %+v", changes)
+	}
+
+	var count int
+	DB.Table("audited_order_logs").Where("actor = ?", "auditor").Count(&count)
+	if count != 3 {
+		t.Errorf("TableAuditor should insert audit logs, but got %d", count)
+	}
+
+	db.SetAuditor(gorm.AuditorFunc(func(tx *gorm.DB, entry *gorm.AuditEntry) error {
+		return gorm.ErrInvalidSQL
+	}), &AuditedOrder{})
+
+	if err := db.Create(&AuditedOrder{Number: "A2"}).Error; err != gorm.ErrInvalidSQL {
+		t.Errorf("Errors of the auditor should be returned, but got %v", err)
+	}
+	if !DB.First(&AuditedOrder{}, "number = ?", "A2").RecordNotFound() {
+		t.Errorf("Changes should be rolled back when the auditor fails")
+	}
+}
@@ -1,6 +1,7 @@
 package gorm
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 )
@@ -15,6 +16,7 @@ func init() {
 	DefaultCallback.Create().Register("gorm:force_reload_after_create", forceReloadAfterCreateCallback)
 	DefaultCallback.Create().Register("gorm:save_after_associations", saveAfterAssociationsCallback)
 	DefaultCallback.Create().Register("gorm:after_create", afterCreateCallback)
+	DefaultCallback.Create().Register("gorm:audit", auditCreateCallback)
 	DefaultCallback.Create().Register("gorm:commit_or_rollback_transaction", commitOrRollbackTransactionCallback)
 }
 
@@ -53,8 +55,8 @@ func createCallback(scope *Scope) {
 		defer scope.trace(NowFunc())
 
 		var (
-			columns, placeholders        []string
-			blankColumnsWithDefaultValue []string
+			columns, columnNames, placeholders []string
+			blankColumnsWithDefaultValue       []string
 		)
 
 		for _, field := range scope.Fields() {
@@ -65,12 +67,14 @@ func createCallback(scope *Scope) {
 						scope.InstanceSet("gorm:blank_columns_with_default_value", blankColumnsWithDefaultValue)
 					} else if !field.IsPrimaryKey || !field.IsBlank {
 						columns = append(columns, scope.Quote(field.DBName))
+						columnNames = append(columnNames, field.DBName)
 						placeholders = append(placeholders, scope.AddToVars(field.Field.Interface()))
 					}
 				} else if field.Relationship != nil && field.Relationship.Kind == "belongs_to" {
 					for _, foreignKey := range field.Relationship.ForeignDBNames {
 						if foreignField, ok := scope.FieldByName(foreignKey); ok && !scope.changeableField(foreignField) {
 							columns = append(columns, scope.Quote(foreignField.DBName))
+							columnNames = append(columnNames, foreignField.DBName)
 							placeholders = append(placeholders, scope.AddToVars(foreignField.Field.Interface()))
 						}
 					}
@@ -101,8 +105,30 @@ func createCallback(scope *Scope) {
 		}
 
 		lastInsertIDReturningSuffix := scope.Dialect().LastInsertIDReturningSuffix(quotedTableName, returningColumn)
+		onConflict, upsert := scope.onConflict(columnNames)
 
-		if len(columns) == 0 {
+		if upsert && len(columns) > 0 {
+			dialect, ok := scope.Dialect().(UpsertDialect)
+			if !ok {
+				scope.Err(ErrUpsertNotSupported)
+				return
+			}
+			upsertSQL, err := dialect.UpsertSQL(quotedTableName, columnNames, []string{"(" + strings.Join(placeholders, ",") + ")"}, onConflict)
+			if scope.Err(err) != nil {
+				return
+			}
+			if insertModifier != "" {
+				if !strings.HasPrefix(upsertSQL, "INSERT ") {
+					scope.Err(ErrUpsertModifierNotSupported)
+					return
+				}
+				upsertSQL = "INSERT " + insertModifier + strings.TrimPrefix(upsertSQL, "INSERT")
+			}
+			scope.Raw(upsertSQL +
+				addExtraSpaceIfExist(extraOption) +
+				addExtraSpaceIfExist(lastInsertIDReturningSuffix),
+			)
+		} else if len(columns) == 0 {
 			scope.Raw(fmt.Sprintf(
 				"INSERT %v INTO %v %v%v%v",
 				addExtraSpaceIfExist(insertModifier),
@@ -125,12 +151,13 @@ func createCallback(scope *Scope) {
 
 		// execute create sql
 		if lastInsertIDReturningSuffix == "" || primaryField == nil {
-			if result, err := scope.SQLDB().Exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+			if result, err := scope.exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
 				// set rows affected count
 				scope.db.RowsAffected, _ = result.RowsAffected()
 
-				// set primary value to primary field
-				if primaryField != nil && primaryField.IsBlank {
+				// set primary value to primary field, the last insert id of an upsert which skipped or updated a row
+				// (MySQL reports 2 rows affected for updates) isn't the one of the record
+				if primaryField != nil && primaryField.IsBlank && (!upsert || scope.db.RowsAffected == 1) {
 					if primaryValue, err := result.LastInsertId(); scope.Err(err) == nil {
 						scope.Err(primaryField.Set(primaryValue))
 					}
@@ -138,7 +165,10 @@ func createCallback(scope *Scope) {
 			}
 		} else {
 			if primaryField.Field.CanAddr() {
-				if err := scope.SQLDB().QueryRow(scope.SQL, scope.SQLVars...).Scan(primaryField.Field.Addr().Interface()); scope.Err(err) == nil {
+				if err := scope.queryRow(scope.SQL, scope.SQLVars...).Scan(primaryField.Field.Addr().Interface()); err == sql.ErrNoRows && upsert {
+					// The record conflicted with an existing row and was skipped
+					scope.db.RowsAffected = 0
+				} else if scope.Err(err) == nil {
 					primaryField.IsBlank = false
 					scope.db.RowsAffected = 1
 				}
@@ -8,9 +8,11 @@ import (
 // Define callbacks for deleting
 func init() {
 	DefaultCallback.Delete().Register("gorm:begin_transaction", beginTransactionCallback)
+	DefaultCallback.Delete().Register("gorm:audit_load", auditLoadCallback)
 	DefaultCallback.Delete().Register("gorm:before_delete", beforeDeleteCallback)
 	DefaultCallback.Delete().Register("gorm:delete", deleteCallback)
 	DefaultCallback.Delete().Register("gorm:after_delete", afterDeleteCallback)
+	DefaultCallback.Delete().Register("gorm:audit", auditDeleteCallback)
 	DefaultCallback.Delete().Register("gorm:commit_or_rollback_transaction", commitOrRollbackTransactionCallback)
 }
 
@@ -25,7 +27,7 @@ func beforeDeleteCallback(scope *Scope) {
 	}
 }
 
-// deleteCallback used to delete data from database or set deleted_at to current time (when using with soft delete)
+// deleteCallback used to delete data from database or mark it as deleted in its soft delete column, e.g. set deleted_at to current time
 func deleteCallback(scope *Scope) {
 	if !scope.HasError() {
 		var extraOption string
@@ -33,14 +35,14 @@ func deleteCallback(scope *Scope) {
 			extraOption = fmt.Sprint(str)
 		}
 
-		deletedAtField, hasDeletedAtField := scope.FieldByName("DeletedAt")
+		softDeleteField, softDelete, hasSoftDelete := scope.softDeleteField()
 
-		if !scope.Search.Unscoped && hasDeletedAtField {
+		if !scope.Search.Unscoped && hasSoftDelete {
 			scope.Raw(fmt.Sprintf(
 				"UPDATE %v SET %v=%v%v%v",
 				scope.QuotedTableName(),
-				scope.Quote(deletedAtField.DBName),
-				scope.AddToVars(NowFunc()),
+				scope.Quote(softDeleteField.DBName),
+				scope.AddToVars(softDelete.DeletedValue()),
 				addExtraSpaceIfExist(scope.CombinedConditionSql()),
 				addExtraSpaceIfExist(extraOption),
 			)).Exec()
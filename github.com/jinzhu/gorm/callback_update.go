@@ -3,6 +3,7 @@ package gorm
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 )
@@ -11,13 +12,16 @@ import (
 func init() {
 	DefaultCallback.Update().Register("gorm:assign_updating_attributes", assignUpdatingAttributesCallback)
 	DefaultCallback.Update().Register("gorm:begin_transaction", beginTransactionCallback)
+	DefaultCallback.Update().Register("gorm:audit_load", auditLoadCallback)
 	DefaultCallback.Update().Register("gorm:before_update", beforeUpdateCallback)
 	DefaultCallback.Update().Register("gorm:save_before_associations", saveBeforeAssociationsCallback)
 	DefaultCallback.Update().Register("gorm:update_time_stamp", updateTimeStampForUpdateCallback)
 	DefaultCallback.Update().Register("gorm:update", updateCallback)
 	DefaultCallback.Update().Register("gorm:save_after_associations", saveAfterAssociationsCallback)
 	DefaultCallback.Update().Register("gorm:after_update", afterUpdateCallback)
+	DefaultCallback.Update().Register("gorm:audit", auditUpdateCallback)
 	DefaultCallback.Update().Register("gorm:commit_or_rollback_transaction", commitOrRollbackTransactionCallback)
+	DefaultCallback.Update().After("gorm:commit_or_rollback_transaction").Register("gorm:increase_version", increaseVersionCallback)
 }
 
 // assignUpdatingAttributesCallback assign updating attributes to model
@@ -58,6 +62,7 @@ func updateTimeStampForUpdateCallback(scope *Scope) {
 func updateCallback(scope *Scope) {
 	if !scope.HasError() {
 		var sqls []string
+		versionField, versioned := scope.versionField()
 
 		if updateAttrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
 			// Sort the column names so that the generated SQL is the same every time.
@@ -69,13 +74,18 @@ func updateCallback(scope *Scope) {
 			sort.Strings(columns)
 
 			for _, column := range columns {
+				if versioned && column == versionField.DBName {
+					continue
+				}
 				value := updateMap[column]
 				sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(column), scope.AddToVars(value)))
 			}
 		} else {
 			for _, field := range scope.Fields() {
 				if scope.changeableField(field) {
-					if !field.IsPrimaryKey && field.IsNormal && (field.Name != "CreatedAt" || !field.IsBlank) {
+					if versioned && field.DBName == versionField.DBName {
+						continue
+					} else if !field.IsPrimaryKey && field.IsNormal && (field.Name != "CreatedAt" || !field.IsBlank) {
 						if !field.IsForeignKey || !field.IsBlank || !field.HasDefaultValue {
 							sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface())))
 						}
@@ -97,6 +107,16 @@ func updateCallback(scope *Scope) {
 		}
 
 		if len(sqls) > 0 {
+			// Optimistic locking, increase the version and only update the record if its version is unchanged
+			var checkVersion bool
+			if versioned {
+				quotedVersion := scope.Quote(versionField.DBName)
+				sqls = append(sqls, fmt.Sprintf("%v = %v + 1", quotedVersion, quotedVersion))
+				if checkVersion = !scope.PrimaryKeyZero(); checkVersion {
+					scope.Search.Where(fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), quotedVersion), versionField.Field.Interface())
+				}
+			}
+
 			scope.Raw(fmt.Sprintf(
 				"UPDATE %v SET %v%v%v",
 				scope.QuotedTableName(),
@@ -104,8 +124,48 @@ func updateCallback(scope *Scope) {
 				addExtraSpaceIfExist(scope.CombinedConditionSql()),
 				addExtraSpaceIfExist(extraOption),
 			)).Exec()
+
+			if checkVersion && !scope.HasError() {
+				if scope.db.RowsAffected == 0 {
+					scope.Err(ErrStaleObject)
+				} else {
+					scope.InstanceSet("gorm:increase_version", versionField.Field)
+				}
+			}
+		}
+	}
+}
+
+// versionField return the optimistic locking version field of the model, tagged `gorm:"version"`, unless updating with `UpdateColumn`
+func (scope *Scope) versionField() (*Field, bool) {
+	if _, ok := scope.Get("gorm:update_column"); ok {
+		return nil, false
+	}
+	for _, field := range scope.Fields() {
+		if _, ok := field.TagSettingsGet("VERSION"); ok && field.IsNormal {
+			return field, true
 		}
 	}
+	return nil, false
+}
+
+// increaseVersionCallback increase the version of the updated record once the update is committed, so that a rolled back update keeps it
+func increaseVersionCallback(scope *Scope) {
+	if field, ok := scope.InstanceGet("gorm:increase_version"); ok && !scope.HasError() {
+		increaseVersion(field.(reflect.Value))
+	}
+}
+
+func increaseVersion(field reflect.Value) {
+	if !field.CanSet() {
+		return
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(field.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(field.Uint() + 1)
+	}
 }
 
 // afterUpdateCallback will invoke `AfterUpdate`, `AfterSave` method after updating
@@ -3,9 +3,11 @@ package gorm_test
 import (
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jinzhu/gorm"
 	"github.com/jinzhu/now"
 )
 
@@ -246,3 +248,142 @@ func TestCreateIgnore(t *testing.T) {
 		t.Error("Should ignore duplicate user insert by insert modifier:IGNORE ")
 	}
 }
+
+type BatchProduct struct {
+	ID        uint
+	Code      string `gorm:"unique_index"`
+	Price     int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func TestCreateInBatches(t *testing.T) {
+	DB.DropTableIfExists(&BatchProduct{})
+	DB.AutoMigrate(&BatchProduct{})
+
+	products := []BatchProduct{{Code: "B1", Price: 1}, {Code: "B2", Price: 2}, {Code: "B3", Price: 3}, {Code: "B4", Price: 4}, {Code: "B5", Price: 5}}
+	if db := DB.CreateInBatches(&products, 2); db.Error != nil || db.RowsAffected != 5 {
+		t.Errorf("No error should happen when creating in batches, but got %v, %v rows affected", db.Error, db.RowsAffected)
+	}
+
+	var count int
+	DB.Model(&BatchProduct{}).Count(&count)
+	if count != 5 {
+		t.Errorf("All records should be created, but got %v", count)
+	}
+
+	for _, product := range products {
+		if product.CreatedAt.IsZero() || product.UpdatedAt.IsZero() {
+			t.Errorf("Records created in batches should have timestamps")
+		}
+		if DB.Dialect().GetName() == "postgres" && product.ID == 0 {
+			t.Errorf("Records created in batches should have their primary key set when the dialect returns it")
+		}
+	}
+
+	if err := DB.CreateInBatches(BatchProduct{Code: "B6"}, 2).Error; err == nil {
+		t.Errorf("Should get error when creating a struct in batches")
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	DB.DropTableIfExists(&BatchProduct{})
+	DB.AutoMigrate(&BatchProduct{})
+
+	DB.Create(&BatchProduct{Code: "U1", Price: 1})
+
+	skipped := BatchProduct{Code: "U1", Price: 2}
+	if err := DB.OnConflict(gorm.OnConflict{Columns: []string{"code"}, DoNothing: true}).Create(&skipped).Error; err != nil {
+		t.Errorf("No error should happen when creating a conflicting record with DoNothing, but got %v", err)
+	}
+	if skipped.ID != 0 {
+		t.Errorf("Skipped record should not get a primary key, but got %v", skipped.ID)
+	}
+
+	var statements []string
+	db := DB.New()
+	db.SetStructuredLogger(gorm.StructuredLoggerFunc(func(event *gorm.LogEvent) {
+		statements = append(statements, event.SQL)
+	}), gorm.LogConfig{})
+	modifier := "IGNORE"
+	if DB.Dialect().GetName() == "sqlite3" {
+		modifier = "OR IGNORE"
+	}
+	if DB.Dialect().GetName() != "mssql" {
+		db.Set("gorm:insert_modifier", modifier).OnConflict(gorm.OnConflict{Columns: []string{"code"}, DoNothing: true}).Create(&BatchProduct{Code: "U1", Price: 2})
+		if len(statements) != 1 || !strings.HasPrefix(statements[0], "INSERT "+modifier+" INTO") {
+			t.Errorf("Upsert should keep the insert modifier, but got %v", statements)
+		}
+	}
+
+	var product BatchProduct
+	if DB.First(&product, "code = ?", "U1"); product.Price != 1 {
+		t.Errorf("Conflicting record should be skipped with DoNothing, but got price %v", product.Price)
+	}
+
+	if err := DB.OnConflict(gorm.OnConflict{Columns: []string{"code"}, DoUpdates: []string{"price"}}).Create(&BatchProduct{Code: "U1", Price: 3}).Error; err != nil {
+		t.Errorf("No error should happen when upserting, but got %v", err)
+	}
+
+	if DB.First(&product, "code = ?", "U1"); product.Price != 3 {
+		t.Errorf("Conflicting record should update price, but got price %v", product.Price)
+	}
+
+	products := []BatchProduct{{Code: "U1", Price: 4}, {Code: "U2", Price: 5}}
+	if err := DB.OnConflict(gorm.OnConflict{Columns: []string{"code"}, UpdateAll: true}).CreateInBatches(&products, 10).Error; err != nil {
+		t.Errorf("No error should happen when upserting in batches, but got %v", err)
+	}
+
+	var results []BatchProduct
+	DB.Order("code").Find(&results)
+	if len(results) != 2 || results[0].Price != 4 || results[1].Price != 5 {
+		t.Errorf("Upsert in batches should update conflicting records and create others, but got %+v", results)
+	}
+
+	if !results[0].CreatedAt.Equal(product.CreatedAt) {
+		t.Errorf("UpdateAll should not update created_at")
+	}
+}
+
+func TestUpsertSQL(t *testing.T) {
+	rows := []string{"(?,?)", "(?,?)"}
+	tests := []struct {
+		dialect    string
+		onConflict gorm.OnConflict
+		sql        string
+		err        error
+	}{
+		{"postgres", gorm.OnConflict{Columns: []string{"code"}},
+			`INSERT INTO "products" ("code","price") VALUES (?,?),(?,?) ON CONFLICT ("code") DO NOTHING`, nil},
+		{"postgres", gorm.OnConflict{Columns: []string{"code"}, DoUpdates: []string{"price"}},
+			`INSERT INTO "products" ("code","price") VALUES (?,?),(?,?) ON CONFLICT ("code") DO UPDATE SET "price" = excluded."price"`, nil},
+		{"sqlite3", gorm.OnConflict{},
+			`INSERT INTO "products" ("code","price") VALUES (?,?),(?,?) ON CONFLICT DO NOTHING`, nil},
+		{"mysql", gorm.OnConflict{Columns: []string{"code"}},
+			"INSERT INTO `products` (`code`,`price`) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE `code` = `code`", nil},
+		{"mysql", gorm.OnConflict{},
+			"INSERT INTO `products` (`code`,`price`) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE `code` = `code`", nil},
+		{"mysql", gorm.OnConflict{Columns: []string{"code"}, DoUpdates: []string{"price"}},
+			"INSERT INTO `products` (`code`,`price`) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE `price` = VALUES(`price`)", nil},
+		{"mssql", gorm.OnConflict{Columns: []string{"code"}},
+			"MERGE INTO [products] WITH (HOLDLOCK) AS target USING (VALUES (?,?),(?,?)) AS source ([code],[price]) ON target.[code] = source.[code]" +
+				" WHEN NOT MATCHED THEN INSERT ([code],[price]) VALUES (source.[code],source.[price]);", nil},
+		{"mssql", gorm.OnConflict{Columns: []string{"code", "price"}, DoUpdates: []string{"price"}},
+			"MERGE INTO [products] WITH (HOLDLOCK) AS target USING (VALUES (?,?),(?,?)) AS source ([code],[price]) ON target.[code] = source.[code] AND target.[price] = source.[price]" +
+				" WHEN MATCHED THEN UPDATE SET target.[price] = source.[price] WHEN NOT MATCHED THEN INSERT ([code],[price]) VALUES (source.[code],source.[price]);", nil},
+		{"mssql", gorm.OnConflict{DoUpdates: []string{"price"}}, "", gorm.ErrUpsertColumnsRequired},
+	}
+
+	for _, test := range tests {
+		dialect, _ := gorm.GetDialect(test.dialect)
+		upsertDialect, ok := dialect.(gorm.UpsertDialect)
+		if !ok {
+			t.Errorf("%v should be an UpsertDialect", test.dialect)
+			continue
+		}
+		sql, err := upsertDialect.UpsertSQL(dialect.Quote("products"), []string{"code", "price"}, rows, test.onConflict)
+		if sql != test.sql || err != test.err {
+			t.Errorf("%v upsert with %+v should be %q, %v, but got %q, %v", test.dialect, test.onConflict, test.sql, test.err, sql, err)
+		}
+	}
+}
@@ -89,3 +89,44 @@ func TestSoftDeleteWithCustomizedDeletedAtColumnName(t *testing.T) {
 		t.Errorf("Can't find permanently deleted record")
 	}
 }
+
+func TestSoftDeleteStrategies(t *testing.T) {
+	type FlagUser struct {
+		Id      int64
+		Name    string
+		Deleted bool `gorm:"soft_delete"`
+	}
+	type UnixUser struct {
+		Id        int64
+		Name      string
+		DeletedAt int64 `gorm:"soft_delete:unix"`
+	}
+	DB.DropTableIfExists(&FlagUser{}, &UnixUser{})
+	DB.AutoMigrate(&FlagUser{}, &UnixUser{})
+
+	flagUser := FlagUser{Name: "soft_delete_flag"}
+	DB.Save(&flagUser)
+	DB.Delete(&flagUser)
+
+	if DB.First(&FlagUser{}, "name = ?", flagUser.Name).Error == nil {
+		t.Errorf("Can't find a soft deleted record")
+	}
+
+	var deletedFlagUser FlagUser
+	if err := DB.Unscoped().First(&deletedFlagUser, "name = ?", flagUser.Name).Error; err != nil || !deletedFlagUser.Deleted {
+		t.Errorf("Soft deleted record should be flagged as deleted, but err=%v", err)
+	}
+
+	unixUser := UnixUser{Name: "soft_delete_unix"}
+	DB.Save(&unixUser)
+	DB.Delete(&unixUser)
+
+	if DB.First(&UnixUser{}, "name = ?", unixUser.Name).Error == nil {
+		t.Errorf("Can't find a soft deleted record")
+	}
+
+	var deletedUnixUser UnixUser
+	if err := DB.Unscoped().First(&deletedUnixUser, "name = ?", unixUser.Name).Error; err != nil || deletedUnixUser.DeletedAt == 0 {
+		t.Errorf("Soft deleted record should have its deletion time, but err=%v", err)
+	}
+}
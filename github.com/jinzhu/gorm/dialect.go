@@ -52,6 +52,26 @@ type Dialect interface {
 	CurrentDatabase() string
 }
 
+// UpsertDialect is implemented by dialects supporting upserts, inserts which update or skip the rows conflicting with existing rows
+type UpsertDialect interface {
+	// UpsertSQL return the statement inserting rows, lists of placeholders like "(?,?)", into the columns of quotedTableName.
+	// onConflict.Columns are the conflicting columns, a conflicting row updates onConflict.DoUpdates, or is skipped if there are none.
+	// It returns ErrUpsertColumnsRequired if the dialect needs conflicting columns and there are none
+	UpsertSQL(quotedTableName string, columns []string, rows []string, onConflict OnConflict) (string, error)
+}
+
+// OnConflict specify how `Create` and `CreateInBatches` handle the records conflicting with existing rows, set it with `DB.OnConflict`
+type OnConflict struct {
+	// Columns are the columns of the unique constraint, the primary keys if empty, they are ignored by MySQL
+	Columns []string
+	// DoNothing skip conflicting records, it is the default if no update is set
+	DoNothing bool
+	// DoUpdates are the columns of existing rows updated to the values of conflicting records
+	DoUpdates []string
+	// UpdateAll update all inserted columns except the primary keys, Columns and `created_at`
+	UpdateAll bool
+}
+
 var dialectsMap = map[string]Dialect{}
 
 func newDialect(name string, db SQLCommon) Dialect {
@@ -163,6 +163,23 @@ func (commonDialect) DefaultValueStr() string {
 	return "DEFAULT VALUES"
 }
 
+// UpsertSQL uses `ON CONFLICT`, supported by Postgres and SQLite
+func (s commonDialect) UpsertSQL(quotedTableName string, columns []string, rows []string, onConflict OnConflict) (string, error) {
+	sql := fmt.Sprintf("INSERT INTO %v (%v) VALUES %v ON CONFLICT", quotedTableName, strings.Join(quoteColumns(s, columns), ","), strings.Join(rows, ","))
+	if len(onConflict.Columns) > 0 {
+		sql += fmt.Sprintf(" (%v)", strings.Join(quoteColumns(s, onConflict.Columns), ","))
+	}
+	if len(onConflict.DoUpdates) == 0 {
+		return sql + " DO NOTHING", nil
+	}
+
+	var updates []string
+	for _, column := range onConflict.DoUpdates {
+		updates = append(updates, fmt.Sprintf("%v = excluded.%v", s.Quote(column), s.Quote(column)))
+	}
+	return sql + " DO UPDATE SET " + strings.Join(updates, ","), nil
+}
+
 // BuildKeyName returns a valid key name (foreign key, index key) for the given table, field and reference
 func (DefaultForeignKeyNamer) BuildKeyName(kind, tableName string, fields ...string) string {
 	keyName := fmt.Sprintf("%s_%s_%s", kind, tableName, strings.Join(fields, "_"))
@@ -174,3 +191,10 @@ func (DefaultForeignKeyNamer) BuildKeyName(kind, tableName string, fields ...str
 func IsByteArrayOrSlice(value reflect.Value) bool {
 	return (value.Kind() == reflect.Array || value.Kind() == reflect.Slice) && value.Type().Elem() == reflect.TypeOf(uint8(0))
 }
+
+func quoteColumns(dialect interface{ Quote(string) string }, columns []string) (quoted []string) {
+	for _, column := range columns {
+		quoted = append(quoted, dialect.Quote(column))
+	}
+	return
+}
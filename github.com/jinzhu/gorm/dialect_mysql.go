@@ -189,3 +189,19 @@ func (s mysql) BuildKeyName(kind, tableName string, fields ...string) string {
 func (mysql) DefaultValueStr() string {
 	return "VALUES()"
 }
+
+// UpsertSQL uses `ON DUPLICATE KEY UPDATE`, which handles conflicts on any unique key, a skipped row assigns a column to itself
+func (s mysql) UpsertSQL(quotedTableName string, columns []string, rows []string, onConflict OnConflict) (string, error) {
+	var updates []string
+	for _, column := range onConflict.DoUpdates {
+		updates = append(updates, fmt.Sprintf("%v = VALUES(%v)", s.Quote(column), s.Quote(column)))
+	}
+	if len(updates) == 0 {
+		column := columns[0]
+		if len(onConflict.Columns) > 0 {
+			column = onConflict.Columns[0]
+		}
+		updates = append(updates, fmt.Sprintf("%v = %v", s.Quote(column), s.Quote(column)))
+	}
+	return fmt.Sprintf("INSERT INTO %v (%v) VALUES %v ON DUPLICATE KEY UPDATE %v", quotedTableName, strings.Join(quoteColumns(s, columns), ","), strings.Join(rows, ","), strings.Join(updates, ",")), nil
+}
@@ -198,6 +198,32 @@ func (mssql) DefaultValueStr() string {
 	return "DEFAULT VALUES"
 }
 
+// UpsertSQL uses `MERGE`, matching rows on the conflicting columns, which are required
+func (s mssql) UpsertSQL(quotedTableName string, columns []string, rows []string, onConflict gorm.OnConflict) (string, error) {
+	if len(onConflict.Columns) == 0 {
+		return "", gorm.ErrUpsertColumnsRequired
+	}
+
+	var quotedColumns, sourceColumns, conditions, updates []string
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, s.Quote(column))
+		sourceColumns = append(sourceColumns, "source."+s.Quote(column))
+	}
+	for _, column := range onConflict.Columns {
+		conditions = append(conditions, fmt.Sprintf("target.%v = source.%v", s.Quote(column), s.Quote(column)))
+	}
+	for _, column := range onConflict.DoUpdates {
+		updates = append(updates, fmt.Sprintf("target.%v = source.%v", s.Quote(column), s.Quote(column)))
+	}
+
+	sql := fmt.Sprintf("MERGE INTO %v WITH (HOLDLOCK) AS target USING (VALUES %v) AS source (%v) ON %v",
+		quotedTableName, strings.Join(rows, ","), strings.Join(quotedColumns, ","), strings.Join(conditions, " AND "))
+	if len(updates) > 0 {
+		sql += " WHEN MATCHED THEN UPDATE SET " + strings.Join(updates, ",")
+	}
+	return sql + fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%v) VALUES (%v);", strings.Join(quotedColumns, ","), strings.Join(sourceColumns, ",")), nil
+}
+
 func currentDatabaseAndTable(dialect gorm.Dialect, tableName string) (string, string) {
 	if strings.Contains(tableName, ".") {
 		splitStrings := strings.SplitN(tableName, ".", 2)
@@ -16,6 +16,24 @@ var (
 	ErrCantStartTransaction = errors.New("can't start transaction")
 	// ErrUnaddressable unaddressable value
 	ErrUnaddressable = errors.New("using unaddressable value")
+	// ErrUpsertNotSupported occurs when creating with `OnConflict` on a dialect which is not an UpsertDialect
+	ErrUpsertNotSupported = errors.New("upsert not supported by dialect")
+	// ErrUpsertColumnsRequired occurs when upserting without `OnConflict.Columns` into a table without primary key, on a dialect which needs them to match rows
+	ErrUpsertColumnsRequired = errors.New("upsert requires conflicting columns")
+	// ErrUpsertModifierNotSupported occurs when creating with `OnConflict` and a `gorm:insert_modifier` on a dialect whose upserts aren't INSERT statements
+	ErrUpsertModifierNotSupported = errors.New("insert modifier not supported by upsert of dialect")
+	// ErrStaleObject occurs when saving or updating a record with a version column that was changed since it was loaded
+	ErrStaleObject = errors.New("stale object")
+	// ErrShardKeyRequired occurs when the sharding key of an operation on a sharded table is neither set with `ShardKey` nor in the record
+	ErrShardKeyRequired = errors.New("sharding key required")
+	// ErrMigrationModified occurs when the SQL of a migration changed after it was applied
+	ErrMigrationModified = errors.New("migration modified after it was applied")
+	// ErrIrreversibleMigration occurs when rolling back a migration that has no down migration
+	ErrIrreversibleMigration = errors.New("irreversible migration")
+	// ErrMigrationNotFound occurs when migrating or rolling back to an unknown migration
+	ErrMigrationNotFound = errors.New("migration not found")
+	// ErrMigrationLocked occurs when another migrator holds the migration lock longer than `Migrator.LockTimeout`
+	ErrMigrationLocked = errors.New("migrations locked by another migrator")
 )
 
 // Errors contains all happened errors
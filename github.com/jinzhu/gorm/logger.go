@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 	"unicode"
 )
@@ -122,3 +123,279 @@ type Logger struct {
 func (logger Logger) Print(values ...interface{}) {
 	logger.Println(LogFormatter(values...)...)
 }
+
+// LogLevel is the level of a LogEvent
+type LogLevel int
+
+const (
+	// LogInfo is the level of statements which are neither slow nor failed
+	LogInfo LogLevel = iota
+	// LogWarn is the level of slow statements
+	LogWarn
+	// LogError is the level of failed statements
+	LogError
+	// LogSilent as minimum level of a LogConfig disables logging
+	LogSilent
+)
+
+func (level LogLevel) String() string {
+	switch level {
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	case LogError:
+		return "error"
+	}
+	return "silent"
+}
+
+// LogEvent is a statement run by a DB connection, passed to a StructuredLogger
+type LogEvent struct {
+	Level LogLevel
+	// SQL is the statement with its placeholders, values are not interpolated
+	SQL string
+	// Vars are the arguments of the placeholders, values of redacted columns are replaced with "[REDACTED]"
+	Vars         []interface{}
+	RowsAffected int64
+	Duration     time.Duration
+	Slow         bool
+	Error        error
+	// Caller is the file and line which ran the statement
+	Caller string
+}
+
+// StructuredLogger receives the statements of a DB connection as structured events
+type StructuredLogger interface {
+	LogEvent(event *LogEvent)
+}
+
+// StructuredLoggerFunc is an adapter to use a function as a StructuredLogger
+type StructuredLoggerFunc func(event *LogEvent)
+
+// LogEvent call f(event)
+func (f StructuredLoggerFunc) LogEvent(event *LogEvent) {
+	f(event)
+}
+
+// LogConfig configures the events sent to a StructuredLogger
+type LogConfig struct {
+	// Level is the minimum level of logged events, LogInfo logs all statements, LogWarn slow and failed statements, LogError failed statements
+	Level LogLevel
+	// SlowThreshold is the duration from which statements are slow, no statement is slow if zero
+	SlowThreshold time.Duration
+	// RedactedColumns are the columns whose values are replaced with "[REDACTED]" in logs, e.g. passwords.
+	// When set, values whose column can not be told from the SQL are replaced too
+	RedactedColumns []string
+}
+
+type structuredLogger struct {
+	logger   StructuredLogger
+	config   LogConfig
+	redacted map[string]bool
+}
+
+const redactedValue = "[REDACTED]"
+
+// SetStructuredLogger send the statements of the DB connection to logger as events, the values of redacted columns are also redacted in the logs of `LogMode`
+//     db.SetStructuredLogger(logger, gorm.LogConfig{Level: gorm.LogWarn, SlowThreshold: 200 * time.Millisecond, RedactedColumns: []string{"password"}})
+func (s *DB) SetStructuredLogger(logger StructuredLogger, config LogConfig) *DB {
+	redacted := map[string]bool{}
+	for _, column := range config.RedactedColumns {
+		redacted[strings.ToLower(column)] = true
+	}
+	s.structuredLogger = &structuredLogger{logger: logger, config: config, redacted: redacted}
+	return s
+}
+
+func (l *structuredLogger) log(sql string, vars []interface{}, duration time.Duration, rowsAffected int64, err error) {
+	event := &LogEvent{
+		Level:        LogInfo,
+		SQL:          sql,
+		Vars:         vars,
+		RowsAffected: rowsAffected,
+		Duration:     duration,
+		Slow:         l.config.SlowThreshold > 0 && duration >= l.config.SlowThreshold,
+	}
+	if err != nil && !IsRecordNotFoundError(err) {
+		event.Level, event.Error = LogError, err
+	} else if event.Slow {
+		event.Level = LogWarn
+	}
+
+	if event.Level >= l.config.Level && l.config.Level != LogSilent {
+		event.Caller = fileWithLineNum()
+		l.logger.LogEvent(event)
+	}
+}
+
+// redact return vars with the values of redacted columns replaced, values whose column is unknown are also replaced
+func (l *structuredLogger) redact(sql string, vars []interface{}) []interface{} {
+	if len(l.redacted) == 0 || len(vars) == 0 {
+		return vars
+	}
+
+	result := make([]interface{}, len(vars))
+	columns := placeholderColumns(sql)
+	for index, value := range vars {
+		if index >= len(columns) || columns[index] == "" || l.redacted[strings.ToLower(columns[index])] {
+			value = redactedValue
+		}
+		result[index] = value
+	}
+	return result
+}
+
+// LevelLogger is a StructuredLogger printing the events of each level to its writer, events of levels without writer are discarded
+type LevelLogger struct {
+	Info  LogWriter
+	Warn  LogWriter
+	Error LogWriter
+}
+
+// LogEvent print event to the writer of its level
+func (logger LevelLogger) LogEvent(event *LogEvent) {
+	writer := map[LogLevel]LogWriter{LogInfo: logger.Info, LogWarn: logger.Warn, LogError: logger.Error}[event.Level]
+	if writer == nil {
+		return
+	}
+
+	message := fmt.Sprintf("[%v] (%v) [%.2fms] [rows:%v] %v", event.Level, event.Caller, float64(event.Duration.Nanoseconds()/1e4)/100.0, event.RowsAffected, event.SQL)
+	if len(event.Vars) > 0 {
+		message += fmt.Sprintf(" %v", event.Vars)
+	}
+	if event.Error != nil {
+		message += fmt.Sprintf(" error: %v", event.Error)
+	}
+	writer.Println(message)
+}
+
+// sqlKeywords are not column names, the ones mapped to true separate clauses, so that following placeholders are not compared with the previous column
+var sqlKeywords = map[string]bool{
+	"WHERE": true, "AND": true, "OR": true, "SET": true, "LIMIT": true, "OFFSET": true, "HAVING": true, "ON": true,
+	"SELECT": true, "FROM": true, "ORDER": true, "GROUP": true, "BY": true, "VALUES": true, "RETURNING": true,
+	"IN": false, "LIKE": false, "ILIKE": false, "NOT": false, "IS": false, "NULL": false, "BETWEEN": false, "AS": false,
+	"ASC": false, "DESC": false, "INSERT": false, "INTO": false, "UPDATE": false, "DELETE": false, "DISTINCT": false,
+	"TRUE": false, "FALSE": false, "JOIN": false, "LEFT": false, "RIGHT": false, "INNER": false, "OUTER": false,
+}
+
+// placeholderColumns return the column compared with or assigned to the placeholder of each var of sql, or an empty string if unknown.
+// Placeholders of INSERT statements belong to the column at their position in the column list, other placeholders to the last column before them,
+// function names are not columns, so that the arguments of a function belong to the column before it
+func placeholderColumns(sql string) []string {
+	var (
+		columns          []string
+		lastColumn       string
+		insertColumns    []string
+		insert, inValues bool
+		depth, position  int
+		varIndex         int
+	)
+
+	setColumn := func(index int) {
+		column := lastColumn
+		if inValues {
+			column = ""
+			if depth >= 1 && position < len(insertColumns) {
+				column = insertColumns[position]
+			}
+		}
+		for len(columns) <= index {
+			columns = append(columns, "")
+		}
+		columns[index] = column
+	}
+
+	identifier := func(name string) {
+		if insert && !inValues && depth == 1 {
+			insertColumns = append(insertColumns, name)
+		}
+		lastColumn = name
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			// string literal, quotes are escaped by doubling them
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+			i++
+		case c == '"' || c == '`' || c == '[':
+			end := map[byte]byte{'"': '"', '`': '`', '[': ']'}[c]
+			start := i + 1
+			for i = start; i < len(sql) && sql[i] != end; i++ {
+			}
+			identifier(sql[start:i])
+			i++
+		case c == '?':
+			setColumn(varIndex)
+			varIndex++
+			i++
+		case c == '$' && i+1 < len(sql) && unicode.IsDigit(rune(sql[i+1])):
+			start := i + 1
+			for i = start; i < len(sql) && unicode.IsDigit(rune(sql[i])); i++ {
+			}
+			index, _ := strconv.Atoi(sql[start:i])
+			setColumn(index - 1)
+		case c == '(':
+			depth++
+			if depth == 1 {
+				position = 0
+			}
+			i++
+		case c == ')':
+			depth--
+			i++
+		case c == ',':
+			if depth == 1 {
+				position++
+			}
+			i++
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for ; i < len(sql) && (sql[i] == '_' || unicode.IsLetter(rune(sql[i])) || unicode.IsDigit(rune(sql[i]))); i++ {
+			}
+			word := strings.ToUpper(sql[start:i])
+			if separator, keyword := sqlKeywords[word]; keyword {
+				if separator {
+					lastColumn = ""
+				}
+				switch word {
+				case "INSERT":
+					insert = true
+				case "VALUES":
+					inValues = insert
+				case "ON", "RETURNING":
+					inValues = false
+				}
+			} else if !isFunctionCall(sql, i) {
+				identifier(sql[start:i])
+			}
+		default:
+			i++
+		}
+	}
+	return columns
+}
+
+// isFunctionCall report whether the word ending at end is followed by an opening parenthesis, like a function name
+func isFunctionCall(sql string, end int) bool {
+	for ; end < len(sql); end++ {
+		switch sql[end] {
+		case ' ', '\t', '\n', '\r':
+		case '(':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
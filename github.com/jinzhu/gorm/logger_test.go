@@ -0,0 +1,105 @@
+package gorm_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type logRecorder struct {
+	lines []string
+}
+
+func (r *logRecorder) Println(v ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprint(v...))
+}
+
+func TestStructuredLogger(t *testing.T) {
+	var events []*gorm.LogEvent
+	db := DB.New()
+	db.SetStructuredLogger(gorm.StructuredLoggerFunc(func(event *gorm.LogEvent) {
+		events = append(events, event)
+	}), gorm.LogConfig{RedactedColumns: []string{"password_hash", "Password"}})
+
+	user := User{Name: "structured_logger", Age: 20, Password: EncryptedData("secret"), PasswordHash: []byte("hash")}
+	db.Create(&user)
+	defer DB.Unscoped().Delete(&user)
+	db.Where("name = ? AND password_hash = ?", user.Name, user.PasswordHash).First(&User{})
+	db.Exec("UPDATE users SET password = ? WHERE id = ?", EncryptedData("new"), user.Id)
+
+	if len(events) < 3 {
+		t.Fatalf("Statements should be logged as events, but got %d events", len(events))
+	}
+
+	for _, event := range events {
+		if event.Level != gorm.LogInfo || event.Caller == "" || strings.Contains(event.SQL, "structured_logger") {
+			t.Errorf("Event should have placeholders and a caller, but got %+v", event)
+		}
+		for _, v := range event.Vars {
+			if value := fmt.Sprintf("%s", v); value == "secret" || value == "new" || value == "hash" {
+				t.Errorf("Values of redacted columns should not be logged, but got %v in %v", v, event.SQL)
+			}
+		}
+	}
+
+	query := events[len(events)-2]
+	if len(query.Vars) != 2 || query.Vars[0] != user.Name || query.Vars[1] != "[REDACTED]" || query.RowsAffected != 1 {
+		t.Errorf("Query should be logged with its redacted arguments, but got %+v", query)
+	}
+
+	events = nil
+	db.SetStructuredLogger(gorm.StructuredLoggerFunc(func(event *gorm.LogEvent) {
+		events = append(events, event)
+	}), gorm.LogConfig{Level: gorm.LogWarn})
+	db.First(&User{}, user.Id)
+	db.Exec("SELECT * FROM unknown_structured_logger_table")
+
+	if len(events) != 1 || events[0].Level != gorm.LogError || events[0].Error == nil {
+		t.Errorf("Only failed statements should be logged with LogWarn, but got %+v", events)
+	}
+}
+
+func TestStructuredLoggerRedactsUnknownColumns(t *testing.T) {
+	var events []*gorm.LogEvent
+	db := DB.New()
+	db.SetStructuredLogger(gorm.StructuredLoggerFunc(func(event *gorm.LogEvent) {
+		events = append(events, event)
+	}), gorm.LogConfig{RedactedColumns: []string{"password"}})
+
+	// these statements fail on some dialects, but are logged all the same
+	db.Exec("UPDATE users SET password = crypt(?, gen_salt('bf')) WHERE name = ?", "secret", "jinzhu")
+	db.Exec("MERGE INTO users USING (VALUES (?, ?)) AS source (name, password) ON users.name = source.name WHEN NOT MATCHED THEN INSERT (name, password) VALUES (source.name, source.password);", "jinzhu", "secret")
+
+	if len(events) != 2 {
+		t.Fatalf("Statements should be logged as events, but got %d events", len(events))
+	}
+
+	if vars := events[0].Vars; len(vars) != 2 || vars[0] != "[REDACTED]" || vars[1] != "jinzhu" {
+		t.Errorf("Arguments of functions should belong to the column before them, but got %v", vars)
+	}
+
+	if vars := events[1].Vars; len(vars) != 2 || vars[0] != "[REDACTED]" || vars[1] != "[REDACTED]" {
+		t.Errorf("Arguments of unknown columns should be redacted, but got %v", vars)
+	}
+}
+
+func TestStructuredLoggerSlowQuery(t *testing.T) {
+	defer func(nowFunc func() time.Time) { gorm.NowFunc = nowFunc }(gorm.NowFunc)
+	now := time.Now()
+	gorm.NowFunc = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	var info, warn logRecorder
+	db := DB.New()
+	db.SetStructuredLogger(gorm.LevelLogger{Info: &info, Warn: &warn}, gorm.LogConfig{SlowThreshold: 500 * time.Millisecond})
+	db.Find(&[]User{})
+
+	if len(info.lines) != 0 || len(warn.lines) != 1 || !strings.HasPrefix(warn.lines[0], "[warn]") {
+		t.Errorf("Slow statements should be logged to the warn writer, but got %v, %v", info.lines, warn.lines)
+	}
+}
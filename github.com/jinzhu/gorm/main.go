@@ -1,6 +1,7 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -18,9 +19,11 @@ type DB struct {
 
 	// single db
 	db                SQLCommon
+	ctx               context.Context
 	blockGlobalUpdate bool
 	logMode           logModeValue
 	logger            logger
+	structuredLogger  *structuredLogger
 	search            *search
 	values            sync.Map
 
@@ -29,6 +32,8 @@ type DB struct {
 	callbacks     *Callback
 	dialect       Dialect
 	singularTable bool
+	resolver      *Resolver
+	audit         *audit
 }
 
 type logModeValue int
@@ -128,6 +133,26 @@ func (s *DB) CommonDB() SQLCommon {
 	return s.db
 }
 
+// WithContext return a new db connection that passes ctx to the database driver, statements are cancelled when ctx is done
+//     db.WithContext(ctx).Where("name = ?", "jinzhu").First(&user)
+// Use `context.WithTimeout` to set a statement timeout
+func (s *DB) WithContext(ctx context.Context) *DB {
+	if ctx == nil {
+		panic("nil context")
+	}
+	clone := s.clone()
+	clone.ctx = ctx
+	return clone
+}
+
+// Context return the context set with `WithContext`, or `context.Background()` if none
+func (s *DB) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
 // Dialect get dialect
 func (s *DB) Dialect() Dialect {
 	return s.dialect
@@ -444,7 +469,7 @@ func (s *DB) Save(value interface{}) *DB {
 	if !scope.PrimaryKeyZero() {
 		newDB := scope.callCallbacks(s.parent.callbacks.updates).db
 		if newDB.Error == nil && newDB.RowsAffected == 0 {
-			return s.New().FirstOrCreate(value)
+			return s.New().UsePrimary().FirstOrCreate(value)
 		}
 		return newDB
 	}
@@ -457,6 +482,21 @@ func (s *DB) Create(value interface{}) *DB {
 	return scope.callCallbacks(s.parent.callbacks.creates).db
 }
 
+// CreateInBatches insert the records of a slice with multi-row INSERT statements of batchSize records, in a transaction
+//     db.CreateInBatches(&users, 1000)
+// The columns of a batch are the ones of its first record, `BeforeSave`, `BeforeCreate`, `AfterCreate` and `AfterSave` methods are called,
+// but associations are not saved. Auto increment primary keys are set on dialects returning them, like Postgres.
+// The create callbacks are not run, the records of a table sharded by the Resolver are created in the shard of the key set with `ShardKey`
+func (s *DB) CreateInBatches(value interface{}, batchSize int) *DB {
+	return s.NewScope(value).createInBatches(batchSize).db
+}
+
+// OnConflict specify how `Create` and `CreateInBatches` handle records conflicting with existing rows
+//     db.OnConflict(gorm.OnConflict{Columns: []string{"email"}, UpdateAll: true}).CreateInBatches(&users, 1000)
+func (s *DB) OnConflict(onConflict OnConflict) *DB {
+	return s.Set("gorm:on_conflict", onConflict)
+}
+
 // Delete delete value match given conditions, if the value has primary key, then will including the primary key as condition
 func (s *DB) Delete(value interface{}, where ...interface{}) *DB {
 	return s.NewScope(value).inlineCondition(where...).callCallbacks(s.parent.callbacks.deletes).db
@@ -501,8 +541,11 @@ func (s *DB) Debug() *DB {
 	return s.clone().LogMode(true)
 }
 
-// Begin begin a transaction
+// Begin begin a transaction, if a context is set with `WithContext`, the transaction is started with it
 func (s *DB) Begin() *DB {
+	if s.ctx != nil {
+		return s.BeginTx(s.ctx, nil)
+	}
 	c := s.clone()
 	if db, ok := c.db.(sqlDb); ok && db != nil {
 		tx, err := db.Begin()
@@ -516,6 +559,21 @@ func (s *DB) Begin() *DB {
 	return c
 }
 
+// BeginTx begin a transaction with ctx and options, the transaction is rolled back if ctx is done before it is committed
+func (s *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) *DB {
+	c := s.WithContext(ctx)
+	if db, ok := c.db.(sqlDbContext); ok && db != nil {
+		tx, err := db.BeginTx(ctx, opts)
+		c.db = interface{}(tx).(SQLCommon)
+
+		c.dialect.SetDB(c.db)
+		c.AddError(err)
+	} else {
+		c.AddError(ErrCantStartTransaction)
+	}
+	return c
+}
+
 // Commit commit a transaction
 func (s *DB) Commit() *DB {
 	var emptySQLTx *sql.Tx
@@ -766,8 +824,10 @@ func (s *DB) GetErrors() []error {
 func (s *DB) clone() *DB {
 	db := &DB{
 		db:                s.db,
+		ctx:               s.ctx,
 		parent:            s.parent,
 		logger:            s.logger,
+		structuredLogger:  s.structuredLogger,
 		logMode:           s.logMode,
 		Value:             s.Value,
 		Error:             s.Error,
@@ -801,7 +861,12 @@ func (s *DB) log(v ...interface{}) {
 }
 
 func (s *DB) slog(sql string, t time.Time, vars ...interface{}) {
+	duration := NowFunc().Sub(t)
+	if s.structuredLogger != nil {
+		vars = s.structuredLogger.redact(sql, vars)
+		s.structuredLogger.log(sql, vars, duration, s.RowsAffected, s.Error)
+	}
 	if s.logMode == detailedLogMode {
-		s.print("sql", fileWithLineNum(), NowFunc().Sub(t), sql, vars, s.RowsAffected)
+		s.print("sql", fileWithLineNum(), duration, sql, vars, s.RowsAffected)
 	}
 }
@@ -1,6 +1,7 @@
 package gorm_test
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
@@ -396,6 +397,66 @@ func TestTransaction(t *testing.T) {
 	}
 }
 
+func TestWithContext(t *testing.T) {
+	user := User{Name: "context", Age: 1}
+	if err := DB.WithContext(context.Background()).Save(&user).Error; err != nil {
+		t.Errorf("No error should raise, but got %v", err)
+	}
+
+	if err := DB.WithContext(context.Background()).First(&User{}, "name = ?", "context").Error; err != nil {
+		t.Errorf("Should find saved record with context, but got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := DB.WithContext(ctx).First(&User{}, "name = ?", "context").Error; err != context.Canceled {
+		t.Errorf("Query with cancelled context should return context.Canceled, but got %v", err)
+	}
+
+	if err := DB.WithContext(ctx).Save(&User{Name: "context-2"}).Error; err != context.Canceled {
+		t.Errorf("Save with cancelled context should return context.Canceled, but got %v", err)
+	}
+
+	if err := DB.WithContext(ctx).Exec("UPDATE users SET age = ? WHERE name = ?", 2, "context").Error; err != context.Canceled {
+		t.Errorf("Exec with cancelled context should return context.Canceled, but got %v", err)
+	}
+
+	if _, err := DB.WithContext(ctx).Table("users").Rows(); err != context.Canceled {
+		t.Errorf("Rows with cancelled context should return context.Canceled, but got %v", err)
+	}
+
+	if DB.WithContext(ctx).Context() != ctx || DB.Context() != context.Background() {
+		t.Errorf("Context should return the context set with WithContext")
+	}
+}
+
+func TestBeginTx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tx := DB.BeginTx(ctx, &sql.TxOptions{})
+	if tx.Error != nil {
+		t.Fatalf("No error should raise, but got %v", tx.Error)
+	}
+
+	if err := tx.Save(&User{Name: "transaction-context"}).Error; err != nil {
+		t.Errorf("No error should raise, but got %v", err)
+	}
+
+	cancel()
+
+	if err := tx.Commit().Error; err == nil {
+		t.Errorf("Should not commit a transaction after its context is cancelled")
+	}
+
+	if err := DB.First(&User{}, "name = ?", "transaction-context").Error; err == nil {
+		t.Errorf("Should not find record of a cancelled transaction")
+	}
+
+	if err := DB.BeginTx(ctx, nil).Error; err != context.Canceled {
+		t.Errorf("BeginTx with cancelled context should return context.Canceled, but got %v", err)
+	}
+}
+
 func TestRow(t *testing.T) {
 	user1 := User{Name: "RowUser1", Age: 1, Birthday: parseTime("2000-1-1")}
 	user2 := User{Name: "RowUser2", Age: 10, Birthday: parseTime("2010-1-1")}
@@ -0,0 +1,595 @@
+package gorm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Migration is a versioned schema change applied by a Migrator, written either in Go with Up and Down, or in SQL with UpSQL and DownSQL
+type Migration struct {
+	// ID identifies the migration, migrations are applied in the lexical order of their IDs, e.g. "20181018120000_create_users"
+	ID string
+
+	// Up and Down apply and revert the migration, tx is a transaction if the database supports it
+	Up   func(tx *DB) error
+	Down func(tx *DB) error
+
+	// UpSQL and DownSQL are SQL scripts used when Up and Down are nil, statements are separated by a semicolon at the end of a line
+	UpSQL   string
+	DownSQL string
+}
+
+// Checksum return the SHA-256 checksum of the up script of a SQL migration, Go migrations have no checksum
+func (migration *Migration) Checksum() string {
+	if migration.Up != nil || migration.UpSQL == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(migration.UpSQL))
+	return hex.EncodeToString(sum[:])
+}
+
+func (migration *Migration) up(tx *DB) error {
+	if migration.Up != nil {
+		return migration.Up(tx)
+	}
+	return execScript(tx, migration.UpSQL)
+}
+
+func (migration *Migration) down(tx *DB) error {
+	if migration.Down != nil {
+		return migration.Down(tx)
+	}
+	if migration.DownSQL == "" {
+		return fmt.Errorf("%w: %v", ErrIrreversibleMigration, migration.ID)
+	}
+	return execScript(tx, migration.DownSQL)
+}
+
+// LoadSQLMigrations load SQL migrations from the files `<id>.up.sql` and `<id>.down.sql` in dir
+func LoadSQLMigrations(dir string) ([]*Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := map[string]*Migration{}
+	for _, file := range files {
+		var id string
+		var up bool
+		if name := file.Name(); strings.HasSuffix(name, ".up.sql") {
+			id, up = strings.TrimSuffix(name, ".up.sql"), true
+		} else if strings.HasSuffix(name, ".down.sql") {
+			id = strings.TrimSuffix(name, ".down.sql")
+		} else {
+			continue
+		}
+
+		script, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if migrations[id] == nil {
+			migrations[id] = &Migration{ID: id}
+		}
+		if up {
+			migrations[id].UpSQL = string(script)
+		} else {
+			migrations[id].DownSQL = string(script)
+		}
+	}
+
+	var results []*Migration
+	for _, migration := range migrations {
+		results = append(results, migration)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	return results, nil
+}
+
+// MigrationStatus is the status of a migration returned by `Migrator.Status`
+type MigrationStatus struct {
+	ID        string
+	Applied   bool
+	AppliedAt time.Time
+	// Modified reports the SQL of an applied migration changed after it was applied
+	Modified bool
+}
+
+// schemaMigration is a row of the table recording applied migrations
+type schemaMigration struct {
+	ID        string `gorm:"primary_key;size:255"`
+	Checksum  string `gorm:"size:64"`
+	AppliedAt time.Time
+}
+
+// schemaMigrationLock is the row of the lock table held while migrating
+type schemaMigrationLock struct {
+	ID       string `gorm:"primary_key;size:255"`
+	LockedBy string `gorm:"size:255"`
+	LockedAt time.Time
+}
+
+const migrationLockID = "lock"
+
+// lockPollInterval is how often a Migrator retries to take the lock held by another migrator
+var lockPollInterval = time.Second
+
+// Migrator applies and reverts versioned migrations, recording applied migrations and their checksums in a table
+//     migrator := gorm.NewMigrator(db, &gorm.Migration{
+//       ID:      "20181018120000_create_users",
+//       UpSQL:   "CREATE TABLE users (id integer PRIMARY KEY, name varchar(255));",
+//       DownSQL: "DROP TABLE users;",
+//     })
+//     err := migrator.Migrate()
+// Concurrent migrators on the same database take turns with a lock row, so that each migration is applied once
+type Migrator struct {
+	// TableName is the table recording applied migrations, "schema_migrations" if empty, the lock is held in the table `TableName + "_lock"`
+	TableName string
+
+	// LockTimeout is how long to wait for the lock held by another migrator before returning ErrMigrationLocked, zero waits until the context of the DB is done
+	LockTimeout time.Duration
+
+	// LockExpiry, if non-zero, is how long after it was taken a lock is considered abandoned by a crashed migrator and is taken over
+	LockExpiry time.Duration
+
+	// DryRun, if not nil, makes the Migrator write the statements changing the database to it instead of executing them.
+	// Queries still run against the database, so Go migrations must not rely on the results of their writes
+	DryRun io.Writer
+
+	db         *DB
+	migrations []*Migration
+}
+
+// NewMigrator create a Migrator applying migrations to db
+func NewMigrator(db *DB, migrations ...*Migration) *Migrator {
+	migrations = append([]*Migration{}, migrations...)
+	sort.SliceStable(migrations, func(i, j int) bool { return migrations[i].ID < migrations[j].ID })
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Migrate apply all pending migrations
+func (m *Migrator) Migrate() error {
+	return m.MigrateTo("")
+}
+
+// MigrateTo apply pending migrations up to and including the migration id
+func (m *Migrator) MigrateTo(id string) error {
+	return m.run(id, func(db *DB, applied map[string]schemaMigration) error {
+		for _, migration := range m.migrations {
+			if id != "" && migration.ID > id {
+				break
+			}
+			if _, ok := applied[migration.ID]; ok {
+				continue
+			}
+			err := m.transaction(db, func(tx *DB) error {
+				if err := migration.up(tx); err != nil {
+					return err
+				}
+				return tx.Exec(fmt.Sprintf("INSERT INTO %v (id, checksum, applied_at) VALUES (?, ?, ?)", db.Dialect().Quote(m.tableName())), migration.ID, migration.Checksum(), NowFunc()).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %v: %w", migration.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Rollback revert the last applied migration
+func (m *Migrator) Rollback() error {
+	return m.run("", func(db *DB, applied map[string]schemaMigration) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].ID]; ok {
+				return m.revert(db, m.migrations[i])
+			}
+		}
+		return nil
+	})
+}
+
+// RollbackTo revert applied migrations after the migration id, an empty id reverts all migrations
+func (m *Migrator) RollbackTo(id string) error {
+	return m.run(id, func(db *DB, applied map[string]schemaMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && m.migrations[i].ID > id; i-- {
+			if _, ok := applied[m.migrations[i].ID]; ok {
+				if err := m.revert(db, m.migrations[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status return the status of the migrations
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	db := m.conn()
+	applied, err := m.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{ID: migration.ID}
+		if record, ok := applied[migration.ID]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			status.Modified = record.Checksum != migration.Checksum()
+		}
+		results = append(results, status)
+	}
+	return results, nil
+}
+
+// run call fc with the applied migrations while holding the lock
+func (m *Migrator) run(id string, fc func(db *DB, applied map[string]schemaMigration) error) error {
+	if err := m.validate(id); err != nil {
+		return err
+	}
+
+	db := m.conn()
+	if err := m.createTable(db, m.tableName(), &schemaMigration{}); err != nil {
+		return err
+	}
+
+	if m.DryRun == nil {
+		if err := m.createTable(db, m.lockTableName(), &schemaMigrationLock{}); err != nil {
+			return err
+		}
+		unlock, err := m.lock(db)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	applied, err := m.applied(db)
+	if err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if record, ok := applied[migration.ID]; ok && record.Checksum != migration.Checksum() {
+			return fmt.Errorf("%w: %v", ErrMigrationModified, migration.ID)
+		}
+	}
+	return fc(db, applied)
+}
+
+// createTable create the table of the Migrator if it doesn't exist, another migrator may be creating it at the same time
+func (m *Migrator) createTable(db *DB, name string, value interface{}) error {
+	if err := db.Table(name).AutoMigrate(value).Error; err != nil && !db.HasTable(name) {
+		return err
+	}
+	return nil
+}
+
+func (m *Migrator) validate(id string) error {
+	found := id == ""
+	for i, migration := range m.migrations {
+		if migration.ID == "" {
+			return fmt.Errorf("migration without ID")
+		}
+		if i > 0 && m.migrations[i-1].ID == migration.ID {
+			return fmt.Errorf("duplicated migration ID %v", migration.ID)
+		}
+		if migration.Up == nil && migration.UpSQL == "" {
+			return fmt.Errorf("migration %v has neither Up nor UpSQL", migration.ID)
+		}
+		found = found || migration.ID == id
+	}
+	if !found {
+		return fmt.Errorf("%w: %v", ErrMigrationNotFound, id)
+	}
+	return nil
+}
+
+func (m *Migrator) revert(db *DB, migration *Migration) error {
+	err := m.transaction(db, func(tx *DB) error {
+		if err := migration.down(tx); err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf("DELETE FROM %v WHERE id = ?", db.Dialect().Quote(m.tableName())), migration.ID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %v: %w", migration.ID, err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(db *DB) (map[string]schemaMigration, error) {
+	applied := map[string]schemaMigration{}
+	// In dry run mode, the table is not created
+	if !db.HasTable(m.tableName()) {
+		return applied, nil
+	}
+
+	var records []schemaMigration
+	if err := db.Table(m.tableName()).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		applied[record.ID] = record
+	}
+	return applied, nil
+}
+
+// transaction call fc in a transaction, or with db if it can't start one, e.g. when it is already a transaction
+func (m *Migrator) transaction(db *DB, fc func(tx *DB) error) error {
+	if m.DryRun != nil {
+		return fc(db)
+	}
+
+	tx := db.Begin()
+	if tx.Error == ErrCantStartTransaction {
+		return fc(db)
+	} else if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := fc(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// lock insert the lock row, waiting for other migrators to delete it
+func (m *Migrator) lock(db *DB) (unlock func(), err error) {
+	var (
+		quotedTableName = db.Dialect().Quote(m.lockTableName())
+		hostname, _     = os.Hostname()
+		owner           = fmt.Sprintf("%v:%v:%v", hostname, os.Getpid(), time.Now().UnixNano())
+		timeout         <-chan time.Time
+		retried         bool
+	)
+
+	if m.LockTimeout > 0 {
+		timer := time.NewTimer(m.LockTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		err := db.Exec(fmt.Sprintf("INSERT INTO %v (id, locked_by, locked_at) VALUES (?, ?, ?)", quotedTableName), migrationLockID, owner, NowFunc()).Error
+		if err == nil {
+			return func() {
+				// Release the lock even if the context of the DB is done
+				db.WithContext(context.Background()).Exec(fmt.Sprintf("DELETE FROM %v WHERE id = ? AND locked_by = ?", quotedTableName), migrationLockID, owner)
+			}, nil
+		}
+
+		var held schemaMigrationLock
+		if e := db.Table(m.lockTableName()).Where("id = ?", migrationLockID).First(&held).Error; e != nil {
+			// The lock was released after the insert failed, retry once, otherwise the insert failed for another reason
+			if IsRecordNotFoundError(e) && !retried {
+				retried = true
+				continue
+			}
+			return nil, err
+		}
+		retried = false
+
+		if m.LockExpiry > 0 && NowFunc().Sub(held.LockedAt) > m.LockExpiry {
+			db.Exec(fmt.Sprintf("DELETE FROM %v WHERE id = ? AND locked_by = ?", quotedTableName), migrationLockID, held.LockedBy)
+			continue
+		}
+
+		select {
+		case <-db.Context().Done():
+			return nil, db.Context().Err()
+		case <-timeout:
+			return nil, ErrMigrationLocked
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (m *Migrator) tableName() string {
+	if m.TableName == "" {
+		return "schema_migrations"
+	}
+	return m.TableName
+}
+
+func (m *Migrator) lockTableName() string {
+	return m.tableName() + "_lock"
+}
+
+// conn return the DB connection used to migrate, in dry run mode statements are written to DryRun
+func (m *Migrator) conn() *DB {
+	if m.DryRun == nil {
+		return m.db.New()
+	}
+	return dryRunConn(m.db, m.DryRun)
+}
+
+// SchemaChangeKind is the kind of a SchemaChange
+type SchemaChangeKind string
+
+// Kinds of SchemaChange
+const (
+	CreateTableChange SchemaChangeKind = "create_table"
+	AddColumnChange   SchemaChangeKind = "add_column"
+	DropColumnChange  SchemaChangeKind = "drop_column"
+	AddIndexChange    SchemaChangeKind = "add_index"
+)
+
+// SchemaChange is a difference between a model and the live schema returned by `Migrator.Diff`
+type SchemaChange struct {
+	Kind  SchemaChangeKind
+	Table string
+	// Name is the name of the column or index, empty for CreateTableChange
+	Name string
+	// SQL is the statements making the live schema match the model, in the dialect of the DB
+	SQL string
+}
+
+// Diff compare models with the live schema, and return the changes needed to make the schema match the models, e.g. to write a migration.
+// Columns are compared by name, changes of column types are not detected
+func (m *Migrator) Diff(models ...interface{}) ([]SchemaChange, error) {
+	db := m.db.New()
+	var changes []SchemaChange
+
+	for _, model := range models {
+		scope := db.NewScope(model)
+		tableName, quotedTableName := scope.TableName(), scope.QuotedTableName()
+
+		if !scope.Dialect().HasTable(tableName) {
+			var buf bytes.Buffer
+			dryRun := dryRunConn(db, &buf)
+			if err := dryRun.NewScope(model).createTable().db.Error; err != nil {
+				return nil, err
+			}
+			changes = append(changes, SchemaChange{Kind: CreateTableChange, Table: tableName, SQL: buf.String()})
+			continue
+		}
+
+		rows, err := db.Raw(fmt.Sprintf("SELECT * FROM %v WHERE 1 = 0", quotedTableName)).Rows()
+		if err != nil {
+			return nil, err
+		}
+		columns, err := rows.Columns()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		liveColumns := map[string]bool{}
+		for _, column := range columns {
+			liveColumns[strings.ToLower(column)] = true
+		}
+
+		modelColumns := map[string]bool{}
+		for _, field := range scope.GetModelStruct().StructFields {
+			if !field.IsNormal {
+				continue
+			}
+			modelColumns[strings.ToLower(field.DBName)] = true
+			if !liveColumns[strings.ToLower(field.DBName)] {
+				changes = append(changes, SchemaChange{
+					Kind:  AddColumnChange,
+					Table: tableName,
+					Name:  field.DBName,
+					SQL:   fmt.Sprintf("ALTER TABLE %v ADD %v %v;\n", quotedTableName, scope.Quote(field.DBName), scope.Dialect().DataTypeOf(field)),
+				})
+			}
+		}
+
+		for _, column := range columns {
+			if !modelColumns[strings.ToLower(column)] {
+				changes = append(changes, SchemaChange{
+					Kind:  DropColumnChange,
+					Table: tableName,
+					Name:  column,
+					SQL:   fmt.Sprintf("ALTER TABLE %v DROP COLUMN %v;\n", quotedTableName, scope.Quote(column)),
+				})
+			}
+		}
+
+		indexes, uniqueIndexes := scope.modelIndexes()
+		for _, unique := range []bool{false, true} {
+			sqlCreate, byName := "CREATE INDEX", indexes
+			if unique {
+				sqlCreate, byName = "CREATE UNIQUE INDEX", uniqueIndexes
+			}
+
+			var names []string
+			for name := range byName {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				if scope.Dialect().HasIndex(tableName, name) {
+					continue
+				}
+				var columns []string
+				for _, column := range byName[name] {
+					columns = append(columns, scope.quoteIfPossible(column))
+				}
+				changes = append(changes, SchemaChange{
+					Kind:  AddIndexChange,
+					Table: tableName,
+					Name:  name,
+					SQL:   fmt.Sprintf("%v %v ON %v(%v);\n", sqlCreate, name, quotedTableName, strings.Join(columns, ", ")),
+				})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// dryRunConn return a DB connection that writes the statements changing the database to w, and runs queries on db
+func dryRunConn(db *DB, w io.Writer) *DB {
+	conn := db.New()
+	conn.db = &dryRunDB{SQLCommon: db.db, w: w}
+	conn.dialect = newDialect(db.dialect.GetName(), conn.db)
+	return conn
+}
+
+type dryRunDB struct {
+	SQLCommon
+	w io.Writer
+}
+
+func (db *dryRunDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	if len(args) > 0 {
+		_, err := fmt.Fprintf(db.w, "%v; -- %v\n", query, args)
+		return dryRunResult{}, err
+	}
+	_, err := fmt.Fprintf(db.w, "%v;\n", query)
+	return dryRunResult{}, err
+}
+
+type dryRunResult struct{}
+
+func (dryRunResult) LastInsertId() (int64, error) { return 0, nil }
+func (dryRunResult) RowsAffected() (int64, error) { return 0, nil }
+
+// execScript execute the statements of a SQL script, separated by a semicolon at the end of a line
+func execScript(db *DB, script string) error {
+	for _, statement := range splitStatements(script) {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitStatements(script string) (statements []string) {
+	var lines []string
+	flush := func() {
+		statement := strings.TrimSuffix(strings.TrimSpace(strings.Join(lines, "\n")), ";")
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+		lines = nil
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		lines = append(lines, line)
+		if strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	flush()
+	return
+}
@@ -0,0 +1,269 @@
+package gorm_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func migratorTestMigrations() []*gorm.Migration {
+	return []*gorm.Migration{
+		{
+			ID:      "2_add_migrator_pets",
+			UpSQL:   "CREATE TABLE migrator_pets (id integer PRIMARY KEY, name varchar(255));\n-- pets belong to users\nALTER TABLE migrator_pets ADD user_id integer;",
+			DownSQL: "DROP TABLE migrator_pets;",
+		},
+		{
+			ID:      "1_add_migrator_users",
+			UpSQL:   "CREATE TABLE migrator_users (id integer PRIMARY KEY, name varchar(255));",
+			DownSQL: "DROP TABLE migrator_users;",
+		},
+		{
+			ID: "3_add_migrator_users_age",
+			Up: func(tx *gorm.DB) error {
+				return tx.Exec("ALTER TABLE migrator_users ADD age integer").Error
+			},
+		},
+	}
+}
+
+func resetMigratorTables() {
+	DB.DropTableIfExists("migrator_users", "migrator_pets", "schema_migrations", "schema_migrations_lock")
+}
+
+func TestMigrator(t *testing.T) {
+	resetMigratorTables()
+	defer resetMigratorTables()
+
+	migrator := gorm.NewMigrator(DB, migratorTestMigrations()...)
+	if err := migrator.MigrateTo("2_add_migrator_pets"); err != nil {
+		t.Fatalf("No error should happen when migrating, but got %v", err)
+	}
+
+	if !DB.HasTable("migrator_users") || !DB.HasTable("migrator_pets") {
+		t.Errorf("Migrations up to 2_add_migrator_pets should be applied")
+	}
+
+	if DB.Dialect().HasColumn("migrator_users", "age") {
+		t.Errorf("Migrations after 2_add_migrator_pets should not be applied")
+	}
+
+	if err := migrator.Migrate(); err != nil {
+		t.Fatalf("No error should happen when migrating, but got %v", err)
+	}
+
+	if !DB.Dialect().HasColumn("migrator_users", "age") || !DB.Dialect().HasColumn("migrator_pets", "user_id") {
+		t.Errorf("All migrations should be applied")
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("No error should happen when getting status, but got %v", err)
+	}
+	if len(statuses) != 3 || statuses[0].ID != "1_add_migrator_users" {
+		t.Fatalf("Status should return migrations in order, but got %+v", statuses)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.Modified || status.AppliedAt.IsZero() {
+			t.Errorf("Migration %v should be applied and not modified, but got %+v", status.ID, status)
+		}
+	}
+
+	if err := migrator.Rollback(); !errors.Is(err, gorm.ErrIrreversibleMigration) {
+		t.Errorf("Rollback of a migration without down migration should return ErrIrreversibleMigration, but got %v", err)
+	}
+
+	if err := migrator.RollbackTo("1_add_migrator_users"); !errors.Is(err, gorm.ErrIrreversibleMigration) {
+		t.Errorf("Rollback of a migration without down migration should return ErrIrreversibleMigration, but got %v", err)
+	}
+
+	DB.Exec("DELETE FROM schema_migrations WHERE id = ?", "3_add_migrator_users_age")
+
+	if err := migrator.Rollback(); err != nil || DB.HasTable("migrator_pets") {
+		t.Errorf("Rollback should revert the last applied migration, but got %v", err)
+	}
+
+	if err := migrator.RollbackTo(""); err != nil || DB.HasTable("migrator_users") {
+		t.Errorf("RollbackTo should revert all applied migrations, but got %v", err)
+	}
+
+	if err := migrator.MigrateTo("4_unknown"); !errors.Is(err, gorm.ErrMigrationNotFound) {
+		t.Errorf("Migrating to an unknown migration should return ErrMigrationNotFound, but got %v", err)
+	}
+}
+
+func TestMigratorModifiedMigration(t *testing.T) {
+	resetMigratorTables()
+	defer resetMigratorTables()
+
+	migrations := migratorTestMigrations()
+	if err := gorm.NewMigrator(DB, migrations...).Migrate(); err != nil {
+		t.Fatalf("No error should happen when migrating, but got %v", err)
+	}
+
+	migrations[1].UpSQL = "CREATE TABLE migrator_users (id integer PRIMARY KEY, name varchar(100));"
+	migrator := gorm.NewMigrator(DB, migrations...)
+	if err := migrator.Migrate(); !errors.Is(err, gorm.ErrMigrationModified) {
+		t.Errorf("Migrating with a modified migration should return ErrMigrationModified, but got %v", err)
+	}
+
+	statuses, _ := migrator.Status()
+	if len(statuses) != 3 || !statuses[0].Modified || statuses[1].Modified {
+		t.Errorf("Status should report the modified migration, but got %+v", statuses)
+	}
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	resetMigratorTables()
+	defer resetMigratorTables()
+
+	var buf bytes.Buffer
+	migrator := gorm.NewMigrator(DB, migratorTestMigrations()...)
+	migrator.DryRun = &buf
+	if err := migrator.Migrate(); err != nil {
+		t.Fatalf("No error should happen in dry run, but got %v", err)
+	}
+
+	if DB.HasTable("migrator_users") || DB.HasTable("schema_migrations") {
+		t.Errorf("Dry run should not change the database")
+	}
+
+	output := buf.String()
+	for _, statement := range []string{
+		"CREATE TABLE migrator_users (id integer PRIMARY KEY, name varchar(255));",
+		"ALTER TABLE migrator_pets ADD user_id integer;",
+		"ALTER TABLE migrator_users ADD age integer;",
+		"INSERT INTO",
+	} {
+		if !strings.Contains(output, statement) {
+			t.Errorf("Dry run output should contain %q, but got %v", statement, output)
+		}
+	}
+
+	if strings.Contains(output, "pets belong to users") {
+		t.Errorf("Dry run output should not contain comments of SQL scripts, but got %v", output)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	resetMigratorTables()
+	defer resetMigratorTables()
+
+	migrator := gorm.NewMigrator(DB, migratorTestMigrations()[1])
+	if err := migrator.Migrate(); err != nil {
+		t.Fatalf("No error should happen when migrating, but got %v", err)
+	}
+
+	DB.Exec("INSERT INTO schema_migrations_lock (id, locked_by, locked_at) VALUES (?, ?, ?)", "lock", "other", time.Now().Add(-time.Hour))
+
+	migrator = gorm.NewMigrator(DB, migratorTestMigrations()...)
+	migrator.LockTimeout = 100 * time.Millisecond
+	if err := migrator.Migrate(); err != gorm.ErrMigrationLocked {
+		t.Errorf("Migrating while another migrator holds the lock should return ErrMigrationLocked, but got %v", err)
+	}
+
+	if DB.HasTable("migrator_pets") {
+		t.Errorf("Migrations should not be applied without the lock")
+	}
+
+	migrator.LockExpiry = time.Minute
+	if err := migrator.Migrate(); err != nil {
+		t.Errorf("An expired lock should be taken over, but got %v", err)
+	}
+
+	var count int
+	DB.Table("schema_migrations_lock").Count(&count)
+	if count != 0 {
+		t.Errorf("The lock should be released after migrating")
+	}
+}
+
+type MigratorUser struct {
+	ID    int
+	Name  string `gorm:"index"`
+	Email string `gorm:"unique_index"`
+}
+
+type MigratorPet struct {
+	ID   int
+	Name string
+}
+
+func TestMigratorDiff(t *testing.T) {
+	resetMigratorTables()
+	defer resetMigratorTables()
+
+	DB.Exec("CREATE TABLE migrator_users (id integer PRIMARY KEY, name varchar(255), nickname varchar(255))")
+
+	changes, err := gorm.NewMigrator(DB).Diff(&MigratorUser{}, &MigratorPet{})
+	if err != nil {
+		t.Fatalf("No error should happen when comparing models with the schema, but got %v", err)
+	}
+
+	expected := []struct {
+		kind gorm.SchemaChangeKind
+		name string
+	}{
+		{gorm.AddColumnChange, "email"},
+		{gorm.DropColumnChange, "nickname"},
+		{gorm.AddIndexChange, "idx_migrator_users_name"},
+		{gorm.AddIndexChange, "uix_migrator_users_email"},
+		{gorm.CreateTableChange, ""},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, but got %+v", len(expected), changes)
+	}
+	for i, change := range changes {
+		if change.Kind != expected[i].kind || !strings.EqualFold(change.Name, expected[i].name) || change.SQL == "" {
+			t.Errorf("Expected change %v %v, but got %+v", expected[i].kind, expected[i].name, change)
+		}
+	}
+
+	if changes[4].Table != "migrator_pets" || !strings.Contains(changes[4].SQL, "CREATE TABLE") || DB.HasTable("migrator_pets") {
+		t.Errorf("Diff should return the statement creating a missing table without running it, but got %+v", changes[4])
+	}
+}
+
+func TestLoadSQLMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorm-migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"2_add_pets.up.sql":    "CREATE TABLE pets (id integer);",
+		"1_add_users.up.sql":   "CREATE TABLE users (id integer);",
+		"1_add_users.down.sql": "DROP TABLE users;",
+		"README.md":            "migrations",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrations, err := gorm.LoadSQLMigrations(dir)
+	if err != nil {
+		t.Fatalf("No error should happen when loading migrations, but got %v", err)
+	}
+
+	if len(migrations) != 2 || migrations[0].ID != "1_add_users" || migrations[1].ID != "2_add_pets" {
+		t.Fatalf("Migrations should be loaded in order, but got %+v", migrations)
+	}
+
+	if migrations[0].UpSQL != files["1_add_users.up.sql"] || migrations[0].DownSQL != files["1_add_users.down.sql"] || migrations[1].DownSQL != "" {
+		t.Errorf("Migrations should be loaded with their scripts, but got %+v", migrations)
+	}
+
+	if migrations[0].Checksum() == "" || migrations[0].Checksum() == migrations[1].Checksum() {
+		t.Errorf("SQL migrations should have a checksum of their up script")
+	}
+}
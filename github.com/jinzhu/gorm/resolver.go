@@ -0,0 +1,220 @@
+package gorm
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"reflect"
+	"sync/atomic"
+)
+
+// Define callbacks for resolving the database connection of an operation
+func init() {
+	DefaultCallback.Create().Before("gorm:begin_transaction").Register("gorm:resolve_connection", resolveWriteConnectionCallback)
+	DefaultCallback.Update().Before("gorm:begin_transaction").Register("gorm:resolve_connection", resolveWriteConnectionCallback)
+	DefaultCallback.Delete().Before("gorm:begin_transaction").Register("gorm:resolve_connection", resolveWriteConnectionCallback)
+	DefaultCallback.Query().Before("gorm:query").Register("gorm:resolve_connection", resolveReadConnectionCallback)
+	DefaultCallback.RowQuery().Before("gorm:row_query").Register("gorm:resolve_connection", resolveReadConnectionCallback)
+}
+
+// Policy chooses the replica a query is sent to
+type Policy interface {
+	Resolve(replicas []SQLCommon) SQLCommon
+}
+
+// PolicyFunc is an adapter to use a function as a Policy
+type PolicyFunc func(replicas []SQLCommon) SQLCommon
+
+// Resolve call f(replicas)
+func (f PolicyFunc) Resolve(replicas []SQLCommon) SQLCommon {
+	return f(replicas)
+}
+
+var defaultPolicy = RandomPolicy()
+
+// RandomPolicy return a Policy choosing a random replica
+func RandomPolicy() Policy {
+	return PolicyFunc(func(replicas []SQLCommon) SQLCommon {
+		return replicas[rand.Intn(len(replicas))]
+	})
+}
+
+// RoundRobinPolicy return a Policy choosing replicas in turn
+func RoundRobinPolicy() Policy {
+	var next uint64
+	return PolicyFunc(func(replicas []SQLCommon) SQLCommon {
+		return replicas[(atomic.AddUint64(&next, 1)-1)%uint64(len(replicas))]
+	})
+}
+
+// LeastInUsePolicy return a Policy choosing the replica with the fewest connections in use, replicas which are not a `*sql.DB` are considered idle
+func LeastInUsePolicy() Policy {
+	return PolicyFunc(func(replicas []SQLCommon) SQLCommon {
+		var result SQLCommon
+		var least = -1
+		for _, replica := range replicas {
+			var inUse int
+			if db, ok := replica.(*sql.DB); ok {
+				inUse = db.Stats().InUse
+			}
+			if least == -1 || inUse < least {
+				result, least = replica, inUse
+			}
+		}
+		return result
+	})
+}
+
+// Resolver routes the operations of a DB connection, queries are sent to replicas and the operations on sharded tables to the database of their shard.
+// Writes and transactions stay on the primary database, the connection the DB was opened with
+//     db, err := gorm.Open("mysql", primaryDSN)
+//     db.SetResolver(&gorm.Resolver{Replicas: []gorm.SQLCommon{replica1, replica2}, Policy: gorm.RoundRobinPolicy()})
+type Resolver struct {
+	// Replicas receive queries, unless they run in a transaction or with `UsePrimary`, and raw queries run with `UseReplica`
+	Replicas []SQLCommon
+
+	// Policy chooses the replica of a query, RandomPolicy if nil
+	Policy Policy
+
+	shardings map[string]*Sharding
+}
+
+// Sharding distributes the records of tables across databases by the value of a sharding key
+type Sharding struct {
+	// Column is the name or database name of the field holding the sharding key, used to find the shard of a record when it is not set with `ShardKey`
+	Column string
+
+	// Shards are the DB connections of the shards, with their own Resolver for replicas
+	Shards []*DB
+
+	// Shard return the index of the shard of a sharding key, the FNV-1a hash of `fmt.Sprint(key)` modulo the number of shards if nil
+	Shard func(key interface{}) int
+}
+
+func (sharding *Sharding) shard(key interface{}) *DB {
+	if sharding.Shard != nil {
+		return sharding.Shards[sharding.Shard(key)]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(fmt.Sprint(key)))
+	return sharding.Shards[h.Sum32()%uint32(len(sharding.Shards))]
+}
+
+// SetResolver set the resolver routing the operations of the DB connection
+func (s *DB) SetResolver(resolver *Resolver) *DB {
+	s.parent.resolver = resolver
+	return s
+}
+
+// ShardTables shard the tables of models, the DB connection must have a Resolver
+//     db.ShardTables(&gorm.Sharding{Column: "UserID", Shards: []*gorm.DB{shard0, shard1}}, &Order{}, &Payment{})
+//     db.Create(&Order{UserID: 42})  // created in the shard of 42
+//     db.ShardKey(42).Where("state = ?", "paid").Find(&orders)
+func (s *DB) ShardTables(sharding *Sharding, models ...interface{}) *DB {
+	resolver := s.parent.resolver
+	if resolver == nil {
+		resolver = &Resolver{}
+		s.parent.resolver = resolver
+	}
+	if resolver.shardings == nil {
+		resolver.shardings = map[string]*Sharding{}
+	}
+	for _, model := range models {
+		resolver.shardings[s.NewScope(model).TableName()] = sharding
+	}
+	return s
+}
+
+// ShardKey specify the sharding key of operations on sharded tables
+func (s *DB) ShardKey(key interface{}) *DB {
+	return s.Set("gorm:shard_key", key)
+}
+
+// UsePrimary send queries to the primary database instead of replicas, e.g. to read a record just written
+func (s *DB) UsePrimary() *DB {
+	return s.Set("gorm:use_primary", true)
+}
+
+// UseReplica send raw queries to replicas, they are sent to the primary database otherwise as they may write
+//     db.UseReplica().Raw("SELECT name, age FROM users WHERE name = ?", "jinzhu").Scan(&result)
+func (s *DB) UseReplica() *DB {
+	return s.Set("gorm:use_replica", true)
+}
+
+// resolveWriteConnectionCallback send writes on sharded tables to their shard
+func resolveWriteConnectionCallback(scope *Scope) {
+	scope.resolveConnection(false)
+}
+
+// resolveReadConnectionCallback send queries to replicas, and queries on sharded tables to their shard
+func resolveReadConnectionCallback(scope *Scope) {
+	_, usePrimary := scope.Get("gorm:use_primary")
+	if scope.Search != nil && scope.Search.raw {
+		_, useReplica := scope.Get("gorm:use_replica")
+		usePrimary = usePrimary || !useReplica
+	}
+	scope.resolveConnection(!usePrimary)
+}
+
+func (scope *Scope) resolveConnection(read bool) {
+	resolver := scope.db.parent.resolver
+	if resolver == nil || scope.HasError() {
+		return
+	}
+
+	// Operations in a transaction stay in the transaction
+	if _, ok := scope.db.db.(sqlTx); ok {
+		return
+	}
+
+	target := scope.db.parent
+	if sharding := resolver.shardings[scope.operationTableName()]; sharding != nil {
+		key, ok := scope.Get("gorm:shard_key")
+		if !ok {
+			// Use a separate scope, so that the fields of the operation are not cached before its callbacks change them
+			valueScope := &Scope{db: scope.db, Value: scope.Value}
+			if field, found := valueScope.FieldByName(sharding.Column); found && !field.IsBlank {
+				key, ok = field.Field.Interface(), true
+			}
+		}
+		if !ok {
+			scope.Err(ErrShardKeyRequired)
+			return
+		}
+		target = sharding.shard(key)
+	} else if !read {
+		return
+	}
+
+	conn := target.db
+	if targetResolver := target.parent.resolver; read && targetResolver != nil && len(targetResolver.Replicas) > 0 {
+		policy := targetResolver.Policy
+		if policy == nil {
+			policy = defaultPolicy
+		}
+		conn = policy.Resolve(targetResolver.Replicas)
+	}
+
+	scope.db.db = conn
+	scope.db.dialect.SetDB(conn)
+}
+
+// operationTableName return the table name of the operation, or an empty string for raw SQL and operations without model
+func (scope *Scope) operationTableName() string {
+	if scope.Search != nil {
+		if scope.Search.raw {
+			return ""
+		}
+		if scope.Search.tableName != "" {
+			return scope.Search.tableName
+		}
+	}
+	if scope.Value == nil {
+		return ""
+	}
+	if value := reflect.ValueOf(scope.Value); value.Kind() == reflect.Ptr && value.IsNil() {
+		return ""
+	}
+	return scope.TableName()
+}
@@ -0,0 +1,209 @@
+package gorm_test
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+// countingDB counts the statements run on a connection
+type countingDB struct {
+	gorm.SQLCommon
+	mu    sync.Mutex
+	count int
+}
+
+func (db *countingDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	db.inc()
+	return db.SQLCommon.Exec(query, args...)
+}
+
+func (db *countingDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	db.inc()
+	return db.SQLCommon.Query(query, args...)
+}
+
+func (db *countingDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	db.inc()
+	return db.SQLCommon.QueryRow(query, args...)
+}
+
+func (db *countingDB) inc() {
+	db.mu.Lock()
+	db.count++
+	db.mu.Unlock()
+}
+
+func (db *countingDB) Count() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.count
+}
+
+func TestResolverReplicas(t *testing.T) {
+	db, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("No error should happen when connecting to test database, but got %v", err)
+	}
+	defer db.Close()
+
+	replica := &countingDB{SQLCommon: DB.DB()}
+	db.SetResolver(&gorm.Resolver{Replicas: []gorm.SQLCommon{replica}})
+
+	user := User{Name: "resolver", Age: 20}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("No error should happen when creating, but got %v", err)
+	}
+	db.Model(&user).Update("age", 21)
+	if replica.Count() != 0 {
+		t.Errorf("Writes should be sent to the primary")
+	}
+
+	var result User
+	if err := db.First(&result, user.Id).Error; err != nil || result.Age != 21 {
+		t.Errorf("Should find record from replica, but got %v", err)
+	}
+	if replica.Count() != 1 {
+		t.Errorf("Queries should be sent to replicas, but got %d", replica.Count())
+	}
+
+	var count int
+	var names []string
+	db.Model(&User{}).Where("name = ?", "resolver").Count(&count)
+	db.Model(&User{}).Where("name = ?", "resolver").Pluck("name", &names)
+	db.Table("users").Where("name = ?", "resolver").Select("name").Row()
+	if replica.Count() != 4 || count != 1 || len(names) != 1 {
+		t.Errorf("Count, Pluck and Row should be sent to replicas, but got %d", replica.Count())
+	}
+
+	db.UsePrimary().First(&User{}, user.Id)
+	if replica.Count() != 4 {
+		t.Errorf("Queries with UsePrimary should be sent to the primary")
+	}
+
+	tx := db.Begin()
+	tx.First(&User{}, user.Id)
+	tx.Rollback()
+	if replica.Count() != 4 {
+		t.Errorf("Queries in a transaction should be sent to the primary")
+	}
+
+	var raw User
+	db.Raw("SELECT name FROM users WHERE id = ?", user.Id).Row()
+	db.Raw("SELECT name FROM users WHERE id = ?", user.Id).Scan(&raw)
+	if replica.Count() != 4 || raw.Name != "resolver" {
+		t.Errorf("Raw queries should be sent to the primary, but got %d", replica.Count())
+	}
+	db.UseReplica().Raw("SELECT name FROM users WHERE id = ?", user.Id).Row()
+	if replica.Count() != 5 {
+		t.Errorf("Raw queries with UseReplica should be sent to replicas, but got %d", replica.Count())
+	}
+
+	missing := User{Id: user.Id + 1000, Name: "resolver_save", Age: 20}
+	if err := db.Save(&missing).Error; err != nil {
+		t.Fatalf("No error should happen when saving, but got %v", err)
+	}
+	defer db.Delete(&missing)
+	if replica.Count() != 5 {
+		t.Errorf("Save should look for missing records on the primary, but got %d", replica.Count())
+	}
+}
+
+func TestResolverPolicies(t *testing.T) {
+	replicas := []gorm.SQLCommon{&countingDB{}, &countingDB{}, &countingDB{}}
+
+	policy := gorm.RoundRobinPolicy()
+	for i := 0; i < 6; i++ {
+		if replica := policy.Resolve(replicas); replica != replicas[i%3] {
+			t.Errorf("RoundRobinPolicy should choose replicas in turn")
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		found := false
+		replica := gorm.RandomPolicy().Resolve(replicas)
+		for _, r := range replicas {
+			found = found || r == replica
+		}
+		if !found {
+			t.Errorf("RandomPolicy should choose one of the replicas")
+		}
+	}
+
+	if replica := gorm.LeastInUsePolicy().Resolve(replicas); replica != replicas[0] {
+		t.Errorf("LeastInUsePolicy should choose the first of idle replicas")
+	}
+}
+
+type ShardedPet struct {
+	ID      int
+	OwnerID int
+	Name    string
+}
+
+func TestResolverSharding(t *testing.T) {
+	DB.DropTableIfExists(&ShardedPet{})
+	DB.AutoMigrate(&ShardedPet{})
+
+	db, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("No error should happen when connecting to test database, but got %v", err)
+	}
+	defer db.Close()
+
+	var shards []*countingDB
+	var shardDBs []*gorm.DB
+	for i := 0; i < 2; i++ {
+		shard := &countingDB{SQLCommon: DB.DB()}
+		shardDB, err := gorm.Open(DB.Dialect().GetName(), shard)
+		if err != nil {
+			t.Fatalf("No error should happen when opening shard, but got %v", err)
+		}
+		shards = append(shards, shard)
+		shardDBs = append(shardDBs, shardDB)
+	}
+
+	db.ShardTables(&gorm.Sharding{
+		Column: "OwnerID",
+		Shards: shardDBs,
+		Shard:  func(key interface{}) int { return key.(int) % 2 },
+	}, &ShardedPet{})
+
+	if err := db.Create(&ShardedPet{OwnerID: 1, Name: "pet"}).Error; err != nil {
+		t.Fatalf("No error should happen when creating, but got %v", err)
+	}
+	if shards[0].Count() != 0 || shards[1].Count() == 0 {
+		t.Errorf("Record should be created in the shard of its key, but got %d, %d", shards[0].Count(), shards[1].Count())
+	}
+
+	var pets []ShardedPet
+	if err := db.ShardKey(2).Find(&pets).Error; err != nil {
+		t.Errorf("No error should happen when querying a shard, but got %v", err)
+	}
+	if shards[0].Count() != 1 {
+		t.Errorf("Query should be sent to the shard of the key")
+	}
+
+	if err := db.Find(&pets).Error; err != gorm.ErrShardKeyRequired {
+		t.Errorf("Query on a sharded table without key should return ErrShardKeyRequired, but got %v", err)
+	}
+
+	if err := db.Find(&[]User{}).Error; err != nil {
+		t.Errorf("Tables which are not sharded should use the primary, but got %v", err)
+	}
+
+	count := shards[0].Count()
+	batch := []ShardedPet{{OwnerID: 2, Name: "a"}, {OwnerID: 2, Name: "b"}}
+	if err := db.ShardKey(2).CreateInBatches(&batch, 10).Error; err != nil {
+		t.Fatalf("No error should happen when creating in batches, but got %v", err)
+	}
+	if shards[0].Count() == count {
+		t.Errorf("Records created in batches should be created in the shard of the key")
+	}
+
+	if err := db.CreateInBatches(&[]ShardedPet{{OwnerID: 2}}, 10).Error; err != gorm.ErrShardKeyRequired {
+		t.Errorf("Creating in batches on a sharded table without key should return ErrShardKeyRequired, but got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package gorm
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -61,6 +62,11 @@ func (scope *Scope) SQLDB() SQLCommon {
 	return scope.db.db
 }
 
+// Context return the context of scope's DB connection, set with `DB.WithContext`
+func (scope *Scope) Context() context.Context {
+	return scope.db.Context()
+}
+
 // Dialect get dialect
 func (scope *Scope) Dialect() Dialect {
 	return scope.db.dialect
@@ -361,7 +367,7 @@ func (scope *Scope) Exec() *Scope {
 	defer scope.trace(NowFunc())
 
 	if !scope.HasError() {
-		if result, err := scope.SQLDB().Exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+		if result, err := scope.exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
 			if count, err := result.RowsAffected(); scope.Err(err) == nil {
 				scope.db.RowsAffected = count
 			}
@@ -401,6 +407,15 @@ func (scope *Scope) InstanceGet(name string) (interface{}, bool) {
 
 // Begin start a transaction
 func (scope *Scope) Begin() *Scope {
+	if ctx := scope.db.ctx; ctx != nil {
+		if db, ok := scope.SQLDB().(sqlDbContext); ok {
+			if tx, err := db.BeginTx(ctx, nil); err == nil {
+				scope.db.db = interface{}(tx).(SQLCommon)
+				scope.InstanceSet("gorm:started_transaction", true)
+			}
+			return scope
+		}
+	}
 	if db, ok := scope.SQLDB().(sqlDb); ok {
 		if tx, err := db.Begin(); err == nil {
 			scope.db.db = interface{}(tx).(SQLCommon)
@@ -429,6 +444,36 @@ func (scope *Scope) CommitOrRollback() *Scope {
 // Private Methods For *gorm.Scope
 ////////////////////////////////////////////////////////////////////////////////
 
+// exec, query and queryRow run a statement on scope's DB connection, passing the context set with `DB.WithContext` if the connection supports it
+func (scope *Scope) exec(query string, args ...interface{}) (sql.Result, error) {
+	if db, ok := scope.sqlDBContext(); ok {
+		return db.ExecContext(scope.db.ctx, query, args...)
+	}
+	return scope.SQLDB().Exec(query, args...)
+}
+
+func (scope *Scope) query(query string, args ...interface{}) (*sql.Rows, error) {
+	if db, ok := scope.sqlDBContext(); ok {
+		return db.QueryContext(scope.db.ctx, query, args...)
+	}
+	return scope.SQLDB().Query(query, args...)
+}
+
+func (scope *Scope) queryRow(query string, args ...interface{}) *sql.Row {
+	if db, ok := scope.sqlDBContext(); ok {
+		return db.QueryRowContext(scope.db.ctx, query, args...)
+	}
+	return scope.SQLDB().QueryRow(query, args...)
+}
+
+func (scope *Scope) sqlDBContext() (sqlCommonContext, bool) {
+	if scope.db.ctx == nil {
+		return nil, false
+	}
+	db, ok := scope.SQLDB().(sqlCommonContext)
+	return db, ok
+}
+
 func (scope *Scope) callMethod(methodName string, reflectValue reflect.Value) {
 	// Only get address from non-pointer
 	if reflectValue.CanAddr() && reflectValue.Kind() != reflect.Ptr {
@@ -711,13 +756,12 @@ func (scope *Scope) buildSelectQuery(clause map[string]interface{}) (str string)
 func (scope *Scope) whereSQL() (sql string) {
 	var (
 		quotedTableName                                = scope.QuotedTableName()
-		deletedAtField, hasDeletedAtField              = scope.FieldByName("DeletedAt")
+		softDeleteField, softDelete, hasSoftDelete     = scope.softDeleteField()
 		primaryConditions, andConditions, orConditions []string
 	)
 
-	if !scope.Search.Unscoped && hasDeletedAtField {
-		sql := fmt.Sprintf("%v.%v IS NULL", quotedTableName, scope.Quote(deletedAtField.DBName))
-		primaryConditions = append(primaryConditions, sql)
+	if !scope.Search.Unscoped && hasSoftDelete {
+		primaryConditions = append(primaryConditions, scope.notDeletedSQL(softDeleteField, softDelete))
 	}
 
 	if !scope.PrimaryKeyZero() {
@@ -951,6 +995,191 @@ func (scope *Scope) rows() (*sql.Rows, error) {
 	return result.Rows, result.Error
 }
 
+// onConflict return the OnConflict set with `DB.OnConflict`, with its defaults resolved for the inserted columns
+func (scope *Scope) onConflict(columns []string) (onConflict OnConflict, ok bool) {
+	value, ok := scope.Get("gorm:on_conflict")
+	if !ok {
+		return
+	}
+	onConflict = value.(OnConflict)
+
+	if len(onConflict.Columns) == 0 {
+		for _, field := range scope.GetModelStruct().PrimaryFields {
+			onConflict.Columns = append(onConflict.Columns, field.DBName)
+		}
+	}
+
+	if onConflict.DoNothing {
+		onConflict.DoUpdates = nil
+	} else if onConflict.UpdateAll {
+		excluded := map[string]bool{"created_at": true}
+		for _, column := range onConflict.Columns {
+			excluded[column] = true
+		}
+		for _, field := range scope.GetModelStruct().PrimaryFields {
+			excluded[field.DBName] = true
+		}
+
+		onConflict.DoUpdates = nil
+		for _, column := range columns {
+			if !excluded[column] {
+				onConflict.DoUpdates = append(onConflict.DoUpdates, column)
+			}
+		}
+	}
+	return onConflict, true
+}
+
+func (scope *Scope) createInBatches(batchSize int) *Scope {
+	values := scope.IndirectValue()
+	if values.Kind() != reflect.Slice {
+		scope.Err(errors.New("unsupported value, should be slice"))
+		return scope
+	}
+	if values.Len() == 0 {
+		return scope
+	}
+	if batchSize <= 0 || batchSize > values.Len() {
+		batchSize = values.Len()
+	}
+
+	// Batches don't run the create callbacks, resolve their connection like `gorm:resolve_connection`,
+	// a sharded table needs the sharding key set with `ShardKey` as records can't be routed one by one
+	if scope.resolveConnection(false); scope.HasError() {
+		return scope
+	}
+
+	scope.Begin()
+	for start := 0; start < values.Len() && !scope.HasError(); start += batchSize {
+		end := start + batchSize
+		if end > values.Len() {
+			end = values.Len()
+		}
+		scope.createBatch(values.Slice(start, end))
+	}
+	scope.CommitOrRollback()
+	return scope
+}
+
+func (scope *Scope) createBatch(values reflect.Value) {
+	var (
+		records               []*Scope
+		columns, columnNames  []string
+		rows                  []string
+		batch                 = scope.New(scope.Value)
+		quotedTableName       = scope.QuotedTableName()
+		primaryField          = scope.PrimaryField()
+		returningPrimaryField bool
+	)
+
+	for i := 0; i < values.Len(); i++ {
+		value := values.Index(i)
+		if value.Kind() != reflect.Ptr {
+			value = value.Addr()
+		}
+
+		// Records share the DB of scope, so that their errors are errors of scope
+		record := &Scope{db: scope.db, Search: &search{}, Value: value.Interface()}
+		beforeCreateCallback(record)
+		updateTimeStampForCreateCallback(record)
+		if scope.HasError() {
+			return
+		}
+		records = append(records, record)
+
+		if i == 0 {
+			for _, field := range record.Fields() {
+				if scope.changeableField(field) && field.IsNormal && !field.IsIgnored && !(field.IsBlank && (field.IsPrimaryKey || field.HasDefaultValue)) {
+					columns = append(columns, scope.Quote(field.DBName))
+					columnNames = append(columnNames, field.DBName)
+				}
+			}
+			if len(columns) == 0 {
+				scope.Err(errors.New("no column to insert"))
+				return
+			}
+			returningPrimaryField = primaryField != nil && !primaryField.IsIgnored
+			for _, name := range columnNames {
+				if primaryField != nil && name == primaryField.DBName {
+					returningPrimaryField = false
+				}
+			}
+		}
+
+		var placeholders []string
+		for _, name := range columnNames {
+			field, _ := record.FieldByName(name)
+			placeholders = append(placeholders, batch.AddToVars(field.Field.Interface()))
+		}
+		rows = append(rows, "("+strings.Join(placeholders, ",")+")")
+	}
+
+	var extraOption, lastInsertIDReturningSuffix string
+	if str, ok := scope.Get("gorm:insert_option"); ok {
+		extraOption = fmt.Sprint(str)
+	}
+
+	if onConflict, ok := scope.onConflict(columnNames); ok {
+		dialect, ok := scope.Dialect().(UpsertDialect)
+		if !ok {
+			scope.Err(ErrUpsertNotSupported)
+			return
+		}
+		upsertSQL, err := dialect.UpsertSQL(quotedTableName, columnNames, rows, onConflict)
+		if scope.Err(err) != nil {
+			return
+		}
+		// Skipped records don't return their primary key, so primary keys can't be matched with records
+		returningPrimaryField = false
+		batch.Raw(upsertSQL + addExtraSpaceIfExist(extraOption))
+	} else {
+		if returningPrimaryField {
+			lastInsertIDReturningSuffix = scope.Dialect().LastInsertIDReturningSuffix(quotedTableName, scope.Quote(primaryField.DBName))
+		}
+		batch.Raw(fmt.Sprintf(
+			"INSERT INTO %v (%v) VALUES %v%v%v",
+			quotedTableName,
+			strings.Join(columns, ","),
+			strings.Join(rows, ","),
+			addExtraSpaceIfExist(extraOption),
+			addExtraSpaceIfExist(lastInsertIDReturningSuffix),
+		))
+	}
+
+	if lastInsertIDReturningSuffix == "" {
+		if scope.Err(batch.Exec().db.Error) != nil {
+			return
+		}
+		scope.db.RowsAffected += batch.db.RowsAffected
+	} else {
+		defer batch.trace(NowFunc())
+
+		rows, err := batch.query(batch.SQL, batch.SQLVars...)
+		if scope.Err(err) != nil {
+			return
+		}
+		defer rows.Close()
+
+		for i := 0; rows.Next() && i < len(records); i++ {
+			if scope.Err(rows.Scan(records[i].PrimaryField().Field.Addr().Interface())) != nil {
+				return
+			}
+			scope.db.RowsAffected++
+		}
+		if scope.Err(rows.Err()) != nil {
+			return
+		}
+	}
+
+	for _, record := range records {
+		afterCreateCallback(record)
+		auditCreateCallback(record)
+		if scope.HasError() {
+			return
+		}
+	}
+}
+
 func (scope *Scope) initialize() *Scope {
 	for _, clause := range scope.Search.whereConditions {
 		scope.updatedAttrsWithValues(clause["query"])
@@ -1266,8 +1495,27 @@ func (scope *Scope) autoMigrate() *Scope {
 }
 
 func (scope *Scope) autoIndex() *Scope {
-	var indexes = map[string][]string{}
-	var uniqueIndexes = map[string][]string{}
+	indexes, uniqueIndexes := scope.modelIndexes()
+
+	for name, columns := range indexes {
+		if db := scope.NewDB().Table(scope.TableName()).Model(scope.Value).AddIndex(name, columns...); db.Error != nil {
+			scope.db.AddError(db.Error)
+		}
+	}
+
+	for name, columns := range uniqueIndexes {
+		if db := scope.NewDB().Table(scope.TableName()).Model(scope.Value).AddUniqueIndex(name, columns...); db.Error != nil {
+			scope.db.AddError(db.Error)
+		}
+	}
+
+	return scope
+}
+
+// modelIndexes return the columns of the indexes and unique indexes defined by the model's tags, by index name
+func (scope *Scope) modelIndexes() (indexes map[string][]string, uniqueIndexes map[string][]string) {
+	indexes = map[string][]string{}
+	uniqueIndexes = map[string][]string{}
 
 	for _, field := range scope.GetStructFields() {
 		if name, ok := field.TagSettingsGet("INDEX"); ok {
@@ -1292,20 +1540,7 @@ func (scope *Scope) autoIndex() *Scope {
 			}
 		}
 	}
-
-	for name, columns := range indexes {
-		if db := scope.NewDB().Table(scope.TableName()).Model(scope.Value).AddIndex(name, columns...); db.Error != nil {
-			scope.db.AddError(db.Error)
-		}
-	}
-
-	for name, columns := range uniqueIndexes {
-		if db := scope.NewDB().Table(scope.TableName()).Model(scope.Value).AddUniqueIndex(name, columns...); db.Error != nil {
-			scope.db.AddError(db.Error)
-		}
-	}
-
-	return scope
+	return
 }
 
 func (scope *Scope) getColumnAsArray(columns []string, values ...interface{}) (results [][]interface{}) {
@@ -0,0 +1,118 @@
+package gorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SoftDeleteStrategy defines how records are marked as deleted in their soft delete column
+type SoftDeleteStrategy interface {
+	// DeletedValue return the value the soft delete column is set to when deleting a record
+	DeletedValue() interface{}
+	// NotDeletedCondition return the condition on the quoted soft delete column matching records which are not deleted, with its arguments
+	NotDeletedCondition(quotedColumn string) (sql string, args []interface{})
+}
+
+type timestampSoftDelete struct{}
+
+func (timestampSoftDelete) DeletedValue() interface{} {
+	return NowFunc()
+}
+
+func (timestampSoftDelete) NotDeletedCondition(quotedColumn string) (string, []interface{}) {
+	return fmt.Sprintf("%v IS NULL", quotedColumn), nil
+}
+
+type flagSoftDelete struct{}
+
+func (flagSoftDelete) DeletedValue() interface{} {
+	return true
+}
+
+func (flagSoftDelete) NotDeletedCondition(quotedColumn string) (string, []interface{}) {
+	return fmt.Sprintf("%v = ?", quotedColumn), []interface{}{false}
+}
+
+type unixSoftDelete struct{}
+
+func (unixSoftDelete) DeletedValue() interface{} {
+	return NowFunc().Unix()
+}
+
+func (unixSoftDelete) NotDeletedCondition(quotedColumn string) (string, []interface{}) {
+	return fmt.Sprintf("%v = ?", quotedColumn), []interface{}{0}
+}
+
+var (
+	softDeleteStrategiesMutex sync.RWMutex
+	softDeleteStrategies      = map[string]SoftDeleteStrategy{
+		"timestamp": timestampSoftDelete{},
+		"flag":      flagSoftDelete{},
+		"unix":      unixSoftDelete{},
+	}
+)
+
+// RegisterSoftDeleteStrategy register a soft delete strategy, used by fields tagged `gorm:"soft_delete:<name>"`.
+// The built-in strategies are "timestamp" (a nullable time, the default of `DeletedAt`), "flag" (a boolean) and "unix" (a unix timestamp, 0 if not deleted)
+//     type User struct {
+//       ID      uint
+//       Deleted bool `gorm:"soft_delete:flag"`
+//     }
+func RegisterSoftDeleteStrategy(name string, strategy SoftDeleteStrategy) {
+	softDeleteStrategiesMutex.Lock()
+	defer softDeleteStrategiesMutex.Unlock()
+	softDeleteStrategies[name] = strategy
+}
+
+func softDeleteStrategy(name string) (SoftDeleteStrategy, bool) {
+	softDeleteStrategiesMutex.RLock()
+	defer softDeleteStrategiesMutex.RUnlock()
+	strategy, ok := softDeleteStrategies[name]
+	return strategy, ok
+}
+
+// softDeleteField return the soft delete column of the model and its strategy, the field tagged `soft_delete` or else `DeletedAt`
+func (scope *Scope) softDeleteField() (*Field, SoftDeleteStrategy, bool) {
+	for _, field := range scope.Fields() {
+		name, ok := field.TagSettingsGet("SOFT_DELETE")
+		if !ok || !field.IsNormal {
+			continue
+		}
+
+		if name == "SOFT_DELETE" {
+			// Without strategy name, choose the strategy by the type of the field
+			switch fieldType := indirectType(field.Struct.Type); {
+			case fieldType.Kind() == reflect.Bool:
+				name = "flag"
+			case fieldType == reflect.TypeOf(time.Time{}):
+				name = "timestamp"
+			default:
+				name = "unix"
+			}
+		}
+
+		strategy, ok := softDeleteStrategy(strings.ToLower(name))
+		if !ok {
+			scope.Err(fmt.Errorf("unknown soft delete strategy %v for field %v", name, field.Name))
+			return nil, nil, false
+		}
+		return field, strategy, true
+	}
+
+	if field, ok := scope.FieldByName("DeletedAt"); ok {
+		return field, timestampSoftDelete{}, true
+	}
+	return nil, nil, false
+}
+
+// notDeletedSQL return the condition matching records of the scope which are not soft deleted
+func (scope *Scope) notDeletedSQL(field *Field, strategy SoftDeleteStrategy) string {
+	sql, args := strategy.NotDeletedCondition(fmt.Sprintf("%v.%v", scope.QuotedTableName(), scope.Quote(field.DBName)))
+	for _, arg := range args {
+		sql = strings.Replace(sql, "?", scope.AddToVars(arg), 1)
+	}
+	return sql
+}
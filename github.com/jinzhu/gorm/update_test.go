@@ -1,6 +1,7 @@
 package gorm_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -463,3 +464,79 @@ func TestUpdateDecodeVirtualAttributes(t *testing.T) {
 		t.Errorf("should decode virtual attributes to struct, so it could be used in callbacks")
 	}
 }
+
+type VersionedProduct struct {
+	Id      int64
+	Code    string
+	Price   int64
+	Version int64 `gorm:"version"`
+}
+
+func TestOptimisticLocking(t *testing.T) {
+	DB.DropTableIfExists(&VersionedProduct{})
+	DB.AutoMigrate(&VersionedProduct{})
+
+	product := VersionedProduct{Code: "versioned", Price: 10}
+	DB.Save(&product)
+
+	var stale VersionedProduct
+	DB.First(&stale, product.Id)
+
+	product.Price = 20
+	if err := DB.Save(&product).Error; err != nil || product.Version != 1 {
+		t.Errorf("Save should increase the version, but got %v, %v", err, product.Version)
+	}
+
+	if err := DB.Model(&product).Update("price", 30).Error; err != nil || product.Version != 2 {
+		t.Errorf("Update should increase the version, but got %v, %v", err, product.Version)
+	}
+
+	stale.Price = 40
+	if err := DB.Save(&stale).Error; err != gorm.ErrStaleObject {
+		t.Errorf("Save of a changed record should return ErrStaleObject, but got %v", err)
+	}
+
+	if err := DB.Model(&stale).Update("price", 40).Error; err != gorm.ErrStaleObject {
+		t.Errorf("Update of a changed record should return ErrStaleObject, but got %v", err)
+	}
+
+	var result VersionedProduct
+	var count int
+	DB.First(&result, product.Id)
+	DB.Model(&VersionedProduct{}).Count(&count)
+	if result.Price != 30 || result.Version != 2 || count != 1 {
+		t.Errorf("Stale records should not be saved, but got %+v", result)
+	}
+}
+
+type FailingVersionedProduct struct {
+	Id      int64
+	Price   int64
+	Version int64 `gorm:"version"`
+	Fail    bool  `gorm:"-"`
+}
+
+func (p *FailingVersionedProduct) AfterUpdate() error {
+	if p.Fail {
+		return errors.New("after update failed")
+	}
+	return nil
+}
+
+func TestOptimisticLockingRollback(t *testing.T) {
+	DB.DropTableIfExists(&FailingVersionedProduct{})
+	DB.AutoMigrate(&FailingVersionedProduct{})
+
+	product := FailingVersionedProduct{Price: 10}
+	DB.Save(&product)
+
+	product.Price, product.Fail = 20, true
+	if err := DB.Save(&product).Error; err == nil || product.Version != 0 {
+		t.Errorf("Rolled back update should keep the version, but got %v, %v", err, product.Version)
+	}
+
+	product.Fail = false
+	if err := DB.Save(&product).Error; err != nil || product.Version != 1 {
+		t.Errorf("Save after a rolled back update should increase the version, but got %v, %v", err, product.Version)
+	}
+}
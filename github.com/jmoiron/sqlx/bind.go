@@ -37,6 +37,33 @@ func BindType(driverName string) int {
 	return UNKNOWN
 }
 
+// MaxBindVars returns the maximum number of bindvars in a single statement for
+// a given database given a drivername.  Bulk NamedExec splits slices into
+// chunks that stay under this limit, and under 1000 rows for sqlserver.
+func MaxBindVars(driverName string) int {
+	switch driverName {
+	case "postgres", "pgx", "pq-timeouts", "cloudsqlpostgres":
+		return 65535
+	case "mysql":
+		return 65535
+	case "sqlserver":
+		return 2100
+	case "oci8", "ora", "goracle":
+		return 65535
+	}
+	// sqlite3 before 3.32 and unknown drivers
+	return 999
+}
+
+// maxValuesRows returns the maximum number of rows of a VALUES clause for a
+// given drivername, or 0 if only the number of bindvars is limited.
+func maxValuesRows(driverName string) int {
+	if driverName == "sqlserver" {
+		return 1000
+	}
+	return 0
+}
+
 // FIXME: this should be able to be tolerant of escaped ?'s in queries without
 // losing much speed, and should be to avoid confusion.
 
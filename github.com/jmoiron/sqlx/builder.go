@@ -0,0 +1,327 @@
+package sqlx
+
+// Query Builder
+//
+//  * SelectFrom, InsertInto, Update - build simple statements
+//    for a table, with columns named by the Mapper of the Ext they run on
+//
+// Conditions use the '?' bindvar and are rebound to the bindtype of the
+// driver, so the same builder can run on any database.
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+type condition struct {
+	query string
+	args  []interface{}
+}
+
+// whereClause returns the conditions joined with AND, with their args.
+func whereClause(conds []condition) (string, []interface{}) {
+	if len(conds) == 0 {
+		return "", nil
+	}
+	var args []interface{}
+	parts := make([]string, 0, len(conds))
+	for _, c := range conds {
+		parts = append(parts, "("+c.query+")")
+		args = append(args, c.args...)
+	}
+	return " WHERE " + strings.Join(parts, " AND "), args
+}
+
+// SelectBuilder builds a SELECT statement.
+type SelectBuilder struct {
+	table   string
+	columns []string
+	model   interface{}
+	where   []condition
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+// SelectFrom starts a SELECT statement on table for columns, or all columns if
+// none are given.
+func SelectFrom(table string, columns ...string) *SelectBuilder {
+	return &SelectBuilder{table: table, columns: columns, limit: -1, offset: -1}
+}
+
+// ColumnsOf selects the columns of the fields of model, a struct or a pointer
+// to one, as named by the Mapper.
+func (b *SelectBuilder) ColumnsOf(model interface{}) *SelectBuilder {
+	b.model = model
+	return b
+}
+
+// Where adds a condition using the '?' bindvar.  Conditions are joined with AND.
+func (b *SelectBuilder) Where(query string, args ...interface{}) *SelectBuilder {
+	b.where = append(b.where, condition{query, args})
+	return b
+}
+
+// OrderBy adds ORDER BY expressions, eg. "last_name DESC".
+func (b *SelectBuilder) OrderBy(exprs ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, exprs...)
+	return b
+}
+
+// Limit sets the maximum number of rows returned.
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Offset sets the number of rows skipped.
+func (b *SelectBuilder) Offset(offset int) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// ToSQL returns the statement for the bindtype of e, and its args.
+func (b *SelectBuilder) ToSQL(e Ext) (string, []interface{}, error) {
+	columns := b.columns
+	if b.model != nil {
+		modelColumns, err := structColumns(mapperFor(e), b.model)
+		if err != nil {
+			return "", nil, err
+		}
+		columns = append(append([]string{}, columns...), modelColumns...)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("SELECT ")
+	if len(columns) == 0 {
+		buf.WriteString("*")
+	} else {
+		buf.WriteString(strings.Join(columns, ", "))
+	}
+	buf.WriteString(" FROM ")
+	buf.WriteString(b.table)
+
+	where, args := whereClause(b.where)
+	buf.WriteString(where)
+	if len(b.orderBy) > 0 {
+		buf.WriteString(" ORDER BY ")
+		buf.WriteString(strings.Join(b.orderBy, ", "))
+	}
+	if b.limit >= 0 {
+		fmt.Fprintf(&buf, " LIMIT %d", b.limit)
+	}
+	if b.offset >= 0 {
+		fmt.Fprintf(&buf, " OFFSET %d", b.offset)
+	}
+	return e.Rebind(buf.String()), args, nil
+}
+
+// Select runs the statement on e, scanning the rows into dest like Select.
+func (b *SelectBuilder) Select(e Ext, dest interface{}) error {
+	query, args, err := b.ToSQL(e)
+	if err != nil {
+		return err
+	}
+	return Select(e, dest, query, args...)
+}
+
+// Get runs the statement on e, scanning a single row into dest like Get.
+func (b *SelectBuilder) Get(e Ext, dest interface{}) error {
+	query, args, err := b.ToSQL(e)
+	if err != nil {
+		return err
+	}
+	return Get(e, dest, query, args...)
+}
+
+// InsertBuilder builds an INSERT statement.
+type InsertBuilder struct {
+	table string
+	arg   interface{}
+	omit  []string
+}
+
+// InsertInto starts an INSERT statement on table for arg, a struct, a
+// map[string]interface{} or a slice or array of them.  The columns are the
+// ones of the fields of the struct, as named by the Mapper, or the keys of
+// the map;  slices insert a row for each element, as with a bulk NamedExec.
+func InsertInto(table string, arg interface{}) *InsertBuilder {
+	return &InsertBuilder{table: table, arg: arg}
+}
+
+// Omit leaves columns out of the statement, eg. auto-increment keys.
+func (b *InsertBuilder) Omit(columns ...string) *InsertBuilder {
+	b.omit = append(b.omit, columns...)
+	return b
+}
+
+// namedQuery returns the INSERT statement with named parameters.
+func (b *InsertBuilder) namedQuery(m *reflectx.Mapper) (string, error) {
+	elem := b.arg
+	if isBulkArg(b.arg) {
+		v := reflect.ValueOf(b.arg)
+		if v.Len() == 0 {
+			return "", fmt.Errorf("length of array is 0: %#v", b.arg)
+		}
+		elem = v.Index(0).Interface()
+	}
+	columns, err := argColumns(m, elem)
+	if err != nil {
+		return "", err
+	}
+	columns = omitColumns(columns, b.omit)
+	if len(columns) == 0 {
+		return "", errors.New("no columns to insert")
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (:%s)", b.table, strings.Join(columns, ", "), strings.Join(columns, ", :")), nil
+}
+
+// ToSQL returns the statement for the bindtype of e, and its args.  Unlike
+// Exec, slices are bound in a single statement.
+func (b *InsertBuilder) ToSQL(e Ext) (string, []interface{}, error) {
+	m := mapperFor(e)
+	query, err := b.namedQuery(m)
+	if err != nil {
+		return "", nil, err
+	}
+	return bindNamedMapper(BindType(e.DriverName()), query, b.arg, m)
+}
+
+// Exec runs the statement on e with NamedExec.
+func (b *InsertBuilder) Exec(e Ext) (sql.Result, error) {
+	query, err := b.namedQuery(mapperFor(e))
+	if err != nil {
+		return nil, err
+	}
+	return NamedExec(e, query, b.arg)
+}
+
+// UpdateBuilder builds an UPDATE statement.
+type UpdateBuilder struct {
+	table string
+	arg   interface{}
+	omit  []string
+	where []condition
+}
+
+// Update starts an UPDATE statement on table setting the columns of arg, a
+// struct or a map[string]interface{}, to its values.
+func Update(table string, arg interface{}) *UpdateBuilder {
+	return &UpdateBuilder{table: table, arg: arg}
+}
+
+// Omit leaves columns out of the SET clause, eg. primary keys.
+func (b *UpdateBuilder) Omit(columns ...string) *UpdateBuilder {
+	b.omit = append(b.omit, columns...)
+	return b
+}
+
+// Where adds a condition using the '?' bindvar.  Conditions are joined with AND.
+func (b *UpdateBuilder) Where(query string, args ...interface{}) *UpdateBuilder {
+	b.where = append(b.where, condition{query, args})
+	return b
+}
+
+// ToSQL returns the statement for the bindtype of e, and its args.
+func (b *UpdateBuilder) ToSQL(e Ext) (string, []interface{}, error) {
+	m := mapperFor(e)
+	columns, err := argColumns(m, b.arg)
+	if err != nil {
+		return "", nil, err
+	}
+	columns = omitColumns(columns, b.omit)
+	if len(columns) == 0 {
+		return "", nil, errors.New("no columns to update")
+	}
+
+	args, err := bindAnyArgs(columns, b.arg, m)
+	if err != nil {
+		return "", nil, err
+	}
+	sets := make([]string, 0, len(columns))
+	for _, column := range columns {
+		sets = append(sets, column+" = ?")
+	}
+
+	where, whereArgs := whereClause(b.where)
+	query := "UPDATE " + b.table + " SET " + strings.Join(sets, ", ") + where
+	return e.Rebind(query), append(args, whereArgs...), nil
+}
+
+// Exec runs the statement on e.
+func (b *UpdateBuilder) Exec(e Ext) (sql.Result, error) {
+	query, args, err := b.ToSQL(e)
+	if err != nil {
+		return nil, err
+	}
+	return e.Exec(query, args...)
+}
+
+// argColumns returns the columns of a struct or map[string]interface{} arg.
+// The keys of maps are sorted so that the generated SQL is stable.
+func argColumns(m *reflectx.Mapper, arg interface{}) ([]string, error) {
+	if maparg, ok := arg.(map[string]interface{}); ok {
+		columns := make([]string, 0, len(maparg))
+		for column := range maparg {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		return columns, nil
+	}
+	return structColumns(m, arg)
+}
+
+// structColumns returns the names of the fields of a struct that map to
+// columns:  fields of embedded structs are included, while nested structs
+// which are not scannable, like time.Time, are left out.
+func structColumns(m *reflectx.Mapper, model interface{}) ([]string, error) {
+	if model == nil {
+		return nil, errors.New("expected a struct, got nil")
+	}
+	t := reflectx.Deref(reflect.TypeOf(model))
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %T", model)
+	}
+
+	var columns []string
+	seen := map[string]bool{}
+	for _, fi := range m.TypeMap(t).Index {
+		if fi.Embedded || fi.Name == "" || strings.Contains(fi.Path, ".") || seen[fi.Path] {
+			continue
+		}
+		if !isScannable(reflectx.Deref(fi.Field.Type)) {
+			continue
+		}
+		seen[fi.Path] = true
+		columns = append(columns, fi.Path)
+	}
+	return columns, nil
+}
+
+// omitColumns returns columns without the omitted ones.
+func omitColumns(columns, omit []string) []string {
+	if len(omit) == 0 {
+		return columns
+	}
+	result := make([]string, 0, len(columns))
+	for _, column := range columns {
+		omitted := false
+		for _, o := range omit {
+			if column == o {
+				omitted = true
+				break
+			}
+		}
+		if !omitted {
+			result = append(result, column)
+		}
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package sqlx
+
+import (
+	"reflect"
+	"testing"
+)
+
+type builderPlace struct {
+	Country string
+	City    string
+	TelCode int `db:"telcode"`
+}
+
+type builderPerson struct {
+	ID int `db:"id"`
+	Person
+	Place  builderPlace
+	Secret string `db:"-"`
+}
+
+func TestSelectBuilder(t *testing.T) {
+	pg := NewDb(nil, "postgres")
+	my := NewDb(nil, "mysql")
+
+	q, args, err := SelectFrom("person").ColumnsOf(&builderPerson{}).
+		Where("first_name = ?", "Jason").
+		Where("email LIKE ? OR email = ?", "%@example.com", "").
+		OrderBy("last_name", "email DESC").Limit(10).Offset(5).ToSQL(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "SELECT id, first_name, last_name, email, added_at FROM person WHERE (first_name = $1) AND (email LIKE $2 OR email = $3) ORDER BY last_name, email DESC LIMIT 10 OFFSET 5"
+	if q != expected {
+		t.Errorf("expected %s, got %s", expected, q)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Jason", "%@example.com", ""}) {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	q, args, err = SelectFrom("person", "count(*)").Where("first_name = ?", "Jason").ToSQL(my)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q != "SELECT count(*) FROM person WHERE (first_name = ?)" || len(args) != 1 {
+		t.Errorf("unexpected query %s %v", q, args)
+	}
+
+	if _, _, err = SelectFrom("person").ColumnsOf(1).ToSQL(pg); err == nil {
+		t.Error("expected error for columns of a non-struct")
+	}
+}
+
+func TestInsertBuilder(t *testing.T) {
+	pg := NewDb(nil, "postgres")
+
+	p := builderPerson{ID: 1, Person: Person{FirstName: "Jason", LastName: "Moiron", Email: "jmoiron@jmoiron.net"}}
+	q, args, err := InsertInto("person", p).Omit("id", "added_at").ToSQL(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q != "INSERT INTO person (first_name, last_name, email) VALUES ($1, $2, $3)" {
+		t.Errorf("unexpected query %s", q)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Jason", "Moiron", "jmoiron@jmoiron.net"}) {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	q, args, err = InsertInto("place", []map[string]interface{}{
+		{"country": "Hong Kong", "telcode": 852},
+		{"country": "Singapore", "telcode": 65},
+	}).ToSQL(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q != "INSERT INTO place (country, telcode) VALUES ($1, $2),($3, $4)" || len(args) != 4 {
+		t.Errorf("unexpected query %s %v", q, args)
+	}
+
+	if _, _, err = InsertInto("person", []Person{}).ToSQL(pg); err == nil {
+		t.Error("expected error for empty slice")
+	}
+}
+
+func TestUpdateBuilder(t *testing.T) {
+	pg := NewDb(nil, "postgres")
+
+	p := builderPerson{ID: 1, Person: Person{FirstName: "Jason", LastName: "Moiron", Email: "jmoiron@jmoiron.net"}}
+	q, args, err := Update("person", &p).Omit("id", "added_at").Where("id = ?", p.ID).ToSQL(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q != "UPDATE person SET first_name = $1, last_name = $2, email = $3 WHERE (id = $4)" {
+		t.Errorf("unexpected query %s", q)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Jason", "Moiron", "jmoiron@jmoiron.net", 1}) {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	q, args, err = Update("place", map[string]interface{}{"telcode": 65, "city": "Singapore"}).Where("country = ?", "Singapore").ToSQL(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q != "UPDATE place SET city = $1, telcode = $2 WHERE (country = $3)" || len(args) != 3 {
+		t.Errorf("unexpected query %s %v", q, args)
+	}
+
+	if _, _, err = Update("person", map[string]interface{}{}).ToSQL(pg); err == nil {
+		t.Error("expected error without columns")
+	}
+}
+
+func TestBuilderDB(t *testing.T) {
+	RunWithSchema(defaultSchema, t, func(db *DB, t *testing.T) {
+		people := []Person{
+			{FirstName: "Ardie", LastName: "Savea", Email: "asavea@ab.co.nz"},
+			{FirstName: "Ngani", LastName: "Laumape", Email: "nlaumape@ab.co.nz"},
+		}
+		if _, err := InsertInto("person", people).Omit("added_at").Exec(db); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Update("person", map[string]interface{}{"last_name": "Savea Jr"}).Where("email = ?", "asavea@ab.co.nz").Exec(db); err != nil {
+			t.Fatal(err)
+		}
+
+		var result []Person
+		err := SelectFrom("person").ColumnsOf(Person{}).Where("email LIKE ?", "%@ab.co.nz").OrderBy("first_name").Select(db, &result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 2 || result[0].LastName != "Savea Jr" || result[1].FirstName != "Ngani" {
+			t.Errorf("unexpected people %+v", result)
+		}
+
+		var p Person
+		if err = SelectFrom("person").Where("first_name = ?", "Ngani").Get(db, &p); err != nil || p.Email != "nlaumape@ab.co.nz" {
+			t.Errorf("unexpected person %+v, %v", p, err)
+		}
+	})
+}
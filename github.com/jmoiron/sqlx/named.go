@@ -212,24 +212,61 @@ func bindStruct(bindType int, query string, arg interface{}, m *reflectx.Mapper)
 
 var (
 	EndBracketsReg = regexp.MustCompile(`\([^()]*\)\s*$`)
+	valuesReg      = regexp.MustCompile(`(?i)\bVALUES\s*\(`)
 )
 
+// valuesGroup returns the start and end of the parenthesized group following
+// the VALUES keyword of an INSERT statement, so that it can be repeated for
+// multi-row inserts even when the statement has a suffix like RETURNING or
+// ON CONFLICT.
+func valuesGroup(bound string) (start, end int, ok bool) {
+	loc := valuesReg.FindStringIndex(bound)
+	if loc == nil {
+		return 0, 0, false
+	}
+	start = loc[1] - 1
+	depth := 0
+	for i := start; i < len(bound); i++ {
+		switch bound[i] {
+		case '\'':
+			// skip string literals, which may contain parentheses
+			for i++; i < len(bound) && bound[i] != '\''; i++ {
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return start, i + 1, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
 func fixBound(bound string, loop int) string {
-	endBrackets := EndBracketsReg.FindString(bound)
-	if endBrackets == "" {
-		return bound
+	start, end, ok := valuesGroup(bound)
+	if !ok {
+		endBrackets := EndBracketsReg.FindStringIndex(bound)
+		if endBrackets == nil {
+			return bound
+		}
+		start, end = endBrackets[0], endBrackets[1]
 	}
+	group := bound[start:end]
+
 	var buffer bytes.Buffer
-	buffer.WriteString(bound)
+	buffer.WriteString(bound[:end])
 	for i := 0; i < loop-1; i++ {
 		buffer.WriteString(",")
-		buffer.WriteString(endBrackets)
+		buffer.WriteString(group)
 	}
+	buffer.WriteString(bound[end:])
 	return buffer.String()
 }
 
 // bindArray binds a named parameter query with fields from an array or slice of
-// structs argument.
+// structs or maps argument.
 func bindArray(bindType int, query string, arg interface{}, m *reflectx.Mapper) (string, []interface{}, error) {
 	// do the initial binding with QUESTION;  if bindType is not question,
 	// we can rebind it at the end.
@@ -238,13 +275,19 @@ func bindArray(bindType int, query string, arg interface{}, m *reflectx.Mapper)
 		return "", []interface{}{}, err
 	}
 	arrayValue := reflect.ValueOf(arg)
-	arrayLen := arrayValue.Len()
-	if arrayLen == 0 {
+	if arrayValue.Len() == 0 {
 		return "", []interface{}{}, fmt.Errorf("length of array is 0: %#v", arg)
 	}
+	return bindArrayValue(bindType, bound, names, arrayValue, m)
+}
+
+// bindArrayValue binds the elements of arrayValue to a query compiled with the
+// QUESTION bindvar, repeating its VALUES group for each element.
+func bindArrayValue(bindType int, bound string, names []string, arrayValue reflect.Value, m *reflectx.Mapper) (string, []interface{}, error) {
+	arrayLen := arrayValue.Len()
 	var arglist []interface{}
 	for i := 0; i < arrayLen; i++ {
-		elemArglist, err := bindArgs(names, arrayValue.Index(i).Interface(), m)
+		elemArglist, err := bindAnyArgs(names, arrayValue.Index(i).Interface(), m)
 		if err != nil {
 			return "", []interface{}{}, err
 		}
@@ -260,6 +303,102 @@ func bindArray(bindType int, query string, arg interface{}, m *reflectx.Mapper)
 	return bound, arglist, nil
 }
 
+// isBulkArg returns whether arg is a slice or array of structs or maps to be
+// bound to a bulk named query.
+func isBulkArg(arg interface{}) bool {
+	if arg == nil {
+		return false
+	}
+	switch reflect.TypeOf(arg).Kind() {
+	case reflect.Array, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+// bulkResult is the result of a bulk NamedExec run as several statements.
+type bulkResult []sql.Result
+
+// LastInsertId returns the last insert id of the last statement.
+func (r bulkResult) LastInsertId() (int64, error) {
+	return r[len(r)-1].LastInsertId()
+}
+
+// RowsAffected returns the total number of rows affected by the statements.
+func (r bulkResult) RowsAffected() (int64, error) {
+	var total int64
+	for _, result := range r {
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// bulkNamedExec binds a named query to the elements of a slice or array arg
+// and runs it with exec.  INSERT statements get a multi-row VALUES clause and
+// are split into chunks that stay under the driver's MaxBindVars and row
+// limit;  other statements are run once for each element.
+func bulkNamedExec(driverName, query string, arg interface{}, m *reflectx.Mapper, exec func(string, ...interface{}) (sql.Result, error)) (sql.Result, error) {
+	bound, names, err := compileNamedQuery([]byte(query), QUESTION)
+	if err != nil {
+		return nil, err
+	}
+
+	arrayValue := reflect.ValueOf(arg)
+	arrayLen := arrayValue.Len()
+	if arrayLen == 0 {
+		return nil, fmt.Errorf("length of array is 0: %#v", arg)
+	}
+	if arrayValue.Kind() == reflect.Array {
+		// arrays must be addressable to be sliced into chunks
+		addressable := reflect.New(arrayValue.Type()).Elem()
+		addressable.Set(arrayValue)
+		arrayValue = addressable
+	}
+
+	chunkSize := 1
+	if _, _, ok := valuesGroup(bound); ok {
+		chunkSize = arrayLen
+		if len(names) > 0 && MaxBindVars(driverName)/len(names) < chunkSize {
+			chunkSize = MaxBindVars(driverName) / len(names)
+		}
+		if maxRows := maxValuesRows(driverName); maxRows > 0 && maxRows < chunkSize {
+			chunkSize = maxRows
+		}
+		if chunkSize < 1 {
+			return nil, fmt.Errorf("%d bindvars per row exceed the limit of %d for %s", len(names), MaxBindVars(driverName), driverName)
+		}
+	}
+
+	var results bulkResult
+	for start := 0; start < arrayLen; start += chunkSize {
+		end := start + chunkSize
+		if end > arrayLen {
+			end = arrayLen
+		}
+		q, args, err := bindArrayValue(BindType(driverName), bound, names, arrayValue.Slice(start, end), m)
+		if err == nil {
+			var result sql.Result
+			if result, err = exec(q, args...); err == nil {
+				results = append(results, result)
+				continue
+			}
+		}
+		// report the statements that ran before the failure
+		if len(results) == 0 {
+			return nil, err
+		}
+		return results, err
+	}
+	if len(results) == 1 {
+		return results[0], nil
+	}
+	return results, nil
+}
+
 // bindMap binds a named parameter query with a map of arguments.
 func bindMap(bindType int, query string, args map[string]interface{}) (string, []interface{}, error) {
 	bound, names, err := compileNamedQuery([]byte(query), bindType)
@@ -404,7 +543,20 @@ func NamedQuery(e Ext, query string, arg interface{}) (*Rows, error) {
 // NamedExec uses BindStruct to get a query executable by the driver and
 // then runs Exec on the result.  Returns an error from the binding
 // or the query execution itself.
+//
+// If arg is a slice or array of structs or maps, INSERT statements are run
+// with a multi-row VALUES clause, split into as many statements as needed to
+// stay under the driver's MaxBindVars;  other statements are run once for
+// each element.  The sql.Result then reports the total rows affected and the
+// last insert id of the last statement.
+//
+// The statements of a bulk NamedExec are not atomic:  if one fails, those
+// that ran before it are not rolled back, and the returned sql.Result reports
+// them along with the error.  Pass a *Tx to insert all elements or none.
 func NamedExec(e Ext, query string, arg interface{}) (sql.Result, error) {
+	if isBulkArg(arg) {
+		return bulkNamedExec(e.DriverName(), query, arg, mapperFor(e), e.Exec)
+	}
 	q, args, err := bindNamedMapper(BindType(e.DriverName()), query, arg, mapperFor(e))
 	if err != nil {
 		return nil, err
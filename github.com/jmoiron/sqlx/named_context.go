@@ -122,8 +122,14 @@ func NamedQueryContext(ctx context.Context, e ExtContext, query string, arg inte
 
 // NamedExecContext uses BindStruct to get a query executable by the driver and
 // then runs Exec on the result.  Returns an error from the binding
-// or the query execution itself.
+// or the query execution itself.  Slices and arrays are bound like in
+// NamedExec, and a *Tx must be passed for their statements to be atomic.
 func NamedExecContext(ctx context.Context, e ExtContext, query string, arg interface{}) (sql.Result, error) {
+	if isBulkArg(arg) {
+		return bulkNamedExec(e.DriverName(), query, arg, mapperFor(e), func(q string, args ...interface{}) (sql.Result, error) {
+			return e.ExecContext(ctx, q, args...)
+		})
+	}
 	q, args, err := bindNamedMapper(BindType(e.DriverName()), query, arg, mapperFor(e))
 	if err != nil {
 		return nil, err
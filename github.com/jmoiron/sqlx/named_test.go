@@ -2,6 +2,10 @@ package sqlx
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -262,3 +266,128 @@ func TestNamedQueries(t *testing.T) {
 
 	})
 }
+
+// recordingExt records the statements executed with Exec.
+type recordingExt struct {
+	*DB
+	queries []string
+	args    [][]interface{}
+	failAt  int // 1-based index of the statement to fail, if non-zero
+}
+
+func (r *recordingExt) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if len(r.queries)+1 == r.failAt {
+		return nil, errors.New("exec failed")
+	}
+	r.queries = append(r.queries, query)
+	r.args = append(r.args, args)
+	return driver.RowsAffected(len(args)), nil
+}
+
+func TestBulkNamedExec(t *testing.T) {
+	people := make([]Person, 1000)
+	for i := range people {
+		people[i] = Person{FirstName: fmt.Sprintf("first%d", i), LastName: "last", Email: fmt.Sprintf("%d@example.com", i)}
+	}
+
+	e := &recordingExt{DB: NewDb(nil, "sqlite3")}
+	res, err := NamedExec(e, `INSERT INTO person (first_name, last_name, email) VALUES (:first_name, :last_name, :email) ON CONFLICT DO NOTHING`, people)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sqlite3 allows 999 bindvars, so 333 rows of 3 columns per statement
+	if len(e.queries) != 4 {
+		t.Fatalf("expected 4 statements, got %d", len(e.queries))
+	}
+	for i, rows := range []int{333, 333, 333, 1} {
+		if len(e.args[i]) != rows*3 || strings.Count(e.queries[i], "(?, ?, ?)") != rows {
+			t.Errorf("expected statement %d to insert %d rows, got %d args", i, rows, len(e.args[i]))
+		}
+		if !strings.HasSuffix(e.queries[i], " ON CONFLICT DO NOTHING") {
+			t.Errorf("expected statement suffix to be kept, got %s", e.queries[i])
+		}
+	}
+	if e.args[3][0] != "first999" {
+		t.Errorf("expected last chunk to start with the last person, got %v", e.args[3][0])
+	}
+	if n, _ := res.RowsAffected(); n != 3000 {
+		t.Errorf("expected total rows affected of all statements, got %d", n)
+	}
+
+	// sqlserver allows 2100 bindvars, but only 1000 rows per VALUES clause
+	e = &recordingExt{DB: NewDb(nil, "sqlserver")}
+	emails := make([]map[string]interface{}, 1500)
+	for i := range emails {
+		emails[i] = map[string]interface{}{"email": fmt.Sprintf("%d@example.com", i)}
+	}
+	_, err = NamedExec(e, `INSERT INTO person (email) VALUES (:email)`, emails)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.args) != 2 || len(e.args[0]) != 1000 || len(e.args[1]) != 500 {
+		t.Errorf("expected statements of 1000 and 500 rows, got %d statements", len(e.args))
+	}
+
+	e = &recordingExt{DB: NewDb(nil, "postgres")}
+	maps := []map[string]interface{}{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}}
+	_, err = NamedExec(e, `INSERT INTO t (id, name) VALUES (:id, :name) RETURNING id`, maps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.queries) != 1 || e.queries[0] != `INSERT INTO t (id, name) VALUES ($1, $2),($3, $4) RETURNING id` {
+		t.Errorf("expected a single statement for slices of maps, got %v", e.queries)
+	}
+
+	e = &recordingExt{DB: NewDb(nil, "postgres")}
+	_, err = NamedExec(e, `UPDATE t SET name = :name WHERE id = :id`, maps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.queries) != 2 || e.queries[1] != `UPDATE t SET name = $1 WHERE id = $2` || e.args[1][1] != 2 {
+		t.Errorf("expected statements without VALUES to run for each element, got %v %v", e.queries, e.args)
+	}
+
+	if _, err = NamedExec(e, `INSERT INTO t (id) VALUES (:id)`, []Person{}); err == nil {
+		t.Error("expected error for empty slice")
+	}
+
+	e = &recordingExt{DB: NewDb(nil, "sqlite3"), failAt: 3}
+	res, err = NamedExec(e, `INSERT INTO person (first_name, last_name, email) VALUES (:first_name, :last_name, :email)`, people)
+	if err == nil || res == nil {
+		t.Fatalf("expected partial result and error, got %v, %v", res, err)
+	}
+	if n, _ := res.RowsAffected(); n != 1998 {
+		t.Errorf("expected rows affected of the statements run before the failure, got %d", n)
+	}
+
+	e = &recordingExt{DB: NewDb(nil, "sqlite3"), failAt: 1}
+	if res, err = NamedExec(e, `INSERT INTO person (first_name, last_name, email) VALUES (:first_name, :last_name, :email)`, people); err == nil || res != nil {
+		t.Errorf("expected no result and error, got %v, %v", res, err)
+	}
+}
+
+func TestBulkNamedExecDB(t *testing.T) {
+	RunWithSchema(defaultSchema, t, func(db *DB, t *testing.T) {
+		people := make([]Person, 500)
+		for i := range people {
+			people[i] = Person{FirstName: "bulk", LastName: fmt.Sprint(i), Email: fmt.Sprintf("bulk%d@example.com", i)}
+		}
+		res, err := db.NamedExec(`INSERT INTO person (first_name, last_name, email) VALUES (:first_name, :last_name, :email)`, people)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := res.RowsAffected(); n != 500 {
+			t.Errorf("expected 500 rows affected, got %d", n)
+		}
+
+		var count int
+		err = db.Get(&count, db.Rebind("SELECT count(*) FROM person WHERE first_name = ?"), "bulk")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 500 {
+			t.Errorf("expected 500 people, got %d", count)
+		}
+	})
+}
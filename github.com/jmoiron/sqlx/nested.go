@@ -0,0 +1,334 @@
+package sqlx
+
+// Nested Scanning
+//
+//  * NestedScan, SelectNested - fold the rows of a JOIN into a slice of
+//    structs with slice-of-struct children and pointer-nullable relations
+//
+// Columns of children are named by their path from the top level struct, as
+// with nested structs, eg. "orders.id" for the "id" of an element of a field
+// `Orders []Order db:"orders"`.  Each level is identified by the column of its
+// primary key, and rows with the same keys are merged into the same structs.
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// nestedLevel is a struct type scanned from the rows:  the top level, or the
+// elements of a slice field of its parent level.
+type nestedLevel struct {
+	path     string
+	parent   *nestedLevel
+	children []*nestedLevel
+	// field is the traversal of the slice field in the parent level
+	field []int
+	typ   reflect.Type
+	isPtr bool
+	// key is the index of the key column
+	key int
+	// columns are the indexes of the columns of this level, and traversals
+	// their traversals in typ
+	columns    []int
+	traversals [][]int
+}
+
+// set sets the fields of v, a pointer to a new struct of the level, to the
+// values scanned in holders.  NULL values are skipped, so that pointers to
+// structs whose columns are all NULL are not allocated.
+func (l *nestedLevel) set(v reflect.Value, holders []reflect.Value) {
+	for i, column := range l.columns {
+		value := holders[column].Elem()
+		if value.IsNil() {
+			continue
+		}
+		reflectx.FieldByIndexes(v, l.traversals[i]).Set(value.Elem())
+	}
+}
+
+// nestedNode is a struct scanned from the rows, with its children by level.
+type nestedNode struct {
+	value    reflect.Value
+	children map[*nestedLevel]*nestedNodes
+}
+
+// build sets the slice fields of the node to its children, and returns its
+// value as an element of the slice of its level.
+func (n *nestedNode) build(level *nestedLevel) reflect.Value {
+	for _, child := range level.children {
+		nodes := n.children[child]
+		if len(nodes.nodes) == 0 {
+			continue
+		}
+		field := reflectx.FieldByIndexes(n.value, child.field)
+		slice := reflect.MakeSlice(field.Type(), 0, len(nodes.nodes))
+		for _, node := range nodes.nodes {
+			slice = reflect.Append(slice, node.build(child))
+		}
+		field.Set(slice)
+	}
+	if level.isPtr {
+		return n.value
+	}
+	return n.value.Elem()
+}
+
+// nestedNodes is the set of nodes of a level by their key, in the order in
+// which they were added.
+type nestedNodes struct {
+	keys  map[interface{}]*nestedNode
+	nodes []*nestedNode
+}
+
+func newNestedNodes() *nestedNodes {
+	return &nestedNodes{keys: map[interface{}]*nestedNode{}}
+}
+
+// NestedScan scans all rows into dest, which must be a pointer to a slice of
+// structs, folding the rows of a JOIN into nested structs.  keys names the
+// primary key column of each level:  the first is the one of the top level
+// struct, and the others the ones of the elements of slice-of-struct fields,
+// named by their path, eg:
+//
+//    type Order struct {
+//        ID    int `db:"id"`
+//        Total int `db:"total"`
+//    }
+//    type Customer struct {
+//        ID      int      `db:"id"`
+//        Name    string   `db:"name"`
+//        Address *Address `db:"address"`
+//        Orders  []Order  `db:"orders"`
+//    }
+//
+//    rows, _ := db.Queryx(`SELECT c.id, c.name, a.city AS "address.city",
+//        o.id AS "orders.id", o.total AS "orders.total" FROM customer c
+//        LEFT JOIN address a ON a.id = c.address_id
+//        LEFT JOIN orders o ON o.customer_id = c.id`)
+//    var customers []Customer
+//    err := sqlx.NestedScan(rows, &customers, "id", "orders.id")
+//
+// Rows with the same keys are merged into the same structs, in the order in
+// which they are first seen, so that the rows need not be sorted.  Children
+// whose key is NULL, like for a LEFT JOIN without match, are left out, and
+// pointers to structs are left nil when all of their columns are NULL.
+// The rows are closed once scanned.
+func NestedScan(rows rowsi, dest interface{}, keys ...string) error {
+	defer rows.Close()
+
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr {
+		return errors.New("must pass a pointer, not a value, to NestedScan destination")
+	}
+	if value.IsNil() {
+		return errors.New("nil pointer passed to NestedScan destination")
+	}
+	slice, err := baseType(value.Type(), reflect.Slice)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("NestedScan requires the key column of the top level")
+	}
+
+	var m *reflectx.Mapper
+	switch r := rows.(type) {
+	case *Rows:
+		m = r.Mapper
+	default:
+		m = mapper()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	top := &nestedLevel{typ: reflectx.Deref(slice.Elem()), isPtr: slice.Elem().Kind() == reflect.Ptr}
+	if top.typ.Kind() != reflect.Struct {
+		return fmt.Errorf("expected a slice of structs, got %s", value.Type())
+	}
+	levels, err := nestedLevels(m, top, columns, keys)
+	if err != nil {
+		return err
+	}
+
+	// every column is scanned into a pointer to a pointer to its field, so
+	// that NULL values can be told apart;  unknown columns of unsafe rows are
+	// discarded
+	values := make([]interface{}, len(columns))
+	holders := make([]reflect.Value, len(columns))
+	for _, level := range levels {
+		if err = nestedColumns(m, level, levels, columns, isUnsafe(rows)); err != nil {
+			return fmt.Errorf("%s in %T", err, dest)
+		}
+		for i, column := range level.columns {
+			t := level.typ.FieldByIndex(level.traversals[i]).Type
+			holders[column] = reflect.New(reflect.PtrTo(t))
+			values[column] = holders[column].Interface()
+		}
+	}
+	for i := range values {
+		if values[i] == nil {
+			values[i] = new(interface{})
+		}
+	}
+
+	root := newNestedNodes()
+	for rows.Next() {
+		if err = rows.Scan(values...); err != nil {
+			return err
+		}
+
+		nodes := map[*nestedLevel]*nestedNode{}
+		for _, level := range levels {
+			siblings := root
+			if level.parent != nil {
+				parent, ok := nodes[level.parent]
+				if !ok {
+					continue
+				}
+				siblings = parent.children[level]
+			}
+
+			holder := holders[level.key].Elem()
+			if holder.IsNil() {
+				if level.parent == nil {
+					return fmt.Errorf("NULL value for the top level key %s", columns[level.key])
+				}
+				continue
+			}
+			key := holder.Elem().Interface()
+			if !holder.Elem().Type().Comparable() {
+				key = fmt.Sprint(key)
+			}
+
+			node, ok := siblings.keys[key]
+			if !ok {
+				node = &nestedNode{value: reflect.New(level.typ), children: map[*nestedLevel]*nestedNodes{}}
+				for _, child := range level.children {
+					node.children[child] = newNestedNodes()
+				}
+				level.set(node.value, holders)
+				siblings.keys[key] = node
+				siblings.nodes = append(siblings.nodes, node)
+			}
+			nodes[level] = node
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	direct := reflect.Indirect(value)
+	for _, node := range root.nodes {
+		direct.Set(reflect.Append(direct, node.build(top)))
+	}
+	return nil
+}
+
+// SelectNested executes a query using the provided Queryer, and scans the
+// rows into dest with NestedScan, using keys as the key columns of its levels.
+func SelectNested(q Queryer, dest interface{}, keys []string, query string, args ...interface{}) error {
+	rows, err := q.Queryx(query, args...)
+	if err != nil {
+		return err
+	}
+	return NestedScan(rows, dest, keys...)
+}
+
+// nestedLevels returns the levels of the keys, with top as the first one, and
+// parents before their children.
+func nestedLevels(m *reflectx.Mapper, top *nestedLevel, columns []string, keys []string) ([]*nestedLevel, error) {
+	levels := make([]*nestedLevel, 0, len(keys))
+	for i, key := range keys {
+		level := top
+		if i > 0 {
+			dot := strings.LastIndex(key, ".")
+			if dot < 0 {
+				return nil, fmt.Errorf("key %s is not the column of a slice field", key)
+			}
+			level = &nestedLevel{path: key[:dot]}
+		}
+		level.key = -1
+		for j, column := range columns {
+			if column == key {
+				level.key = j
+			}
+		}
+		if level.key < 0 {
+			return nil, fmt.Errorf("missing key column %s", key)
+		}
+		levels = append(levels, level)
+	}
+	sort.SliceStable(levels[1:], func(i, j int) bool {
+		return strings.Count(levels[1+i].path, ".") < strings.Count(levels[1+j].path, ".")
+	})
+
+	for _, level := range levels[1:] {
+		level.parent = nestedParent(level.path, levels)
+		path := level.path
+		if level.parent != top {
+			path = strings.TrimPrefix(path, level.parent.path+".")
+		}
+		fi := m.TypeMap(level.parent.typ).GetByPath(path)
+		if fi == nil || fi.Field.Type.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("%s is not a slice field of %s", path, level.parent.typ)
+		}
+		elem := fi.Field.Type.Elem()
+		level.field = fi.Index
+		level.typ = reflectx.Deref(elem)
+		level.isPtr = elem.Kind() == reflect.Ptr
+		if level.typ.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s is not a slice of structs", path)
+		}
+		level.parent.children = append(level.parent.children, level)
+	}
+	return levels, nil
+}
+
+// nestedParent returns the level with the longest path which is a prefix of
+// path, which is the top level if there is no other.
+func nestedParent(path string, levels []*nestedLevel) *nestedLevel {
+	parent := levels[0]
+	for _, level := range levels[1:] {
+		if strings.HasPrefix(path, level.path+".") && len(level.path) > len(parent.path) {
+			parent = level
+		}
+	}
+	return parent
+}
+
+// nestedColumns sets the columns of level to the ones for which it is the
+// deepest level, with their traversals.
+func nestedColumns(m *reflectx.Mapper, level *nestedLevel, levels []*nestedLevel, columns []string, unsafe bool) error {
+	var names []string
+	var indexes []int
+	for i, column := range columns {
+		if nestedParent(column, levels) != level {
+			continue
+		}
+		name := column
+		if level.path != "" {
+			name = strings.TrimPrefix(column, level.path+".")
+		}
+		names = append(names, name)
+		indexes = append(indexes, i)
+	}
+
+	for i, traversal := range m.TraversalsByName(level.typ, names) {
+		if len(traversal) == 0 {
+			if unsafe && indexes[i] != level.key {
+				continue
+			}
+			return fmt.Errorf("missing destination name %s", columns[indexes[i]])
+		}
+		level.columns = append(level.columns, indexes[i])
+		level.traversals = append(level.traversals, traversal)
+	}
+	return nil
+}
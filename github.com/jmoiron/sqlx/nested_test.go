@@ -0,0 +1,168 @@
+package sqlx
+
+import (
+	"reflect"
+	"testing"
+)
+
+// nestedRows are rows of values, scanned into pointers to pointers like the
+// ones NestedScan passes to Scan.
+type nestedRows struct {
+	columns []string
+	rows    [][]interface{}
+	i       int
+	closed  bool
+}
+
+func (r *nestedRows) Close() error               { r.closed = true; return nil }
+func (r *nestedRows) Columns() ([]string, error) { return r.columns, nil }
+func (r *nestedRows) Err() error                 { return nil }
+
+func (r *nestedRows) Next() bool {
+	r.i++
+	return r.i <= len(r.rows)
+}
+
+func (r *nestedRows) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		v := reflect.ValueOf(d).Elem()
+		src := r.rows[r.i-1][i]
+		switch {
+		case src == nil:
+			v.Set(reflect.Zero(v.Type()))
+		case v.Kind() == reflect.Ptr:
+			p := reflect.New(v.Type().Elem())
+			p.Elem().Set(reflect.ValueOf(src).Convert(v.Type().Elem()))
+			v.Set(p)
+		default:
+			v.Set(reflect.ValueOf(src))
+		}
+	}
+	return nil
+}
+
+type NestedAddress struct {
+	City    string `db:"city"`
+	Country string `db:"country"`
+}
+
+type NestedItem struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+}
+
+type NestedOrder struct {
+	ID    int          `db:"id"`
+	Total int          `db:"total"`
+	Items []NestedItem `db:"items"`
+}
+
+type NestedCustomer struct {
+	ID      int            `db:"id"`
+	Name    string         `db:"name"`
+	Address *NestedAddress `db:"address"`
+	Orders  []*NestedOrder `db:"orders"`
+}
+
+func TestNestedScan(t *testing.T) {
+	rows := &nestedRows{
+		columns: []string{"id", "name", "address.city", "address.country", "orders.id", "orders.total", "orders.items.id", "orders.items.name"},
+		rows: [][]interface{}{
+			{1, "Jason", "Toronto", "Canada", 10, 30, 100, "tea"},
+			{1, "Jason", "Toronto", "Canada", 10, 30, 101, "cake"},
+			{2, "John", nil, nil, 20, 5, nil, nil},
+			{1, "Jason", "Toronto", "Canada", 11, 12, 102, "tea"},
+			{3, "Jane", nil, nil, nil, nil, nil, nil},
+			{2, "John", nil, nil, 21, 7, 103, "jam"},
+		},
+	}
+
+	var customers []NestedCustomer
+	err := NestedScan(rows, &customers, "id", "orders.id", "orders.items.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rows.closed {
+		t.Error("expected rows to be closed")
+	}
+
+	expected := []NestedCustomer{
+		{ID: 1, Name: "Jason", Address: &NestedAddress{City: "Toronto", Country: "Canada"}, Orders: []*NestedOrder{
+			{ID: 10, Total: 30, Items: []NestedItem{{100, "tea"}, {101, "cake"}}},
+			{ID: 11, Total: 12, Items: []NestedItem{{102, "tea"}}},
+		}},
+		{ID: 2, Name: "John", Orders: []*NestedOrder{
+			{ID: 20, Total: 5},
+			{ID: 21, Total: 7, Items: []NestedItem{{103, "jam"}}},
+		}},
+		{ID: 3, Name: "Jane"},
+	}
+	if !reflect.DeepEqual(customers, expected) {
+		t.Errorf("expected %#v, got %#v", expected, customers)
+	}
+}
+
+func TestNestedScanErrors(t *testing.T) {
+	columns := []string{"id", "name", "orders.id", "orders.total"}
+	tests := []struct {
+		name    string
+		columns []string
+		keys    []string
+	}{
+		{"no keys", columns, nil},
+		{"missing key column", columns, []string{"id", "orders.number"}},
+		{"key not in a slice field", columns, []string{"id", "name.id"}},
+		{"key without path", columns, []string{"id", "total"}},
+		{"missing destination", append(columns, "orders.discount"), []string{"id", "orders.id"}},
+	}
+	for _, test := range tests {
+		var customers []NestedCustomer
+		rows := &nestedRows{columns: test.columns}
+		if err := NestedScan(rows, &customers, test.keys...); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	var customers []NestedCustomer
+	rows := &nestedRows{columns: columns, rows: [][]interface{}{{nil, "Jason", nil, nil}}}
+	if err := NestedScan(rows, &customers, "id", "orders.id"); err == nil {
+		t.Error("expected an error for a NULL top level key")
+	}
+}
+
+type Employee struct {
+	ID      int    `db:"id"`
+	Name    string `db:"name"`
+	Reports []struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	} `db:"reports"`
+}
+
+func TestSelectNested(t *testing.T) {
+	RunWithSchema(defaultSchema, t, func(db *DB, t *testing.T) {
+		loadDefaultFixture(db, t)
+
+		var employees []Employee
+		err := SelectNested(db, &employees, []string{"id", "reports.id"}, `
+			SELECT b.id, b.name, e.id AS "reports.id", e.name AS "reports.name"
+			FROM employees b LEFT JOIN employees e ON e.boss_id = b.id
+			ORDER BY b.id, e.id`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(employees) != 3 {
+			t.Fatalf("expected 3 employees, got %d", len(employees))
+		}
+		peter := employees[2]
+		if peter.Name != "Peter" || len(peter.Reports) != 2 {
+			t.Fatalf("expected Peter with 2 reports, got %#v", peter)
+		}
+		if peter.Reports[0].Name != "Joe" || peter.Reports[1].Name != "Martin" {
+			t.Errorf("expected reports Joe and Martin, got %#v", peter.Reports)
+		}
+		if len(employees[0].Reports) != 0 {
+			t.Errorf("expected no reports for %s, got %#v", employees[0].Name, employees[0].Reports)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ContextJob is a Job that accepts a context and reports whether the run
+// failed. Job wrappers and Cron call RunContext instead of Run when a job
+// implements ContextJob.
+type ContextJob interface {
+	Job
+	RunContext(ctx context.Context) error
+}
+
+// ContextFuncJob is a wrapper that turns a func(context.Context) error into a
+// cron.ContextJob.
+type ContextFuncJob func(ctx context.Context) error
+
+// Run runs the func with a background context and discards the error.
+func (f ContextFuncJob) Run() { f(context.Background()) }
+
+// RunContext runs the func.
+func (f ContextFuncJob) RunContext(ctx context.Context) error { return f(ctx) }
+
+// runJob runs j with ctx if j is a ContextJob, or calls its Run method
+// otherwise.
+func runJob(ctx context.Context, j Job) error {
+	if cj, ok := j.(ContextJob); ok {
+		return cj.RunContext(ctx)
+	}
+	j.Run()
+	return nil
+}
+
+// PanicError is the error recorded for a job that panicked.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("cron: panic running job: %v", e.Value)
+}
+
+// recoverPanic converts a recovered panic value into a *PanicError.
+func recoverPanic(r interface{}) *PanicError {
+	const size = 64 << 10
+	buf := make([]byte, size)
+	buf = buf[:runtime.Stack(buf, false)]
+	return &PanicError{Value: r, Stack: buf}
+}
+
+// JobWrapper decorates the given Job with some behavior.
+type JobWrapper func(Job) Job
+
+// Chain is a sequence of JobWrappers that decorates submitted jobs with
+// cross-cutting behaviors like logging or synchronization.
+type Chain struct {
+	wrappers []JobWrapper
+}
+
+// NewChain returns a Chain consisting of the given JobWrappers.
+func NewChain(c ...JobWrapper) Chain {
+	return Chain{c}
+}
+
+// Then decorates the given job with all JobWrappers in the chain.
+//
+// This:
+//     NewChain(m1, m2, m3).Then(job)
+// is equivalent to:
+//     m1(m2(m3(job)))
+func (c Chain) Then(j Job) Job {
+	for i := range c.wrappers {
+		j = c.wrappers[len(c.wrappers)-i-1](j)
+	}
+	return j
+}
+
+// Recover recovers panics in wrapped jobs and passes them to handler as a
+// *PanicError. The panic is returned as the error of the run, so that
+// wrappers further out in the chain, like OnError and Retry, see it. If
+// handler is nil, panics are logged with the standard logger.
+func Recover(handler func(err *PanicError)) JobWrapper {
+	return func(j Job) Job {
+		return ContextFuncJob(func(ctx context.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					perr := recoverPanic(r)
+					if handler != nil {
+						handler(perr)
+					} else {
+						log.Printf("%v\n%s", perr, perr.Stack)
+					}
+					err = perr
+				}
+			}()
+			return runJob(ctx, j)
+		})
+	}
+}
+
+// OnError calls handler with the errors returned by wrapped jobs.
+func OnError(handler func(err error)) JobWrapper {
+	return func(j Job) Job {
+		return ContextFuncJob(func(ctx context.Context) error {
+			err := runJob(ctx, j)
+			if err != nil {
+				handler(err)
+			}
+			return err
+		})
+	}
+}
+
+// DelayIfStillRunning serializes jobs, delaying subsequent runs until the
+// previous one is complete. Delays of more than a minute are logged with the
+// standard logger.
+func DelayIfStillRunning() JobWrapper {
+	return func(j Job) Job {
+		var mu sync.Mutex
+		return ContextFuncJob(func(ctx context.Context) error {
+			start := time.Now()
+			mu.Lock()
+			defer mu.Unlock()
+			if dur := time.Since(start); dur > time.Minute {
+				log.Printf("cron: job delayed by %v", dur)
+			}
+			return runJob(ctx, j)
+		})
+	}
+}
+
+// SkipIfStillRunning skips an invocation of the Job if a previous invocation
+// is still running.
+func SkipIfStillRunning() JobWrapper {
+	return func(j Job) Job {
+		var ch = make(chan struct{}, 1)
+		ch <- struct{}{}
+		return ContextFuncJob(func(ctx context.Context) error {
+			select {
+			case v := <-ch:
+				defer func() { ch <- v }()
+				return runJob(ctx, j)
+			default:
+				return nil
+			}
+		})
+	}
+}
+
+// Timeout runs wrapped jobs with a context that is canceled after d. Jobs
+// that do not implement ContextJob run without a deadline.
+func Timeout(d time.Duration) JobWrapper {
+	return func(j Job) Job {
+		return ContextFuncJob(func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return runJob(ctx, j)
+		})
+	}
+}
+
+// Retry runs wrapped jobs again when they return an error, up to attempts
+// runs in total. The delay between runs starts at backoff and doubles after
+// each failed run. Retrying stops early when the context is done. The error
+// of the last run is returned.
+func Retry(attempts int, backoff time.Duration) JobWrapper {
+	return func(j Job) Job {
+		return ContextFuncJob(func(ctx context.Context) error {
+			delay := backoff
+			for i := 1; ; i++ {
+				err := runJob(ctx, j)
+				if err == nil || i >= attempts {
+					return err
+				}
+				t := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return err
+				case <-t.C:
+				}
+				delay *= 2
+			}
+		})
+	}
+}
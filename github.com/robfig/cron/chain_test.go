@@ -0,0 +1,205 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func appendingJob(slice *[]int, value int) Job {
+	var m sync.Mutex
+	return FuncJob(func() {
+		m.Lock()
+		*slice = append(*slice, value)
+		m.Unlock()
+	})
+}
+
+func appendingWrapper(slice *[]int, value int) JobWrapper {
+	return func(j Job) Job {
+		return FuncJob(func() {
+			appendingJob(slice, value).Run()
+			j.Run()
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	var nums []int
+	var (
+		append1 = appendingWrapper(&nums, 1)
+		append2 = appendingWrapper(&nums, 2)
+		append3 = appendingWrapper(&nums, 3)
+		append4 = appendingJob(&nums, 4)
+	)
+	NewChain(append1, append2, append3).Then(append4).Run()
+	if !reflect.DeepEqual(nums, []int{1, 2, 3, 4}) {
+		t.Error("unexpected order of calls:", nums)
+	}
+}
+
+func TestChainRecover(t *testing.T) {
+	panickingJob := FuncJob(func() {
+		panic("panickingJob panics")
+	})
+
+	t.Run("panic exits job by default", func(t *testing.T) {
+		defer func() {
+			if err := recover(); err == nil {
+				t.Errorf("panic expected, but none received")
+			}
+		}()
+		NewChain().Then(panickingJob).Run()
+	})
+
+	t.Run("Recovering JobWrapper recovers", func(t *testing.T) {
+		var got *PanicError
+		err := runJob(context.Background(), NewChain(Recover(func(err *PanicError) { got = err })).Then(panickingJob))
+		if got == nil || got.Value != "panickingJob panics" || len(got.Stack) == 0 {
+			t.Errorf("handler received %v, want the panic", got)
+		}
+		if err != got {
+			t.Errorf("run returned %v, want the panic error", err)
+		}
+	})
+}
+
+func TestChainOnErrorAndRetry(t *testing.T) {
+	var calls int
+	failTwice := ContextFuncJob(func(ctx context.Context) error {
+		calls++
+		if calls <= 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	var reported []error
+	job := NewChain(Retry(3, time.Millisecond), OnError(func(err error) { reported = append(reported, err) })).Then(failTwice)
+	if err := runJob(context.Background(), job); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if calls != 3 || len(reported) != 2 {
+		t.Errorf("calls=%d reported=%v, want 3 calls and 2 errors", calls, reported)
+	}
+
+	calls = 0
+	job = NewChain(Retry(2, time.Millisecond)).Then(failTwice)
+	if err := runJob(context.Background(), job); err == nil || calls != 2 {
+		t.Errorf("err=%v calls=%d, want error after 2 calls", err, calls)
+	}
+}
+
+func TestChainTimeout(t *testing.T) {
+	job := NewChain(Timeout(10 * time.Millisecond)).Then(ContextFuncJob(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	start := time.Now()
+	if err := runJob(context.Background(), job); err != context.DeadlineExceeded {
+		t.Errorf("run returned %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("run took %v, want about 10ms", d)
+	}
+}
+
+type countJob struct {
+	m       sync.Mutex
+	started int
+	done    int
+	delay   time.Duration
+}
+
+func (j *countJob) Run() {
+	j.m.Lock()
+	j.started++
+	j.m.Unlock()
+	time.Sleep(j.delay)
+	j.m.Lock()
+	j.done++
+	j.m.Unlock()
+}
+
+func (j *countJob) Started() int {
+	defer j.m.Unlock()
+	j.m.Lock()
+	return j.started
+}
+
+func (j *countJob) Done() int {
+	defer j.m.Unlock()
+	j.m.Lock()
+	return j.done
+}
+
+func TestChainDelayIfStillRunning(t *testing.T) {
+	var j countJob
+	j.delay = 10 * time.Millisecond
+	wrappedJob := NewChain(DelayIfStillRunning()).Then(&j)
+	go wrappedJob.Run()
+	time.Sleep(2 * time.Millisecond) // Give the job 2ms to start.
+	go wrappedJob.Run()
+	<-time.After(15 * time.Millisecond)
+	started, done := j.Started(), j.Done()
+	if started != 2 || done != 1 {
+		t.Errorf("expected second job to be delayed, got started=%d done=%d", started, done)
+	}
+	<-time.After(15 * time.Millisecond)
+	if done := j.Done(); done != 2 {
+		t.Errorf("expected both jobs done, got %d", done)
+	}
+}
+
+func TestChainSkipIfStillRunning(t *testing.T) {
+	var j countJob
+	j.delay = 10 * time.Millisecond
+	wrappedJob := NewChain(SkipIfStillRunning()).Then(&j)
+	go wrappedJob.Run()
+	time.Sleep(2 * time.Millisecond) // Give the job 2ms to start.
+	go wrappedJob.Run()
+	<-time.After(15 * time.Millisecond)
+	started, done := j.Started(), j.Done()
+	if started != 1 || done != 1 {
+		t.Errorf("expected second job to be skipped, got started=%d done=%d", started, done)
+	}
+
+	// The job runs again once the first run is done.
+	wrappedJob.Run()
+	if started := j.Started(); started != 2 {
+		t.Errorf("expected job to run after the first run completed, got started=%d", started)
+	}
+}
+
+// Run a failing job and check the last run status in the entries.
+func TestEntryLastRun(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	cron := New()
+	cron.Chain = NewChain(Recover(func(*PanicError) {}))
+	id := cron.Schedule(Every(time.Second), ContextFuncJob(func(ctx context.Context) error {
+		defer wg.Done()
+		return errors.New("failed")
+	}))
+	cron.Start()
+	defer cron.Stop()
+
+	select {
+	case <-time.After(2 * OneSecond):
+		t.Fatal("expected job runs")
+	case <-wait(wg):
+	}
+	// The status is recorded after the job returns.
+	time.Sleep(10 * time.Millisecond)
+
+	e := cron.Entry(id)
+	if e.LastRun.Start.IsZero() || e.LastRun.Err == nil || e.LastRun.Err.Error() != "failed" {
+		t.Errorf("unexpected last run %+v", e.LastRun)
+	}
+	if e.Running != 0 {
+		t.Errorf("expected no run in progress, got %d", e.Running)
+	}
+}
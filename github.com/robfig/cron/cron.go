@@ -1,9 +1,10 @@
 package cron
 
 import (
+	"context"
 	"log"
-	"runtime"
 	"sort"
+	"sync"
 	"time"
 )
 
@@ -14,10 +15,36 @@ type Cron struct {
 	entries  []*Entry
 	stop     chan struct{}
 	add      chan *Entry
+	remove   chan EntryID
 	snapshot chan []*Entry
 	running  bool
 	ErrorLog *log.Logger
 	location *time.Location
+	nextID   EntryID
+	idMu     sync.Mutex
+
+	// Parser parses the specs given to AddFunc and AddJob. If nil, Parse is
+	// used.
+	Parser ScheduleParser
+
+	// Chain decorates the jobs added to the Cron after it is set.
+	Chain Chain
+
+	// Locker, if set, is consulted before each run of a named entry so that
+	// Cron instances sharing the Locker run each scheduled run of the entry
+	// once.
+	Locker Locker
+
+	// CatchUp determines whether the runs of named entries missed while no
+	// instance was running are made up when the Cron starts. It requires a
+	// Locker.
+	CatchUp CatchUpPolicy
+}
+
+// ScheduleParser is an interface for schedule spec parsers that return a
+// Schedule. Parser implements ScheduleParser.
+type ScheduleParser interface {
+	Parse(spec string) (Schedule, error)
 }
 
 // Job is an interface for submitted cron jobs.
@@ -32,8 +59,19 @@ type Schedule interface {
 	Next(time.Time) time.Time
 }
 
+// EntryID identifies an entry within a Cron instance.
+type EntryID int
+
 // Entry consists of a schedule and the func to execute on that schedule.
 type Entry struct {
+	// ID is the cron-assigned ID of this entry, which may be used to look up a
+	// snapshot or remove it.
+	ID EntryID
+
+	// Name identifies the entry across Cron instances sharing a Locker. It
+	// is empty for entries added without a name.
+	Name string
+
 	// The schedule on which this job should be run.
 	Schedule Schedule
 
@@ -47,6 +85,40 @@ type Entry struct {
 
 	// The Job to run.
 	Job Job
+
+	// LastRun is the status of the last completed run of the job. It is the
+	// zero value if no run has completed.
+	LastRun RunStatus
+
+	// Running is the number of runs of the job in progress.
+	Running int
+
+	status *entryStatus
+
+	// caughtUp is closed once the missed runs of the entry are made up. It is
+	// nil if the entry has no runs to make up.
+	caughtUp chan struct{}
+}
+
+// RunStatus describes a completed run of a job.
+type RunStatus struct {
+	// Start is the time the run started.
+	Start time.Time
+
+	// Duration is how long the run took.
+	Duration time.Duration
+
+	// Err is the error returned by a ContextJob, or a *PanicError if the job
+	// panicked.
+	Err error
+}
+
+// entryStatus tracks the runs of an entry. It is shared by the goroutines
+// running the job.
+type entryStatus struct {
+	mu      sync.Mutex
+	last    RunStatus
+	running int
 }
 
 // byTime is a wrapper for sorting the entry array by time
@@ -78,6 +150,7 @@ func NewWithLocation(location *time.Location) *Cron {
 	return &Cron{
 		entries:  nil,
 		add:      make(chan *Entry),
+		remove:   make(chan EntryID),
 		stop:     make(chan struct{}),
 		snapshot: make(chan []*Entry),
 		running:  false,
@@ -92,32 +165,80 @@ type FuncJob func()
 func (f FuncJob) Run() { f() }
 
 // AddFunc adds a func to the Cron to be run on the given schedule.
-func (c *Cron) AddFunc(spec string, cmd func()) error {
+// The spec is parsed using the time zone of this Cron instance as the default.
+// An opaque ID is returned that can be used to later remove it.
+func (c *Cron) AddFunc(spec string, cmd func()) (EntryID, error) {
 	return c.AddJob(spec, FuncJob(cmd))
 }
 
 // AddJob adds a Job to the Cron to be run on the given schedule.
-func (c *Cron) AddJob(spec string, cmd Job) error {
-	schedule, err := Parse(spec)
-	if err != nil {
-		return err
-	}
-	c.Schedule(schedule, cmd)
-	return nil
+// The spec is parsed using the time zone of this Cron instance as the default.
+// An opaque ID is returned that can be used to later remove it.
+func (c *Cron) AddJob(spec string, cmd Job) (EntryID, error) {
+	return c.AddNamedJob("", spec, cmd)
 }
 
 // Schedule adds a Job to the Cron to be run on the given schedule.
-func (c *Cron) Schedule(schedule Schedule, cmd Job) {
+// The job is wrapped with the configured Chain.
+// An opaque ID is returned that can be used to later remove it.
+func (c *Cron) Schedule(schedule Schedule, cmd Job) EntryID {
+	return c.schedule("", schedule, cmd)
+}
+
+func (c *Cron) schedule(name string, schedule Schedule, cmd Job) EntryID {
+	c.idMu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.idMu.Unlock()
 	entry := &Entry{
+		ID:       id,
+		Name:     name,
 		Schedule: schedule,
-		Job:      cmd,
+		Job:      c.Chain.Then(cmd),
+		status:   &entryStatus{},
 	}
 	if !c.running {
 		c.entries = append(c.entries, entry)
-		return
+	} else {
+		c.add <- entry
+	}
+	return entry.ID
+}
+
+// AddNamedFunc adds a named func to the Cron to be run on the given schedule.
+// When the Cron has a Locker, each scheduled run of the func is executed by
+// a single one of the Cron instances sharing the Locker.
+func (c *Cron) AddNamedFunc(name, spec string, cmd func()) (EntryID, error) {
+	return c.AddNamedJob(name, spec, FuncJob(cmd))
+}
+
+// AddNamedJob adds a named Job to the Cron to be run on the given schedule.
+// See AddNamedFunc.
+func (c *Cron) AddNamedJob(name, spec string, cmd Job) (EntryID, error) {
+	parse := Parse
+	if c.Parser != nil {
+		parse = c.Parser.Parse
+	}
+	schedule, err := parse(spec)
+	if err != nil {
+		return 0, err
 	}
+	return c.ScheduleNamed(name, schedule, cmd), nil
+}
 
-	c.add <- entry
+// ScheduleNamed adds a named Job to the Cron to be run on the given schedule.
+// See AddNamedFunc.
+func (c *Cron) ScheduleNamed(name string, schedule Schedule, cmd Job) EntryID {
+	return c.schedule(name, schedule, cmd)
+}
+
+// Remove an entry from being run in the future.
+func (c *Cron) Remove(id EntryID) {
+	if c.running {
+		c.remove <- id
+	} else {
+		c.removeEntry(id)
+	}
 }
 
 // Entries returns a snapshot of the cron entries.
@@ -130,6 +251,16 @@ func (c *Cron) Entries() []*Entry {
 	return c.entrySnapshot()
 }
 
+// Entry returns a snapshot of the given entry, or nil if it couldn't be found.
+func (c *Cron) Entry(id EntryID) *Entry {
+	for _, entry := range c.Entries() {
+		if id == entry.ID {
+			return entry
+		}
+	}
+	return nil
+}
+
 // Location gets the time zone location
 func (c *Cron) Location() *time.Location {
 	return c.location
@@ -153,16 +284,40 @@ func (c *Cron) Run() {
 	c.run()
 }
 
-func (c *Cron) runWithRecovery(j Job) {
+func (c *Cron) runWithRecovery(e *Entry, scheduled time.Time) {
+	ctx := context.WithValue(context.Background(), scheduledKey{}, scheduled)
+	if !c.acquire(ctx, e, scheduled) {
+		return
+	}
+
+	s := e.status
+	s.mu.Lock()
+	s.running++
+	s.mu.Unlock()
+
+	start := time.Now()
+	var err error
 	defer func() {
 		if r := recover(); r != nil {
-			const size = 64 << 10
-			buf := make([]byte, size)
-			buf = buf[:runtime.Stack(buf, false)]
-			c.logf("cron: panic running job: %v\n%s", r, buf)
+			perr := recoverPanic(r)
+			c.logf("%v\n%s", perr, perr.Stack)
+			err = perr
 		}
+		s.mu.Lock()
+		s.running--
+		s.last = RunStatus{Start: start, Duration: time.Since(start), Err: err}
+		s.mu.Unlock()
 	}()
-	j.Run()
+	err = runJob(ctx, e.Job)
+}
+
+// runScheduled runs the entry at a scheduled time once its missed runs are
+// made up, so that the Locker does not reject them as older than this run.
+func (c *Cron) runScheduled(e *Entry, scheduled time.Time, caughtUp <-chan struct{}) {
+	if caughtUp != nil {
+		<-caughtUp
+	}
+	c.runWithRecovery(e, scheduled)
 }
 
 // Run the scheduler. this is private just due to the need to synchronize
@@ -171,7 +326,11 @@ func (c *Cron) run() {
 	// Figure out the next activation times for each entry.
 	now := c.now()
 	for _, entry := range c.entries {
-		entry.Next = entry.Schedule.Next(now)
+		entry.Next = c.next(entry, now)
+		if c.Locker != nil && c.CatchUp != CatchUpNone && entry.Name != "" {
+			entry.caughtUp = make(chan struct{})
+			go c.catchUp(entry, now)
+		}
 	}
 
 	for {
@@ -196,21 +355,26 @@ func (c *Cron) run() {
 					if e.Next.After(now) || e.Next.IsZero() {
 						break
 					}
-					go c.runWithRecovery(e.Job)
+					go c.runScheduled(e, e.Next, e.caughtUp)
 					e.Prev = e.Next
-					e.Next = e.Schedule.Next(now)
+					e.Next = c.next(e, now)
 				}
 
 			case newEntry := <-c.add:
 				timer.Stop()
 				now = c.now()
-				newEntry.Next = newEntry.Schedule.Next(now)
+				newEntry.Next = c.next(newEntry, now)
 				c.entries = append(c.entries, newEntry)
 
 			case <-c.snapshot:
 				c.snapshot <- c.entrySnapshot()
 				continue
 
+			case id := <-c.remove:
+				timer.Stop()
+				now = c.now()
+				c.removeEntry(id)
+
 			case <-c.stop:
 				timer.Stop()
 				return
@@ -243,16 +407,34 @@ func (c *Cron) Stop() {
 func (c *Cron) entrySnapshot() []*Entry {
 	entries := []*Entry{}
 	for _, e := range c.entries {
+		e.status.mu.Lock()
 		entries = append(entries, &Entry{
+			ID:       e.ID,
+			Name:     e.Name,
 			Schedule: e.Schedule,
 			Next:     e.Next,
 			Prev:     e.Prev,
 			Job:      e.Job,
+			LastRun:  e.status.last,
+			Running:  e.status.running,
+			status:   e.status,
 		})
+		e.status.mu.Unlock()
 	}
 	return entries
 }
 
+// removeEntry removes the entry with the given ID.
+func (c *Cron) removeEntry(id EntryID) {
+	var entries []*Entry
+	for _, e := range c.entries {
+		if e.ID != id {
+			entries = append(entries, e)
+		}
+	}
+	c.entries = entries
+}
+
 // now returns current time in c location
 func (c *Cron) now() time.Time {
 	return time.Now().In(c.location)
@@ -109,6 +109,31 @@ func TestAddWhileRunning(t *testing.T) {
 	}
 }
 
+func TestConcurrentAddWhileRunning(t *testing.T) {
+	cron := New()
+	cron.Start()
+	defer cron.Stop()
+
+	var mu sync.Mutex
+	ids := map[EntryID]bool{}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, _ := cron.AddFunc("@every 1h", func() {})
+			mu.Lock()
+			ids[id] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(ids) != 10 {
+		t.Errorf("expected 10 distinct entry ids, got %d", len(ids))
+	}
+}
+
 // Test for #34. Adding a job after calling start results in multiple job invocations
 func TestAddWhileRunningWithDelay(t *testing.T) {
 	cron := New()
@@ -282,7 +307,7 @@ func (t testJob) Run() {
 // Test that adding an invalid job spec returns an error
 func TestInvalidJobSpec(t *testing.T) {
 	cron := New()
-	err := cron.AddJob("this will not parse", nil)
+	_, err := cron.AddJob("this will not parse", nil)
 	if err == nil {
 		t.Errorf("expected an error with invalid spec, got nil")
 	}
@@ -397,6 +422,63 @@ func TestJobWithZeroTimeDoesNotRun(t *testing.T) {
 	}
 }
 
+// Add two entries, remove one while running, expect only the other runs.
+func TestRemoveWhileRunning(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	cron := New()
+	cron.Start()
+	defer cron.Stop()
+	id, _ := cron.AddFunc("* * * * * ?", func() { t.Error("expected removed job will not run") })
+	cron.AddFunc("* * * * * ?", func() { wg.Done() })
+	cron.Remove(id)
+
+	select {
+	case <-time.After(OneSecond):
+		t.Fatal("expected job runs")
+	case <-wait(wg):
+	}
+}
+
+// Remove an entry before running and check the entry IDs.
+func TestRemoveBeforeRunning(t *testing.T) {
+	cron := New()
+	id1, _ := cron.AddFunc("@every 1h", func() {})
+	id2 := cron.Schedule(Every(time.Hour), FuncJob(func() {}))
+	if id1 == id2 {
+		t.Fatalf("expected distinct entry IDs, got %d twice", id1)
+	}
+	cron.Remove(id1)
+	cron.Start()
+	defer cron.Stop()
+
+	entries := cron.Entries()
+	if len(entries) != 1 || entries[0].ID != id2 {
+		t.Fatalf("expected entry %d to remain, got %v", id2, entries)
+	}
+	if e := cron.Entry(id2); e == nil || e.Next.IsZero() {
+		t.Errorf("expected scheduled entry %d, got %v", id2, e)
+	}
+	if e := cron.Entry(id1); e != nil {
+		t.Errorf("expected removed entry %d to be missing, got %v", id1, e)
+	}
+}
+
+// Use a custom parser and a time zone prefix in a spec.
+func TestCustomParser(t *testing.T) {
+	cron := New()
+	cron.Parser = NewParser(SecondOptional | Minute | Hour | Dom | Month | Dow | YearOptional | Descriptor)
+	for _, spec := range []string{"0 0 * * *", "0 0 0 * * *", "0 0 0 * * * 2099", "CRON_TZ=Asia/Tokyo 30 4 * * *"} {
+		if _, err := cron.AddFunc(spec, func() {}); err != nil {
+			t.Errorf("%s: unexpected error %v", spec, err)
+		}
+	}
+	if _, err := cron.AddFunc("0 0 0 0 * * * *", func() {}); err == nil {
+		t.Error("expected an error for 8 fields")
+	}
+}
+
 func wait(wg *sync.WaitGroup) chan bool {
 	ch := make(chan bool)
 	go func() {
@@ -0,0 +1,123 @@
+package cron
+
+import (
+	"context"
+	"time"
+)
+
+// Locker coordinates the runs of named entries shared by several Cron
+// instances, for example one per replica of a service, so that each scheduled
+// run of an entry is executed by a single instance.
+//
+// A Locker records the scheduled time of the last run of each name. The
+// subpackages redislock, etcdlock and filelock provide Lockers backed by
+// Redis, etcd and local files.
+type Locker interface {
+	// Lock records t as the last run of name if t is after the recorded
+	// last run, and reports whether it did. The check and the update must be
+	// atomic across all the instances sharing the Locker.
+	Lock(ctx context.Context, name string, t time.Time) (bool, error)
+
+	// LastRun returns the recorded last run of name, or the zero time if
+	// name has never run.
+	LastRun(ctx context.Context, name string) (time.Time, error)
+}
+
+// CatchUpPolicy determines what happens to the runs of named entries that
+// were missed while no Cron instance was running.
+type CatchUpPolicy int
+
+const (
+	// CatchUpNone skips missed runs.
+	CatchUpNone CatchUpPolicy = iota
+
+	// CatchUpOnce runs the most recent missed run when the Cron starts.
+	CatchUpOnce
+
+	// CatchUpAll runs every missed run, oldest first, when the Cron starts.
+	// At most maxCatchUp runs are made up for each entry.
+	CatchUpAll
+)
+
+// maxCatchUp is the maximum number of missed runs made up for an entry.
+const maxCatchUp = 1000
+
+type scheduledKey struct{}
+
+// ScheduledTime returns the time the run of a job was scheduled for. Cron
+// passes the scheduled time in the context given to ContextJob.RunContext.
+// The scheduled time differs from the current time for runs that are made
+// up under a CatchUpPolicy.
+func ScheduledTime(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(scheduledKey{}).(time.Time)
+	return t, ok
+}
+
+// next returns the next run of the entry after t. The runs of named entries
+// with a constant delay are aligned on the Unix epoch when the Cron has a
+// Locker, as the instances sharing it start at different times and must
+// schedule the same runs.
+func (c *Cron) next(e *Entry, t time.Time) time.Time {
+	if s, ok := e.Schedule.(ConstantDelaySchedule); ok && c.Locker != nil && e.Name != "" {
+		delay := int64(s.Delay)
+		return time.Unix(0, (t.UnixNano()/delay+1)*delay).In(t.Location())
+	}
+	return e.Schedule.Next(t)
+}
+
+// acquire reports whether this Cron should run the entry at the scheduled
+// time. The run is claimed before the job wrappers of the Chain run, so a
+// wrapper skipping it, like SkipIfStillRunning, skips it on every instance.
+func (c *Cron) acquire(ctx context.Context, e *Entry, scheduled time.Time) bool {
+	if c.Locker == nil || e.Name == "" {
+		return true
+	}
+	ok, err := c.Locker.Lock(ctx, e.Name, scheduled)
+	if err != nil {
+		c.logf("cron: lock %s at %v: %v", e.Name, scheduled, err)
+		return false
+	}
+	return ok
+}
+
+// catchUp runs the missed runs of the entry between its recorded last run
+// and now according to the CatchUpPolicy. The scheduled runs of the entry
+// wait until catchUp returns.
+func (c *Cron) catchUp(e *Entry, now time.Time) {
+	defer close(e.caughtUp)
+
+	last, err := c.Locker.LastRun(context.Background(), e.Name)
+	if err != nil {
+		c.logf("cron: last run of %s: %v", e.Name, err)
+		return
+	}
+	if last.IsZero() {
+		return
+	}
+
+	var missed []time.Time
+	for t := c.next(e, last); !t.IsZero() && !t.After(now); {
+		if len(missed) == maxCatchUp {
+			// Skip ahead rather than walk the schedule from a very old last
+			// run, assuming the runs before now are spaced like the ones
+			// collected so far.
+			if span := t.Sub(missed[0]); now.Sub(t) > 2*span {
+				missed = missed[:0]
+				t = c.next(e, now.Add(-2*span))
+				continue
+			}
+			missed = missed[1:]
+		}
+		missed = append(missed, t)
+		t = c.next(e, t)
+	}
+	if len(missed) == 0 {
+		return
+	}
+	if c.CatchUp == CatchUpOnce {
+		missed = missed[len(missed)-1:]
+	}
+	for _, t := range missed {
+		c.runWithRecovery(e, t)
+	}
+}
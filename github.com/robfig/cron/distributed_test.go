@@ -0,0 +1,226 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memLocker is a Locker shared by the Cron instances of a test.
+type memLocker struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func newMemLocker() *memLocker {
+	return &memLocker{last: make(map[string]time.Time)}
+}
+
+func (l *memLocker) Lock(ctx context.Context, name string, t time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if last, ok := l.last[name]; ok && !last.Before(t) {
+		return false, nil
+	}
+	l.last[name] = t
+	return true, nil
+}
+
+func (l *memLocker) LastRun(ctx context.Context, name string) (time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last[name], nil
+}
+
+// Run a named job on several Cron instances sharing a Locker, expect each
+// scheduled run to execute once.
+func TestNamedJobRunsOnce(t *testing.T) {
+	locker := newMemLocker()
+	var mu sync.Mutex
+	runs := make(map[time.Time]int)
+	job := ContextFuncJob(func(ctx context.Context) error {
+		scheduled, _ := ScheduledTime(ctx)
+		mu.Lock()
+		runs[scheduled]++
+		mu.Unlock()
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		cron := New()
+		cron.Locker = locker
+		cron.ScheduleNamed("job", Every(time.Second), job)
+		// Unnamed entries are not coordinated.
+		cron.Schedule(Every(time.Hour), FuncJob(func() {}))
+		cron.Start()
+		defer cron.Stop()
+	}
+
+	<-time.After(2*OneSecond + 100*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) < 2 {
+		t.Fatalf("expected at least 2 scheduled runs, got %v", runs)
+	}
+	for scheduled, n := range runs {
+		if n != 1 {
+			t.Errorf("run scheduled at %v executed %d times, expected once", scheduled, n)
+		}
+	}
+}
+
+// Start a Cron after missed runs, expect them to be made up according to the
+// catch-up policy.
+func TestCatchUp(t *testing.T) {
+	tests := []struct {
+		policy CatchUpPolicy
+		runs   int
+	}{
+		{CatchUpNone, 0},
+		{CatchUpOnce, 1},
+		{CatchUpAll, 3},
+	}
+	for _, test := range tests {
+		locker := newMemLocker()
+		// the runs of named delay schedules are aligned on the hour
+		locker.last["job"] = time.Now().Truncate(time.Hour).Add(-3 * time.Hour)
+
+		var mu sync.Mutex
+		var scheduled []time.Time
+		cron := New()
+		cron.Locker = locker
+		cron.CatchUp = test.policy
+		cron.ScheduleNamed("job", Every(time.Hour), ContextFuncJob(func(ctx context.Context) error {
+			t, _ := ScheduledTime(ctx)
+			mu.Lock()
+			scheduled = append(scheduled, t)
+			mu.Unlock()
+			return nil
+		}))
+		cron.Start()
+		<-time.After(100 * time.Millisecond)
+		cron.Stop()
+
+		mu.Lock()
+		if len(scheduled) != test.runs {
+			t.Errorf("policy %d: expected %d runs, got %v", test.policy, test.runs, scheduled)
+		}
+		for i := 1; i < len(scheduled); i++ {
+			if !scheduled[i-1].Before(scheduled[i]) {
+				t.Errorf("policy %d: runs out of order: %v", test.policy, scheduled)
+			}
+		}
+		mu.Unlock()
+	}
+}
+
+// slowLocker delays LastRun, so that the first scheduled run of an entry is
+// due before its missed runs are known.
+type slowLocker struct {
+	*memLocker
+	delay time.Duration
+}
+
+func (l slowLocker) LastRun(ctx context.Context, name string) (time.Time, error) {
+	time.Sleep(l.delay)
+	return l.memLocker.LastRun(ctx, name)
+}
+
+// Start a Cron whose catch-up outlasts the first scheduled run, expect the
+// missed runs to be made up before the scheduled runs.
+func TestCatchUpBeforeScheduledRuns(t *testing.T) {
+	locker := newMemLocker()
+	locker.last["job"] = time.Now().Add(-3*time.Second - 500*time.Millisecond)
+
+	var mu sync.Mutex
+	var scheduled []time.Time
+	cron := New()
+	cron.Locker = slowLocker{locker, 1500 * time.Millisecond}
+	cron.CatchUp = CatchUpAll
+	cron.ScheduleNamed("job", Every(time.Second), ContextFuncJob(func(ctx context.Context) error {
+		t, _ := ScheduledTime(ctx)
+		mu.Lock()
+		scheduled = append(scheduled, t)
+		mu.Unlock()
+		return nil
+	}))
+	cron.Start()
+	<-time.After(2*OneSecond + 100*time.Millisecond)
+	cron.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(scheduled) < 4 {
+		t.Fatalf("expected 3 missed runs and at least 1 scheduled run, got %v", scheduled)
+	}
+	for i := 1; i < len(scheduled); i++ {
+		if !scheduled[i-1].Before(scheduled[i]) {
+			t.Errorf("runs out of order: %v", scheduled)
+		}
+	}
+}
+
+// Start a Cron long after the last run of a frequent entry, expect the most
+// recent maxCatchUp runs to be made up without walking the whole schedule.
+func TestCatchUpLimit(t *testing.T) {
+	locker := newMemLocker()
+	locker.last["job"] = time.Now().AddDate(-10, 0, 0)
+
+	var mu sync.Mutex
+	var scheduled []time.Time
+	cron := New()
+	cron.Locker = locker
+	cron.CatchUp = CatchUpAll
+	cron.ScheduleNamed("job", Every(time.Second), ContextFuncJob(func(ctx context.Context) error {
+		t, _ := ScheduledTime(ctx)
+		mu.Lock()
+		scheduled = append(scheduled, t)
+		mu.Unlock()
+		return nil
+	}))
+	start := time.Now()
+	cron.Start()
+	<-time.After(500 * time.Millisecond)
+	cron.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	var missed []time.Time
+	for _, t := range scheduled {
+		if t.Before(start) {
+			missed = append(missed, t)
+		}
+	}
+	if len(missed) != maxCatchUp {
+		t.Fatalf("expected %d missed runs, got %d", maxCatchUp, len(missed))
+	}
+	if last := missed[len(missed)-1]; start.Sub(last) > time.Second {
+		t.Errorf("expected the most recent missed run, got %v", last)
+	}
+}
+
+// Compute the next run of a named entry with a constant delay from different
+// start times, expect the runs to be aligned on the Unix epoch.
+func TestNamedDelayAligned(t *testing.T) {
+	cron := New()
+	cron.Locker = newMemLocker()
+	named := &Entry{Name: "job", Schedule: Every(time.Minute)}
+	unnamed := &Entry{Schedule: Every(time.Minute)}
+
+	expected := time.Date(2019, 4, 17, 2, 1, 0, 0, time.UTC)
+	for _, start := range []time.Time{
+		time.Date(2019, 4, 17, 2, 0, 0, 0, time.UTC),
+		time.Date(2019, 4, 17, 2, 0, 30, 5, time.UTC),
+		time.Date(2019, 4, 17, 2, 0, 59, 0, time.UTC),
+	} {
+		if next := cron.next(named, start); !next.Equal(expected) {
+			t.Errorf("next run of named entry from %v: expected %v, got %v", start, expected, next)
+		}
+	}
+
+	start := time.Date(2019, 4, 17, 2, 0, 30, 0, time.UTC)
+	if next := cron.next(unnamed, start); !next.Equal(start.Add(time.Minute)) {
+		t.Errorf("expected unnamed entries to run a delay after the start, got %v", next)
+	}
+}
@@ -15,11 +15,14 @@ them in their own goroutines.
 	// Funcs are invoked in their own goroutine, asynchronously.
 	...
 	// Funcs may also be added to a running Cron
-	c.AddFunc("@daily", func() { fmt.Println("Every day") })
+	id, _ := c.AddFunc("@daily", func() { fmt.Println("Every day") })
 	..
 	// Inspect the cron job entries' next and previous run times.
 	inspect(c.Entries())
 	..
+	// Entries may be removed from a running Cron
+	c.Remove(id)
+	..
 	c.Stop()  // Stop the scheduler (does not stop any jobs already running).
 
 CRON Expression Format
@@ -35,6 +38,13 @@ A cron expression represents a set of times, using 6 space-separated fields.
 	Month        | Yes        | 1-12 or JAN-DEC | * / , -
 	Day of week  | Yes        | 0-6 or SUN-SAT  | * / , - ?
 
+Parsers created with NewParser can make the seconds field and a trailing year
+field (1970-2099) optional, so that one parser accepts 5-field standard specs,
+6-field specs with seconds and 7-field specs with seconds and year:
+
+	c.Parser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour |
+		cron.Dom | cron.Month | cron.Dow | cron.YearOptional | cron.Descriptor)
+
 Note: Month and Day-of-week field values are case insensitive.  "SUN", "Sun",
 and "sun" are equally accepted.
 
@@ -102,11 +112,73 @@ it will have only 2 minutes of idle time between each run.
 Time zones
 
 All interpretation and scheduling is done in the machine's local time zone (as
-provided by the Go time package (http://www.golang.org/pkg/time).
+provided by the Go time package (http://www.golang.org/pkg/time), or in the
+time zone given to NewWithLocation.
+
+Individual schedules may override the time zone by prefixing the spec with
+"CRON_TZ=" and an IANA time zone name:
+
+	# Runs at 6am in Asia/Tokyo
+	c.AddFunc("CRON_TZ=Asia/Tokyo 0 0 6 * * ?", ...)
+
+The legacy "TZ=" prefix is also accepted.
 
 Be aware that jobs scheduled during daylight-savings leap-ahead transitions will
 not be run!
 
+Job Wrappers
+
+A Cron runner may be configured with a chain of job wrappers to add
+cross-cutting functionality to all submitted jobs. For example, they may be
+used to achieve the following effects:
+
+  - Recover any panics from jobs
+  - Delay a job's execution if the previous run hasn't completed yet
+  - Skip a job's execution if the previous run hasn't completed yet
+  - Cancel a job's context after a timeout
+  - Retry failed jobs with exponential backoff
+  - Report errors and panics
+
+Jobs implementing ContextJob receive the context of the run and report errors:
+
+	c := cron.New()
+	c.Chain = cron.NewChain(
+		cron.SkipIfStillRunning(),
+		cron.OnError(func(err error) { log.Println("report failed:", err) }),
+		cron.Recover(nil),
+		cron.Timeout(time.Hour),
+	)
+	c.Schedule(schedule, cron.ContextFuncJob(func(ctx context.Context) error {
+		return generateReport(ctx)
+	}))
+
+The outcome of the last completed run of each entry is available in the
+LastRun field of the entries returned by Entries.
+
+Distributed execution
+
+When the same schedule runs in several processes, for example on every
+replica of a service, named entries may be coordinated with a Locker so that
+each scheduled run executes in a single process:
+
+	c := cron.New()
+	c.Locker = &redislock.Locker{Pool: pool}
+	c.CatchUp = cron.CatchUpOnce
+	c.AddNamedFunc("nightly-report", "0 0 2 * * *", report)
+
+The redislock, etcdlock and filelock packages provide Lockers backed by Redis,
+etcd and local files. The CatchUp policy makes up the runs missed while no
+process was running when the Cron starts. ScheduledTime returns the time a run
+was scheduled for from the context passed to a ContextJob.
+
+The runs of named entries with a constant delay, like "@every 10m", are
+aligned on the Unix epoch rather than on the start of each process, so that
+all processes schedule the same runs.
+
+A process claims a run from the Locker before the job wrappers of its Chain
+run. A run skipped by SkipIfStillRunning in the process which claimed it is
+therefore skipped everywhere; use DelayIfStillRunning to keep every run.
+
 Thread safety
 
 Since the Cron service runs concurrently with the calling code, some amount of
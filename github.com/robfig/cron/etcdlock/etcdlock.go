@@ -0,0 +1,110 @@
+// Package etcdlock implements a cron.Locker backed by etcd.
+//
+//	c := cron.New()
+//	c.Locker = &etcdlock.Locker{Client: client}
+//	c.AddNamedFunc("nightly-report", "0 0 2 * * *", report)
+package etcdlock
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+)
+
+// Locker records the last run of each name in an etcd key holding the run
+// time in milliseconds since the Unix epoch. Keys are updated in
+// transactions conditioned on their revision.
+type Locker struct {
+	// Client is the etcd client.
+	Client *clientv3.Client
+
+	// Prefix is prepended to names to build keys. If empty, "cron/" is used.
+	Prefix string
+
+	// TTL, if non-zero, attaches the keys to a lease with the TTL so that the
+	// records of removed entries expire. It must be longer than the period of
+	// the schedules, or runs may be repeated after the lease expires.
+	TTL time.Duration
+}
+
+func (l *Locker) key(name string) string {
+	if l.Prefix == "" {
+		return "cron/" + name
+	}
+	return l.Prefix + name
+}
+
+// Lock implements cron.Locker.
+func (l *Locker) Lock(ctx context.Context, name string, t time.Time) (ok bool, err error) {
+	key := l.key(name)
+	value := strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+
+	// The lease is granted once the key is to be updated and reused when the
+	// update is retried. It is revoked unless the key was attached to it.
+	lease := clientv3.NoLease
+	defer func() {
+		if lease != clientv3.NoLease && !ok {
+			l.Client.Revoke(ctx, lease)
+		}
+	}()
+
+	for {
+		last, rev, err := l.get(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		if !last.IsZero() && !last.Before(t) {
+			return false, nil
+		}
+
+		var opts []clientv3.OpOption
+		if l.TTL > 0 {
+			if lease == clientv3.NoLease {
+				resp, err := l.Client.Grant(ctx, int64((l.TTL+time.Second-1)/time.Second))
+				if err != nil {
+					return false, err
+				}
+				lease = resp.ID
+			}
+			opts = append(opts, clientv3.WithLease(lease))
+		}
+		resp, err := l.Client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", rev)).
+			Then(clientv3.OpPut(key, value, opts...)).
+			Commit()
+		if err != nil {
+			return false, err
+		}
+		if resp.Succeeded {
+			return true, nil
+		}
+		// The key changed since it was read, check the new run time.
+	}
+}
+
+// LastRun implements cron.Locker.
+func (l *Locker) LastRun(ctx context.Context, name string) (time.Time, error) {
+	last, _, err := l.get(ctx, l.key(name))
+	return last, err
+}
+
+// get returns the run time stored at key and the revision of the key. The
+// revision is zero if the key does not exist.
+func (l *Locker) get(ctx context.Context, key string) (time.Time, int64, error) {
+	resp, err := l.Client.Get(ctx, key)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return time.Time{}, 0, nil
+	}
+	kv := resp.Kvs[0]
+	ms, err := strconv.ParseInt(string(kv.Value), 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("etcdlock: invalid run time %q at %s", kv.Value, key)
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), kv.ModRevision, nil
+}
@@ -0,0 +1,139 @@
+package etcdlock
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// fakeKV is an in-memory clientv3.KV supporting the requests of Locker.
+type fakeKV struct {
+	clientv3.KV
+	rev  int64
+	kvs  map[string]*mvccpb.KeyValue
+	hook func() // called before the next commit, if set
+}
+
+func (kv *fakeKV) put(key, value string) {
+	kv.rev++
+	kv.kvs[key] = &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: kv.rev}
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	resp := &clientv3.GetResponse{}
+	if v, ok := kv.kvs[key]; ok {
+		resp.Kvs = []*mvccpb.KeyValue{v}
+	}
+	return resp, nil
+}
+
+func (kv *fakeKV) Txn(ctx context.Context) clientv3.Txn {
+	return &fakeTxn{kv: kv}
+}
+
+type fakeTxn struct {
+	kv   *fakeKV
+	cmps []clientv3.Cmp
+	ops  []clientv3.Op
+}
+
+func (txn *fakeTxn) If(cs ...clientv3.Cmp) clientv3.Txn   { txn.cmps = cs; return txn }
+func (txn *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn { txn.ops = ops; return txn }
+func (txn *fakeTxn) Else(ops ...clientv3.Op) clientv3.Txn { return txn }
+
+func (txn *fakeTxn) Commit() (*clientv3.TxnResponse, error) {
+	if hook := txn.kv.hook; hook != nil {
+		txn.kv.hook = nil
+		hook()
+	}
+	for _, cmp := range txn.cmps {
+		var rev int64
+		if v, ok := txn.kv.kvs[string(cmp.KeyBytes())]; ok {
+			rev = v.ModRevision
+		}
+		if rev != (*pb.Compare)(&cmp).GetModRevision() {
+			return &clientv3.TxnResponse{}, nil
+		}
+	}
+	for _, op := range txn.ops {
+		txn.kv.put(string(op.KeyBytes()), string(op.ValueBytes()))
+	}
+	return &clientv3.TxnResponse{Succeeded: true}, nil
+}
+
+// fakeLease counts the leases granted and revoked.
+type fakeLease struct {
+	clientv3.Lease
+	granted, revoked int
+}
+
+func (l *fakeLease) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	l.granted++
+	return &clientv3.LeaseGrantResponse{ID: clientv3.LeaseID(l.granted), TTL: ttl}, nil
+}
+
+func (l *fakeLease) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	l.revoked++
+	return &clientv3.LeaseRevokeResponse{}, nil
+}
+
+func newFakeLocker() (*Locker, *fakeKV, *fakeLease) {
+	kv := &fakeKV{kvs: make(map[string]*mvccpb.KeyValue)}
+	lease := &fakeLease{}
+	l := &Locker{Client: &clientv3.Client{KV: kv, Lease: lease}, TTL: time.Minute}
+	return l, kv, lease
+}
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+	l, _, lease := newFakeLocker()
+
+	run := time.Date(2019, 4, 17, 2, 0, 0, 0, time.UTC)
+	if ok, err := l.Lock(ctx, "job", run); !ok || err != nil {
+		t.Fatalf("Lock = %v, %v, expected true", ok, err)
+	}
+	if ok, err := l.Lock(ctx, "job", run); ok || err != nil {
+		t.Errorf("second Lock of run = %v, %v, expected false", ok, err)
+	}
+	if ok, err := l.Lock(ctx, "job", run.Add(time.Hour)); !ok || err != nil {
+		t.Errorf("Lock of later run = %v, %v, expected true", ok, err)
+	}
+	if last, err := l.LastRun(ctx, "job"); err != nil || !last.Equal(run.Add(time.Hour)) {
+		t.Errorf("LastRun = %v, %v, expected %v", last, err, run.Add(time.Hour))
+	}
+	if lease.granted != 2 || lease.revoked != 0 {
+		t.Errorf("granted %d and revoked %d leases, expected 2 and 0", lease.granted, lease.revoked)
+	}
+}
+
+// Change the key between the read and the update of Lock, expect the update
+// to be retried with the same lease.
+func TestLockerConflict(t *testing.T) {
+	ctx := context.Background()
+	run := time.Date(2019, 4, 17, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		other   time.Time // run recorded by another instance
+		ok      bool
+		revoked int
+	}{
+		{run.Add(-time.Hour), true, 0},
+		{run, false, 1},
+	}
+	for _, test := range tests {
+		l, kv, lease := newFakeLocker()
+		kv.hook = func() {
+			kv.put("cron/job", strconv.FormatInt(test.other.UnixNano()/int64(time.Millisecond), 10))
+		}
+		if ok, err := l.Lock(ctx, "job", run); ok != test.ok || err != nil {
+			t.Errorf("other run %v: Lock = %v, %v, expected %v", test.other, ok, err, test.ok)
+		}
+		if lease.granted != 1 || lease.revoked != test.revoked {
+			t.Errorf("other run %v: granted %d and revoked %d leases, expected 1 and %d", test.other, lease.granted, lease.revoked, test.revoked)
+		}
+	}
+}
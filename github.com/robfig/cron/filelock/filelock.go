@@ -0,0 +1,96 @@
+// Package filelock implements a cron.Locker backed by files, for Cron
+// instances running in several processes on the same host.
+//
+//	c := cron.New()
+//	c.Locker = &filelock.Locker{Dir: "/var/lib/myapp/cron"}
+//	c.AddNamedFunc("nightly-report", "0 0 2 * * *", report)
+package filelock
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Locker records the last run of each name in a file of Dir holding the run
+// time in milliseconds since the Unix epoch. Files are updated under an
+// exclusive advisory lock.
+type Locker struct {
+	// Dir is the directory holding the files. It is created if needed.
+	Dir string
+}
+
+func (l *Locker) open(name string) (*os.File, error) {
+	if err := os.MkdirAll(l.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(l.Dir, url.PathEscape(name)), os.O_RDWR|os.O_CREATE, 0644)
+}
+
+// Lock implements cron.Locker.
+func (l *Locker) Lock(ctx context.Context, name string, t time.Time) (bool, error) {
+	f, err := l.open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return false, err
+	}
+	defer unlockFile(f)
+
+	last, err := readRunTime(f)
+	if err != nil {
+		return false, err
+	}
+	if !last.IsZero() && !last.Before(t) {
+		return false, nil
+	}
+	value := strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	if err := f.Truncate(0); err != nil {
+		return false, err
+	}
+	if _, err := f.WriteAt([]byte(value), 0); err != nil {
+		return false, err
+	}
+	if err := f.Sync(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LastRun implements cron.Locker.
+func (l *Locker) LastRun(ctx context.Context, name string) (time.Time, error) {
+	f, err := l.open(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return time.Time{}, err
+	}
+	defer unlockFile(f)
+	return readRunTime(f)
+}
+
+func readRunTime(f *os.File) (time.Time, error) {
+	p, err := ioutil.ReadAll(f)
+	if err != nil {
+		return time.Time{}, err
+	}
+	p = bytes.TrimSpace(p)
+	if len(p) == 0 {
+		return time.Time{}, nil
+	}
+	ms, err := strconv.ParseInt(string(p), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("filelock: invalid run time %q in %s", p, f.Name())
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
+}
@@ -0,0 +1,60 @@
+package filelock
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filelock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	l := &Locker{Dir: dir}
+
+	last, err := l.LastRun(ctx, "a/b")
+	if err != nil || !last.IsZero() {
+		t.Fatalf("LastRun of new name = %v, %v, expected zero time", last, err)
+	}
+
+	run := time.Date(2019, 4, 17, 2, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	acquired := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each goroutine uses its own Locker, as separate processes would.
+			ok, err := (&Locker{Dir: dir}).Lock(ctx, "a/b", run)
+			if err != nil {
+				t.Error(err)
+			}
+			if ok {
+				mu.Lock()
+				acquired++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if acquired != 1 {
+		t.Errorf("run acquired %d times, expected once", acquired)
+	}
+
+	if ok, err := l.Lock(ctx, "a/b", run.Add(-time.Hour)); ok || err != nil {
+		t.Errorf("Lock of earlier run = %v, %v, expected false", ok, err)
+	}
+	if ok, err := l.Lock(ctx, "a/b", run.Add(time.Hour)); !ok || err != nil {
+		t.Errorf("Lock of later run = %v, %v, expected true", ok, err)
+	}
+	if last, err := l.LastRun(ctx, "a/b"); err != nil || !last.Equal(run.Add(time.Hour)) {
+		t.Errorf("LastRun = %v, %v, expected %v", last, err, run.Add(time.Hour))
+	}
+}
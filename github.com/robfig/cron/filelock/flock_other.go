@@ -0,0 +1,14 @@
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package filelock
+
+import (
+	"errors"
+	"os"
+)
+
+var errNotSupported = errors.New("filelock: file locking is not supported on this platform")
+
+func lockFile(f *os.File) error { return errNotSupported }
+
+func unlockFile(f *os.File) error { return errNotSupported }
@@ -3,6 +3,7 @@ package cron
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,14 +16,17 @@ import (
 type ParseOption int
 
 const (
-	Second      ParseOption = 1 << iota // Seconds field, default 0
-	Minute                              // Minutes field, default 0
-	Hour                                // Hours field, default 0
-	Dom                                 // Day of month field, default *
-	Month                               // Month field, default *
-	Dow                                 // Day of week field, default *
-	DowOptional                         // Optional day of week field, default *
-	Descriptor                          // Allow descriptors such as @monthly, @weekly, etc.
+	Second         ParseOption = 1 << iota // Seconds field, default 0
+	Minute                                 // Minutes field, default 0
+	Hour                                   // Hours field, default 0
+	Dom                                    // Day of month field, default *
+	Month                                  // Month field, default *
+	Dow                                    // Day of week field, default *
+	DowOptional                            // Optional day of week field, default *
+	Descriptor                             // Allow descriptors such as @monthly, @weekly, etc.
+	SecondOptional                         // Optional seconds field, default 0
+	Year                                   // Year field, default *
+	YearOptional                           // Optional year field, default *
 )
 
 var places = []ParseOption{
@@ -32,6 +36,7 @@ var places = []ParseOption{
 	Dom,
 	Month,
 	Dow,
+	Year,
 }
 
 var defaults = []string{
@@ -41,6 +46,17 @@ var defaults = []string{
 	"*",
 	"*",
 	"*",
+	"*",
+}
+
+// optionalPlaces lists the optional fields in the order they are assumed
+// missing from a spec with fewer fields than the parser accepts.
+var optionalPlaces = []struct {
+	optional, place ParseOption
+}{
+	{YearOptional, Year},
+	{SecondOptional, Second},
+	{DowOptional, Dow},
 }
 
 // A custom Parser that can be configured.
@@ -51,6 +67,10 @@ type Parser struct {
 
 // Creates a custom Parser with custom options.
 //
+// A spec may start with "CRON_TZ=<time zone>" (or "TZ=<time zone>") to
+// interpret the schedule in the named IANA time zone instead of the time zone
+// of the Cron.
+//
 //  // Standard parser without descriptors
 //  specParser := NewParser(Minute | Hour | Dom | Month | Dow)
 //  sched, err := specParser.Parse("0 0 15 */3 *")
@@ -63,11 +83,18 @@ type Parser struct {
 //  subsParser := NewParser(Dom | Month | DowOptional)
 //  sched, err := specParser.Parse("15 */3")
 //
+//  // Accepts 5 fields (standard), 6 fields (with seconds) and 7 fields
+//  // (with seconds and year)
+//  anyParser := NewParser(SecondOptional | Minute | Hour | Dom | Month | Dow | YearOptional | Descriptor)
+//  sched, err := anyParser.Parse("0 0 12 * * ? 2030")
+//
 func NewParser(options ParseOption) Parser {
 	optionals := 0
-	if options&DowOptional > 0 {
-		options |= Dow
-		optionals++
+	for _, o := range optionalPlaces {
+		if options&o.optional > 0 {
+			options |= o.place
+			optionals++
+		}
 	}
 	return Parser{options, optionals}
 }
@@ -79,6 +106,30 @@ func (p Parser) Parse(spec string) (Schedule, error) {
 	if len(spec) == 0 {
 		return nil, fmt.Errorf("Empty spec string")
 	}
+
+	// Extract the time zone if present.
+	var loc *time.Location
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		i := strings.IndexAny(spec, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("Missing schedule after time zone: %s", spec)
+		}
+		name := spec[strings.Index(spec, "=")+1 : i]
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("Provided bad location %s: %v", name, err)
+		}
+		spec = strings.TrimSpace(spec[i:])
+	}
+
+	schedule, err := p.parse(spec)
+	if err != nil || loc == nil {
+		return schedule, err
+	}
+	return locationSchedule{schedule, loc}, nil
+}
+
+func (p Parser) parse(spec string) (Schedule, error) {
 	if spec[0] == '@' && p.options&Descriptor > 0 {
 		return parseDescriptor(spec)
 	}
@@ -103,8 +154,18 @@ func (p Parser) Parse(spec string) (Schedule, error) {
 		return nil, fmt.Errorf("Expected %d to %d fields, found %d: %s", min, max, count, spec)
 	}
 
+	// Leave out the optional fields missing from the spec.
+	options := p.options
+	missing := max - len(fields)
+	for _, o := range optionalPlaces {
+		if missing > 0 && options&o.optional > 0 {
+			options &^= o.place
+			missing--
+		}
+	}
+
 	// Fill in missing fields
-	fields = expandFields(fields, p.options)
+	fields = expandFields(fields, options)
 
 	var err error
 	field := func(field string, r bounds) uint64 {
@@ -127,15 +188,23 @@ func (p Parser) Parse(spec string) (Schedule, error) {
 	if err != nil {
 		return nil, err
 	}
+	yearList, err := getYears(fields[6])
+	if err != nil {
+		return nil, err
+	}
 
-	return &SpecSchedule{
+	schedule := &SpecSchedule{
 		Second: second,
 		Minute: minute,
 		Hour:   hour,
 		Dom:    dayofmonth,
 		Month:  month,
 		Dow:    dayofweek,
-	}, nil
+	}
+	if yearList != nil {
+		return yearSchedule{schedule, yearList}, nil
+	}
+	return schedule, nil
 }
 
 func expandFields(fields []string, options ParseOption) []string {
@@ -205,23 +274,58 @@ func getField(field string, r bounds) (uint64, error) {
 //   number | number "-" number [ "/" number ]
 // or error parsing range.
 func getRange(expr string, r bounds) (uint64, error) {
+	start, end, step, star, err := parseRange(expr, r)
+	if err != nil {
+		return 0, err
+	}
+	var extra uint64
+	if star {
+		extra = starBit
+	}
+	return getBits(start, end, step) | extra, nil
+}
+
+// getYears returns the sorted years the given field represents, or nil if the
+// field matches every year.
+func getYears(field string) ([]int, error) {
+	if field == "*" || field == "?" {
+		return nil, nil
+	}
+	set := make(map[int]bool)
+	for _, expr := range strings.FieldsFunc(field, func(r rune) bool { return r == ',' }) {
+		start, end, step, _, err := parseRange(expr, years)
+		if err != nil {
+			return nil, err
+		}
+		for y := start; y <= end; y += step {
+			set[int(y)] = true
+		}
+	}
+	result := make([]int, 0, len(set))
+	for y := range set {
+		result = append(result, y)
+	}
+	sort.Ints(result)
+	return result, nil
+}
+
+// parseRange returns the start, end and step of the given range expression
+// and whether the expression is a star.
+func parseRange(expr string, r bounds) (start, end, step uint, star bool, err error) {
 	var (
-		start, end, step uint
-		rangeAndStep     = strings.Split(expr, "/")
-		lowAndHigh       = strings.Split(rangeAndStep[0], "-")
-		singleDigit      = len(lowAndHigh) == 1
-		err              error
+		rangeAndStep = strings.Split(expr, "/")
+		lowAndHigh   = strings.Split(rangeAndStep[0], "-")
+		singleDigit  = len(lowAndHigh) == 1
 	)
 
-	var extra uint64
 	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
 		start = r.min
 		end = r.max
-		extra = starBit
+		star = true
 	} else {
 		start, err = parseIntOrName(lowAndHigh[0], r.names)
 		if err != nil {
-			return 0, err
+			return
 		}
 		switch len(lowAndHigh) {
 		case 1:
@@ -229,10 +333,11 @@ func getRange(expr string, r bounds) (uint64, error) {
 		case 2:
 			end, err = parseIntOrName(lowAndHigh[1], r.names)
 			if err != nil {
-				return 0, err
+				return
 			}
 		default:
-			return 0, fmt.Errorf("Too many hyphens: %s", expr)
+			err = fmt.Errorf("Too many hyphens: %s", expr)
+			return
 		}
 	}
 
@@ -242,7 +347,7 @@ func getRange(expr string, r bounds) (uint64, error) {
 	case 2:
 		step, err = mustParseInt(rangeAndStep[1])
 		if err != nil {
-			return 0, err
+			return
 		}
 
 		// Special handling: "N/step" means "N-max/step".
@@ -250,23 +355,21 @@ func getRange(expr string, r bounds) (uint64, error) {
 			end = r.max
 		}
 	default:
-		return 0, fmt.Errorf("Too many slashes: %s", expr)
+		err = fmt.Errorf("Too many slashes: %s", expr)
+		return
 	}
 
-	if start < r.min {
-		return 0, fmt.Errorf("Beginning of range (%d) below minimum (%d): %s", start, r.min, expr)
+	switch {
+	case start < r.min:
+		err = fmt.Errorf("Beginning of range (%d) below minimum (%d): %s", start, r.min, expr)
+	case end > r.max:
+		err = fmt.Errorf("End of range (%d) above maximum (%d): %s", end, r.max, expr)
+	case start > end:
+		err = fmt.Errorf("Beginning of range (%d) beyond end of range (%d): %s", start, end, expr)
+	case step == 0:
+		err = fmt.Errorf("Step of range should be a positive number: %s", expr)
 	}
-	if end > r.max {
-		return 0, fmt.Errorf("End of range (%d) above maximum (%d): %s", end, r.max, expr)
-	}
-	if start > end {
-		return 0, fmt.Errorf("Beginning of range (%d) beyond end of range (%d): %s", start, end, expr)
-	}
-	if step == 0 {
-		return 0, fmt.Errorf("Step of range should be a positive number: %s", expr)
-	}
-
-	return getBits(start, end, step) | extra, nil
+	return
 }
 
 // parseIntOrName returns the (possibly-named) integer contained in expr.
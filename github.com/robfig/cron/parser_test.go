@@ -195,6 +195,73 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestOptionalFields(t *testing.T) {
+	parser := NewParser(SecondOptional | Minute | Hour | Dom | Month | Dow | YearOptional | Descriptor)
+	standard := &SpecSchedule{1 << seconds.min, 1 << 5, all(hours), all(dom), all(months), all(dow)}
+	entries := []struct {
+		expr     string
+		expected Schedule
+		err      string
+	}{
+		{"5 * * * *", standard, ""},
+		{"0 5 * * * *", standard, ""},
+		{"0 5 * * * * *", standard, ""},
+		{"0 5 * * * * 2020,2018-2019", yearSchedule{standard, []int{2018, 2019, 2020}}, ""},
+		{"0 5 * * * * 2020/40", yearSchedule{standard, []int{2020, 2060}}, ""},
+		{"0 5 * * * * 1969", nil, "below minimum"},
+		{"* * * *", nil, "Expected 5 to 7 fields"},
+	}
+
+	for _, c := range entries {
+		actual, err := parser.Parse(c.expr)
+		if len(c.err) != 0 && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s => expected %v, got %v", c.expr, c.err, err)
+		}
+		if len(c.err) == 0 && err != nil {
+			t.Errorf("%s => unexpected error %v", c.expr, err)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s => expected %b, got %b", c.expr, c.expected, actual)
+		}
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	spec := &SpecSchedule{
+		Second: 1 << seconds.min,
+		Minute: 1 << 30,
+		Hour:   1 << 4,
+		Dom:    all(dom),
+		Month:  all(months),
+		Dow:    all(dow),
+	}
+	entries := []struct {
+		expr     string
+		expected Schedule
+		err      string
+	}{
+		{"CRON_TZ=Asia/Tokyo 0 30 4 * * *", locationSchedule{spec, tokyo}, ""},
+		{"TZ=Asia/Tokyo 0 30 4 * * *", locationSchedule{spec, tokyo}, ""},
+		{"CRON_TZ=Asia/Tokyo @every 5m", locationSchedule{ConstantDelaySchedule{5 * time.Minute}, tokyo}, ""},
+		{"CRON_TZ=Mars/Olympus 0 30 4 * * *", nil, "Provided bad location"},
+		{"CRON_TZ=Asia/Tokyo", nil, "Missing schedule"},
+	}
+
+	for _, c := range entries {
+		actual, err := Parse(c.expr)
+		if len(c.err) != 0 && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s => expected %v, got %v", c.expr, c.err, err)
+		}
+		if len(c.err) == 0 && err != nil {
+			t.Errorf("%s => unexpected error %v", c.expr, err)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s => expected %v, got %v", c.expr, c.expected, actual)
+		}
+	}
+}
+
 func TestStandardSpecSchedule(t *testing.T) {
 	entries := []struct {
 		expr     string
@@ -0,0 +1,81 @@
+// Package redislock implements a cron.Locker backed by Redis.
+//
+//	c := cron.New()
+//	c.Locker = &redislock.Locker{Pool: pool}
+//	c.AddNamedFunc("nightly-report", "0 0 2 * * *", report)
+package redislock
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// lockScript sets the key to the run time if the key is unset or holds an
+// earlier run time.
+var lockScript = redis.NewScript(1, `
+local last = tonumber(redis.call("GET", KEYS[1]))
+if last and last >= tonumber(ARGV[1]) then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+else
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+return 1`)
+
+// Locker records the last run of each name in a Redis key holding the run
+// time in milliseconds since the Unix epoch.
+type Locker struct {
+	// Pool is the pool used to get connections.
+	Pool *redis.Pool
+
+	// Prefix is prepended to names to build keys. If empty, "cron:" is used.
+	Prefix string
+
+	// TTL, if non-zero, expires the keys so that the records of removed
+	// entries do not accumulate. It must be longer than the period of the
+	// schedules, or runs may be repeated after the key expires.
+	TTL time.Duration
+}
+
+func (l *Locker) key(name string) string {
+	if l.Prefix == "" {
+		return "cron:" + name
+	}
+	return l.Prefix + name
+}
+
+// Lock implements cron.Locker.
+func (l *Locker) Lock(ctx context.Context, name string, t time.Time) (bool, error) {
+	c, err := l.Pool.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer c.Close()
+	n, err := redis.Int(lockScript.Do(c, l.key(name), millis(t), int64(l.TTL/time.Millisecond)))
+	return n == 1, err
+}
+
+// LastRun implements cron.Locker.
+func (l *Locker) LastRun(ctx context.Context, name string) (time.Time, error) {
+	c, err := l.Pool.GetContext(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer c.Close()
+	ms, err := redis.Int64(c.Do("GET", l.key(name)))
+	if err == redis.ErrNil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,43 @@
+package redislock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestLocker(t *testing.T) {
+	c, err := redis.Dial("tcp", ":6379", redis.DialDatabase(9))
+	if err != nil {
+		t.Skipf("no redis server: %v", err)
+	}
+	c.Close()
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) {
+		return redis.Dial("tcp", ":6379", redis.DialDatabase(9))
+	}}
+	defer pool.Close()
+
+	ctx := context.Background()
+	l := &Locker{Pool: pool, Prefix: "redislock-test:", TTL: time.Minute}
+	defer func() {
+		c := pool.Get()
+		c.Do("DEL", "redislock-test:job")
+		c.Close()
+	}()
+
+	run := time.Date(2019, 4, 17, 2, 0, 0, 0, time.UTC)
+	if ok, err := l.Lock(ctx, "job", run); !ok || err != nil {
+		t.Fatalf("Lock = %v, %v, expected true", ok, err)
+	}
+	if ok, err := l.Lock(ctx, "job", run); ok || err != nil {
+		t.Errorf("second Lock of run = %v, %v, expected false", ok, err)
+	}
+	if ok, err := l.Lock(ctx, "job", run.Add(time.Hour)); !ok || err != nil {
+		t.Errorf("Lock of later run = %v, %v, expected true", ok, err)
+	}
+	if last, err := l.LastRun(ctx, "job"); err != nil || !last.Equal(run.Add(time.Hour)) {
+		t.Errorf("LastRun = %v, %v, expected %v", last, err, run.Add(time.Hour))
+	}
+}
@@ -1,6 +1,9 @@
 package cron
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 // SpecSchedule specifies a duty cycle (to the second granularity), based on a
 // traditional crontab specification. It is computed initially and stored as bit sets.
@@ -43,6 +46,7 @@ var (
 		"fri": 5,
 		"sat": 6,
 	}}
+	years = bounds{1970, 2099, nil}
 )
 
 const (
@@ -156,3 +160,54 @@ func dayMatches(s *SpecSchedule, t time.Time) bool {
 	}
 	return domMatch || dowMatch
 }
+
+// yearSchedule restricts a schedule to the given sorted years.
+type yearSchedule struct {
+	Schedule
+	years []int
+}
+
+// Next returns the next activation time of the schedule in one of the years.
+func (s yearSchedule) Next(t time.Time) time.Time {
+	for {
+		year := s.nextYear(t.Year())
+		if year == 0 {
+			return time.Time{}
+		}
+		if year != t.Year() {
+			// Start just before the first instant of the year.
+			t = time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location()).Add(-time.Nanosecond)
+		}
+		next := s.Schedule.Next(t)
+		if next.IsZero() || s.nextYear(next.Year()) == next.Year() {
+			return next
+		}
+		t = next
+	}
+}
+
+// nextYear returns the first year of the schedule not before year, or zero
+// if there is none.
+func (s yearSchedule) nextYear(year int) int {
+	i := sort.SearchInts(s.years, year)
+	if i == len(s.years) {
+		return 0
+	}
+	return s.years[i]
+}
+
+// locationSchedule evaluates a schedule in a fixed time zone.
+type locationSchedule struct {
+	Schedule
+	location *time.Location
+}
+
+// Next returns the next activation time of the schedule, in the time zone of
+// the given time.
+func (s locationSchedule) Next(t time.Time) time.Time {
+	next := s.Schedule.Next(t.In(s.location))
+	if next.IsZero() {
+		return next
+	}
+	return next.In(t.Location())
+}
@@ -230,6 +230,59 @@ func TestNextWithTz(t *testing.T) {
 	}
 }
 
+func TestNextWithSpecTz(t *testing.T) {
+	runs := []struct {
+		time, spec string
+		expected   string
+	}{
+		// 04:30 in Tokyo is 19:30 the day before in UTC.
+		{"2016-01-03T13:09:03+0000", "CRON_TZ=Asia/Tokyo 0 30 4 * * *", "2016-01-03T19:30:00+0000"},
+		{"2016-01-03T20:09:03+0000", "TZ=Asia/Tokyo 0 30 4 * * *", "2016-01-04T19:30:00+0000"},
+		{"2016-01-03T13:09:03+0530", "CRON_TZ=UTC 0 0 12 * * *", "2016-01-03T17:30:00+0530"},
+	}
+	for _, c := range runs {
+		sched, err := Parse(c.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		actual := sched.Next(getTimeTZ(c.time))
+		expected := getTimeTZ(c.expected)
+		_, actualOffset := actual.Zone()
+		_, expectedOffset := expected.Zone()
+		if !actual.Equal(expected) || actualOffset != expectedOffset {
+			t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+		}
+	}
+}
+
+func TestNextWithYear(t *testing.T) {
+	parser := NewParser(Second | Minute | Hour | Dom | Month | Dow | YearOptional)
+	runs := []struct {
+		time, spec string
+		expected   string
+	}{
+		{"Mon Jul 9 14:45 2012", "0 0 0 1 1 * 2012", ""},
+		{"Mon Jul 9 14:45 2012", "0 0 0 1 1 * 2012-2014", "Wed Jan 1 00:00 2013"},
+		{"Mon Jul 9 14:45 2012", "0 0 0 1 1 * 2020,2030", "Wed Jan 1 00:00 2020"},
+		{"Mon Jul 9 14:45 2012", "0 0 12 * * * 2012", "Tue Jul 10 12:00 2012"},
+		{"Mon Dec 31 14:45 2012", "0 0 12 * * * 2012,2040", "Sun Jan 1 12:00 2040"},
+		{"Mon Jul 9 14:45 2012", "0 0 0 29 2 * 2013-2015", ""},
+	}
+	for _, c := range runs {
+		sched, err := parser.Parse(c.spec)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		actual := sched.Next(getTime(c.time))
+		expected := getTime(c.expected)
+		if !actual.Equal(expected) {
+			t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+		}
+	}
+}
+
 func getTimeTZ(value string) time.Time {
 	if value == "" {
 		return time.Time{}